@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/bootstrap"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/fuse"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// MountCmd represents the mount command
+var MountCmd = &cobra.Command{
+	Use:   "mount [openlist path] [mount point]",
+	Short: "Mount a path of OpenList to the local file system with FUSE",
+	Long: `Mount a path of OpenList to the local file system with FUSE.
+Files written to the mount point are staged in the temp directory
+and uploaded to the storage when they are closed.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		bootstrap.Init()
+		defer bootstrap.Release()
+		username, _ := cmd.Flags().GetString("user")
+		if username == "" {
+			username = "admin"
+		}
+		user, err := op.GetUserByName(username)
+		if err != nil {
+			return fmt.Errorf("failed to get user: %+v", err)
+		}
+		mountSrc, err := user.JoinPath(args[0])
+		if err != nil {
+			return err
+		}
+		stagingDir, _ := cmd.Flags().GetString("staging-dir")
+		if stagingDir == "" {
+			stagingDir = filepath.Join(conf.Conf.TempDir, "fuse")
+		}
+		opts, _ := cmd.Flags().GetStringArray("option")
+		fuseOpts := make([]string, 0, len(opts)*2)
+		for _, o := range opts {
+			fuseOpts = append(fuseOpts, "-o", o)
+		}
+
+		bootstrap.LoadStorages()
+		<-conf.StoragesLoadSignal()
+
+		ctx, cancel := context.WithCancel(context.WithValue(context.Background(), conf.UserKey, user))
+		defer cancel()
+		quit := make(chan os.Signal, 1)
+		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+		go func() {
+			<-quit
+			cancel()
+		}()
+		utils.Log.Infof("mount [%s] to [%s]", mountSrc, args[1])
+		return fuse.Mount(ctx, mountSrc, args[1], stagingDir, fuseOpts)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(MountCmd)
+	MountCmd.Flags().String("user", "admin", "mount as the given user, the user's base path is applied")
+	MountCmd.Flags().String("staging-dir", "", "directory for staging files before upload (default: [temp dir]/fuse)")
+	MountCmd.Flags().StringArrayP("option", "o", nil, "fuse mount option, can be repeated")
+}
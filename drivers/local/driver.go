@@ -356,6 +356,31 @@ func (d *Local) Copy(_ context.Context, srcObj, dstDir model.Obj) error {
 	return nil
 }
 
+func (d *Local) HardLink(_ context.Context, srcObj, dstObj model.Obj) error {
+	srcPath, dstPath := srcObj.GetPath(), dstObj.GetPath()
+	srcInfo, err := os.Stat(srcPath)
+	if err != nil {
+		return err
+	}
+	dstInfo, err := os.Stat(dstPath)
+	if err != nil {
+		return err
+	}
+	if os.SameFile(srcInfo, dstInfo) {
+		return nil
+	}
+	// link beside dst first so that dst is only replaced once the link succeeds
+	tmpPath := filepath.Join(filepath.Dir(dstPath), fmt.Sprintf(".%s.link-%d", filepath.Base(dstPath), time.Now().UnixNano()))
+	if err = os.Link(srcPath, tmpPath); err != nil {
+		return err
+	}
+	if err = os.Rename(tmpPath, dstPath); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
 func (d *Local) Remove(ctx context.Context, obj model.Obj) error {
 	var err error
 	if utils.SliceContains([]string{"", "delete permanently"}, d.RecycleBinPath) {
@@ -444,3 +469,4 @@ func (d *Local) GetDetails(ctx context.Context) (*model.StorageDetails, error) {
 }
 
 var _ driver.Driver = (*Local)(nil)
+var _ driver.HardLink = (*Local)(nil)
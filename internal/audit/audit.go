@@ -0,0 +1,147 @@
+// Package audit records who did what, from where and whether it worked.
+// Entries are queued and written to the database in batches, so that
+// recording never slows down the operation itself.
+package audit
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	queueSize = 1024
+	batchSize = 100
+)
+
+var (
+	queue   chan *model.AuditLog
+	queueMu sync.RWMutex
+	stopped chan struct{}
+)
+
+// Start starts writing the recorded entries, entries recorded before are dropped
+func Start() {
+	queueMu.Lock()
+	defer queueMu.Unlock()
+	if queue != nil {
+		return
+	}
+	queue = make(chan *model.AuditLog, queueSize)
+	stopped = make(chan struct{})
+	go write(queue, stopped)
+}
+
+// Stop writes the queued entries and returns once they are stored
+func Stop() {
+	queueMu.Lock()
+	if queue == nil {
+		queueMu.Unlock()
+		return
+	}
+	close(queue)
+	queue = nil
+	queueMu.Unlock()
+	<-stopped
+}
+
+func write(q chan *model.AuditLog, stopped chan struct{}) {
+	defer close(stopped)
+	for e := range q {
+		batch := []*model.AuditLog{e}
+	drain:
+		for len(batch) < batchSize {
+			select {
+			case e, ok := <-q:
+				if !ok {
+					break drain
+				}
+				batch = append(batch, e)
+			default:
+				break drain
+			}
+		}
+		if err := db.CreateAuditLogs(batch); err != nil {
+			log.Errorf("failed write %d audit logs: %+v", len(batch), err)
+		}
+	}
+}
+
+// Record completes e with the time and what ctx knows about the request, then queues it.
+// The user of ctx is only used when e names none, err decides the outcome and is added to the detail.
+func Record(ctx context.Context, e *model.AuditLog, err error) {
+	if !setting.GetBool(conf.AuditEnabled) {
+		return
+	}
+	e.Time = time.Now()
+	if e.Username == "" {
+		if user, ok := ctx.Value(conf.UserKey).(*model.User); ok {
+			e.UserId, e.Username = user.ID, user.Username
+		}
+	}
+	if e.Protocol == "" {
+		e.Protocol, _ = ctx.Value(conf.ProtocolKey).(string)
+	}
+	if e.IP == "" {
+		e.IP, _ = ctx.Value(conf.ClientIPKey).(string)
+	}
+	// ftp and sftp know the remote address only
+	if host, _, err := net.SplitHostPort(e.IP); err == nil {
+		e.IP = host
+	}
+	e.Success = err == nil
+	if err != nil {
+		if e.Detail != "" {
+			e.Detail += ": "
+		}
+		e.Detail += err.Error()
+	}
+	queueMu.RLock()
+	defer queueMu.RUnlock()
+	if queue == nil {
+		return
+	}
+	select {
+	case queue <- e:
+	default:
+		log.Warnf("audit queue is full, dropped %s of %s", e.Operation, e.Path)
+	}
+}
+
+// Log records an operation on path, dstPath is set by the operations having a destination
+func Log(ctx context.Context, operation, path, dstPath string, err error) {
+	Record(ctx, &model.AuditLog{Operation: operation, Path: path, DstPath: dstPath}, err)
+}
+
+// LogDownload records a read of the file at path of size bytes
+func LogDownload(ctx context.Context, path string, size int64, err error) {
+	Record(ctx, &model.AuditLog{Operation: model.AuditDownload, Path: path, Size: size}, err)
+}
+
+// Purge deletes the entries older than the retention
+func Purge() error {
+	days := setting.GetInt(conf.AuditRetentionDays, 90)
+	if days <= 0 {
+		return nil
+	}
+	n, err := db.DeleteAuditLogsBefore(time.Now().AddDate(0, 0, -days))
+	if n > 0 {
+		log.Infof("purged %d audit logs older than %d days", n, days)
+	}
+	return err
+}
+
+func GetAuditLogs(q *model.AuditLogQuery) ([]model.AuditLog, int64, error) {
+	return db.GetAuditLogs(q)
+}
+
+func EachAuditLogs(q *model.AuditLogQuery, fn func(logs []model.AuditLog) error) error {
+	return db.EachAuditLogs(q, fn)
+}
@@ -0,0 +1,29 @@
+package bootstrap
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+	log "github.com/sirupsen/logrus"
+)
+
+var auditPurgeCron *cron.Cron
+
+func InitAudit() {
+	audit.Start()
+	auditPurgeCron = cron.NewCron(time.Hour)
+	auditPurgeCron.Do(func() {
+		if err := audit.Purge(); err != nil {
+			log.Errorf("failed purge audit logs: %+v", err)
+		}
+	})
+}
+
+func releaseAudit() {
+	if auditPurgeCron != nil {
+		auditPurgeCron.Stop()
+		auditPurgeCron = nil
+	}
+	audit.Stop()
+}
@@ -0,0 +1,50 @@
+package bootstrap
+
+import (
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/cache"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	log "github.com/sirupsen/logrus"
+)
+
+var cacheBackend cache.Backend
+
+func InitCache() {
+	c := conf.Conf.Cache
+	var err error
+	switch strings.ToLower(c.Backend) {
+	case "", "memory":
+		return
+	case "disk":
+		cacheBackend, err = cache.NewBoltBackend(c.DiskFile)
+	case "redis":
+		cacheBackend, err = cache.NewRedisBackend(cache.RedisConfig{
+			Address:  c.Redis.Address,
+			Password: c.Redis.Password,
+			DB:       c.Redis.DB,
+			Prefix:   c.Redis.Prefix,
+		})
+	default:
+		log.Errorf("unknown cache backend: %s, fallback to memory", c.Backend)
+		return
+	}
+	if err != nil {
+		log.Errorf("failed to init %s cache backend, fallback to memory: %+v", c.Backend, err)
+		return
+	}
+	op.Cache.SetBackend(cacheBackend)
+	log.Infof("init %s cache backend", c.Backend)
+}
+
+func releaseCache() {
+	if cacheBackend == nil {
+		return
+	}
+	op.Cache.SetBackend(nil)
+	if err := cacheBackend.Close(); err != nil {
+		log.Errorf("failed to close cache backend: %+v", err)
+	}
+	cacheBackend = nil
+}
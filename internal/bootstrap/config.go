@@ -134,6 +134,7 @@ func InitConfig() {
 	convertAbsPath(&conf.Conf.Log.Name)
 	convertAbsPath(&conf.Conf.TempDir)
 	convertAbsPath(&conf.Conf.BleveDir)
+	convertAbsPath(&conf.Conf.Cache.DiskFile)
 	convertAbsPath(&conf.Conf.DistDir)
 
 	err := os.MkdirAll(conf.Conf.TempDir, 0o777)
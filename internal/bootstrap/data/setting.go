@@ -180,9 +180,18 @@ func InitialSettings() []model.SettingItem {
 		{Key: conf.HandleHookAfterWriting, Value: "false", Type: conf.TypeBool, Group: model.GLOBAL, Flag: model.PRIVATE},
 		{Key: conf.HandleHookRateLimit, Value: "0", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE},
 		{Key: conf.IgnoreSystemFiles, Value: "false", Type: conf.TypeBool, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `When enabled, ignores common system files during upload (.DS_Store, desktop.ini, Thumbs.db, and files starting with ._)`},
+		{Key: conf.TransferVerify, Value: "false", Type: conf.TypeBool, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `When enabled, every file copied or moved between storages is checked against its source by size and hash, the source of a move is only removed once verified`},
+		{Key: conf.TrashEnabled, Value: "false", Type: conf.TypeBool, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `When enabled, removed objects are moved into the trash dir of their storage instead of being deleted permanently`},
+		{Key: conf.TrashDir, Value: ".openlist_trash", Type: conf.TypeString, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `name of the trash dir created under the root of each storage`},
+		{Key: conf.TrashAutoPurgeDays, Value: "30", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `purge trashed objects older than this many days, 0 to keep forever`},
+		{Key: conf.VersionDir, Value: ".openlist_versions", Type: conf.TypeString, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `name of the dir created under the root of each storage to keep old file versions, enable versioning per path in metas`},
+		{Key: conf.AuditEnabled, Value: "true", Type: conf.TypeBool, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `record file operations, downloads, share access, logins and admin changes in the audit log`},
+		{Key: conf.AuditRetentionDays, Value: "90", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `delete audit log entries older than this many days, 0 to keep forever`},
+		{Key: conf.WebhookDeliveryRetentionDays, Value: "30", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `delete webhook deliveries older than this many days, 0 to keep forever`},
 
 		// single settings
 		{Key: conf.Token, Value: token, Type: conf.TypeString, Group: model.SINGLE, Flag: model.PRIVATE},
+		{Key: conf.APITokenKey, Value: random.String(64), Type: conf.TypeString, Group: model.SINGLE, Flag: model.PRIVATE},
 		{Key: conf.SearchIndex, Value: "none", Type: conf.TypeSelect, Options: "database,database_non_full_text,bleve,meilisearch,none", Group: model.INDEX},
 		{Key: conf.AutoUpdateIndex, Value: "false", Type: conf.TypeBool, Group: model.INDEX},
 		{Key: conf.IgnorePaths, Value: "", Type: conf.TypeText, Group: model.INDEX, Flag: model.PRIVATE, Help: `one path per line`},
@@ -204,6 +213,7 @@ func InitialSettings() []model.SettingItem {
 		{Key: conf.SSODefaultDir, Value: "/", Type: conf.TypeString, Group: model.SSO, Flag: model.PRIVATE},
 		{Key: conf.SSODefaultPermission, Value: "0", Type: conf.TypeNumber, Group: model.SSO, Flag: model.PRIVATE},
 		{Key: conf.SSOCompatibilityMode, Value: "false", Type: conf.TypeBool, Group: model.SSO, Flag: model.PUBLIC},
+		{Key: conf.SSOOIDCGroupsKey, Value: "groups", Type: conf.TypeString, Group: model.SSO, Flag: model.PRIVATE},
 
 		// ldap settings
 		{Key: conf.LdapLoginEnabled, Value: "false", Type: conf.TypeBool, Group: model.LDAP, Flag: model.PUBLIC},
@@ -216,11 +226,22 @@ func InitialSettings() []model.SettingItem {
 		{Key: conf.LdapDefaultDir, Value: "/", Type: conf.TypeString, Group: model.LDAP, Flag: model.PRIVATE},
 		{Key: conf.LdapDefaultPermission, Value: "0", Type: conf.TypeNumber, Group: model.LDAP, Flag: model.PRIVATE},
 		{Key: conf.LdapLoginTips, Value: "login with ldap", Type: conf.TypeString, Group: model.LDAP, Flag: model.PUBLIC},
+		{Key: conf.LdapGroupAttribute, Value: "memberOf", Type: conf.TypeString, Group: model.LDAP, Flag: model.PRIVATE},
+
+		// smtp settings
+		{Key: conf.SMTPHost, Value: "", Type: conf.TypeString, Group: model.SMTP, Flag: model.PRIVATE},
+		{Key: conf.SMTPPort, Value: "587", Type: conf.TypeNumber, Group: model.SMTP, Flag: model.PRIVATE},
+		{Key: conf.SMTPEncryption, Value: "starttls", Type: conf.TypeSelect, Options: "starttls,ssl,none", Group: model.SMTP, Flag: model.PRIVATE},
+		{Key: conf.SMTPUsername, Value: "", Type: conf.TypeString, Group: model.SMTP, Flag: model.PRIVATE},
+		{Key: conf.SMTPPassword, Value: "", Type: conf.TypeString, Group: model.SMTP, Flag: model.PRIVATE},
+		{Key: conf.SMTPFrom, Value: "", Type: conf.TypeString, Group: model.SMTP, Flag: model.PRIVATE, Help: `the sender address, the username if empty`},
 
 		// s3 settings
 		{Key: conf.S3AccessKeyId, Value: "", Type: conf.TypeString, Group: model.S3, Flag: model.PRIVATE},
 		{Key: conf.S3SecretAccessKey, Value: "", Type: conf.TypeString, Group: model.S3, Flag: model.PRIVATE},
 		{Key: conf.S3Buckets, Value: "[]", Type: conf.TypeString, Group: model.S3, Flag: model.PRIVATE},
+		{Key: conf.S3MultipartExpire, Value: "24", Type: conf.TypeNumber, Group: model.S3, Flag: model.PRIVATE, Help: `hours after which unfinished multipart uploads are removed`},
+		{Key: conf.S3MultipartLimit, Value: "0", Type: conf.TypeNumber, Group: model.S3, Flag: model.PRIVATE, Help: `max size in MB of the parts staged on disk, 0 is unlimited`},
 
 		// ftp settings
 		{Key: conf.FTPPublicHost, Value: "127.0.0.1", Type: conf.TypeString, Group: model.FTP, Flag: model.PRIVATE},
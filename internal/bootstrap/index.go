@@ -1,7 +1,10 @@
 package bootstrap
 
 import (
+	"time"
+
 	"github.com/OpenListTeam/OpenList/v4/internal/search"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -16,3 +19,19 @@ func InitIndex() {
 		search.WriteProgress(progress)
 	}
 }
+
+var indexScheduleCron *cron.Cron
+
+func InitIndexScheduler() {
+	indexScheduleCron = cron.NewCron(time.Minute)
+	indexScheduleCron.Do(func() {
+		search.ScheduleStorageIndexes(time.Now())
+	})
+}
+
+func releaseIndexScheduler() {
+	if indexScheduleCron != nil {
+		indexScheduleCron.Stop()
+		indexScheduleCron = nil
+	}
+}
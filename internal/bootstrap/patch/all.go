@@ -6,6 +6,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/bootstrap/patch/v3_41_0"
 	"github.com/OpenListTeam/OpenList/v4/internal/bootstrap/patch/v4_1_8"
 	"github.com/OpenListTeam/OpenList/v4/internal/bootstrap/patch/v4_1_9"
+	"github.com/OpenListTeam/OpenList/v4/internal/bootstrap/patch/v4_2_0"
 )
 
 type VersionPatches struct {
@@ -47,4 +48,10 @@ var UpgradePatches = []VersionPatches{
 			v4_1_9.ResetSkipTlsVerify,
 		},
 	},
+	{
+		Version: "v4.2.0",
+		Patches: []func(){
+			v4_2_0.HashSharingPwd,
+		},
+	},
 }
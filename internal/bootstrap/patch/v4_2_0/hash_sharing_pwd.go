@@ -0,0 +1,26 @@
+package v4_2_0
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+// HashSharingPwd hashes the passwords of the sharings, they were stored in plain text
+func HashSharingPwd() {
+	sharings, _, err := db.GetSharings(1, -1)
+	if err != nil {
+		utils.Log.Errorf("[HashSharingPwd] failed to get sharings: %s", err.Error())
+		return
+	}
+	for i := range sharings {
+		s := model.Sharing{SharingDB: &sharings[i]}
+		if s.Pwd == "" {
+			continue
+		}
+		s.SetPwd(s.Pwd)
+		if err := db.UpdateSharing(s.SharingDB); err != nil {
+			utils.Log.Errorf("[HashSharingPwd] failed to update sharing %s: %s", s.ID, err.Error())
+		}
+	}
+}
@@ -33,13 +33,23 @@ func Init() {
 	InitConfig()
 	Log()
 	InitDB()
+	InitCache()
 	data.InitData()
 	InitStreamLimit()
 	InitIndex()
+	InitTrash()
+	InitAudit()
+	InitWebhook()
 	InitUpgradePatch()
 }
 
 func Release() {
+	releaseSyncScheduler()
+	releaseIndexScheduler()
+	releaseTrash()
+	releaseWebhook()
+	releaseAudit()
+	releaseCache()
 	db.Close()
 }
 
@@ -92,6 +102,8 @@ func Start() {
 	InitOfflineDownloadTools()
 	LoadStorages()
 	InitTaskManager()
+	InitSyncScheduler()
+	InitIndexScheduler()
 	if !flags.Debug && !flags.Dev {
 		gin.SetMode(gin.ReleaseMode)
 	}
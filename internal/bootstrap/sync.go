@@ -0,0 +1,24 @@
+package bootstrap
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+)
+
+var syncScheduleCron *cron.Cron
+
+func InitSyncScheduler() {
+	syncScheduleCron = cron.NewCron(time.Minute)
+	syncScheduleCron.Do(func() {
+		fs.ScheduleSyncJobs(time.Now())
+	})
+}
+
+func releaseSyncScheduler() {
+	if syncScheduleCron != nil {
+		syncScheduleCron.Stop()
+		syncScheduleCron = nil
+	}
+}
@@ -3,6 +3,7 @@ package bootstrap
 import (
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/dedup"
 	"github.com/OpenListTeam/OpenList/v4/internal/fs"
 	"github.com/OpenListTeam/OpenList/v4/internal/offline_download/tool"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
@@ -49,4 +50,8 @@ func InitTaskManager() {
 	op.RegisterSettingChangingCallback(func() {
 		fs.ArchiveContentUploadTaskManager.SetWorkersNumActive(taskFilterNegative(setting.GetInt(conf.TaskDecompressUploadThreadsNum, conf.Conf.Tasks.DecompressUpload.Workers)))
 	})
+	fs.SyncTaskManager = tache.NewManager[*fs.SyncTask](tache.WithWorks(conf.Conf.Tasks.Sync.Workers), tache.WithMaxRetry(conf.Conf.Tasks.Sync.MaxRetry))         //sync will not support persist, the history is kept by sync runs
+	dedup.ScanTaskManager = tache.NewManager[*dedup.ScanTask](tache.WithWorks(conf.Conf.Tasks.Dedup.Workers), tache.WithMaxRetry(conf.Conf.Tasks.Dedup.MaxRetry)) //dedup will not support persist, the results are kept by duplicate scans
+	// usage scan will not support persist, it can simply be started again
+	fs.UsageScanTaskManager = tache.NewManager[*fs.UsageScanTask](tache.WithWorks(conf.Conf.Tasks.UsageScan.Workers), tache.WithMaxRetry(conf.Conf.Tasks.UsageScan.MaxRetry))
 }
@@ -0,0 +1,25 @@
+package bootstrap
+
+import (
+	"context"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+)
+
+var trashPurgeCron *cron.Cron
+
+func InitTrash() {
+	trashPurgeCron = cron.NewCron(time.Hour)
+	trashPurgeCron.Do(func() {
+		_ = fs.PurgeExpiredTrash(context.Background())
+	})
+}
+
+func releaseTrash() {
+	if trashPurgeCron != nil {
+		trashPurgeCron.Stop()
+		trashPurgeCron = nil
+	}
+}
@@ -0,0 +1,29 @@
+package bootstrap
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/webhook"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+	log "github.com/sirupsen/logrus"
+)
+
+var webhookPurgeCron *cron.Cron
+
+func InitWebhook() {
+	webhook.Start()
+	webhookPurgeCron = cron.NewCron(time.Hour)
+	webhookPurgeCron.Do(func() {
+		if err := webhook.Purge(); err != nil {
+			log.Errorf("failed purge webhook deliveries: %+v", err)
+		}
+	})
+}
+
+func releaseWebhook() {
+	if webhookPurgeCron != nil {
+		webhookPurgeCron.Stop()
+		webhookPurgeCron = nil
+	}
+	webhook.Stop()
+}
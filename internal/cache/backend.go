@@ -0,0 +1,27 @@
+package cache
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrNotFound = errors.New("cache entry not found")
+
+// Backend is a persistent key-value store behind the in-memory caches,
+// so that cached data survives restarts and can be shared by several instances.
+type Backend interface {
+	// Get returns ErrNotFound if the key doesn't exist or is expired
+	Get(bucket, key string) ([]byte, error)
+	Set(bucket, key string, value []byte, ttl time.Duration) error
+	Delete(bucket string, keys ...string) error
+	// Clear removes all keys of the bucket
+	Clear(bucket string) error
+	// Publish notifies the other instances sharing the backend that the key is invalid,
+	// an empty key means the whole bucket is invalid
+	Publish(bucket, key string) error
+	// Subscribe registers the handler of invalidations published by the other instances.
+	// The handler is called with an empty bucket if some invalidations may have been
+	// missed (e.g. after a reconnection), and everything should be dropped.
+	Subscribe(handler func(bucket, key string))
+	Close() error
+}
@@ -0,0 +1,246 @@
+package cache
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis is a minimal stand-in speaking the subset of the redis protocol used by RedisBackend
+type fakeRedis struct {
+	ln net.Listener
+
+	mu          sync.Mutex
+	data        map[string]string
+	expire      map[string]time.Time
+	subscribers map[string][]*bufio.Writer
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeRedis{
+		ln:          ln,
+		data:        make(map[string]string),
+		expire:      make(map[string]time.Time),
+		subscribers: make(map[string][]*bufio.Writer),
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeRedis) readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line)[1:])
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		if line, err = r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, _ := strconv.Atoi(strings.TrimSpace(line)[1:])
+		buf := make([]byte, size+2)
+		if _, err = io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func bulk(s string) string {
+	return fmt.Sprintf("$%d\r\n%s\r\n", len(s), s)
+}
+
+func (s *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r, w := bufio.NewReader(conn), bufio.NewWriter(conn)
+	for {
+		args, err := s.readCommand(r)
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		switch strings.ToUpper(args[0]) {
+		case "PING":
+			w.WriteString("+PONG\r\n")
+		case "GET":
+			v, ok := s.data[args[1]]
+			if ok && time.Now().After(s.expire[args[1]]) {
+				delete(s.data, args[1])
+				ok = false
+			}
+			if ok {
+				w.WriteString(bulk(v))
+			} else {
+				w.WriteString("$-1\r\n")
+			}
+		case "SET":
+			ms, _ := strconv.Atoi(args[4])
+			s.data[args[1]] = args[2]
+			s.expire[args[1]] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+			w.WriteString("+OK\r\n")
+		case "DEL":
+			for _, k := range args[1:] {
+				delete(s.data, k)
+			}
+			fmt.Fprintf(w, ":%d\r\n", len(args)-1)
+		case "SCAN":
+			// only prefix patterns are used, note that * matches / in redis
+			prefix := strings.NewReplacer(`\`, "").Replace(strings.TrimSuffix(args[3], "*"))
+			var keys []string
+			for k := range s.data {
+				if strings.HasPrefix(k, prefix) {
+					keys = append(keys, bulk(k))
+				}
+			}
+			fmt.Fprintf(w, "*2\r\n%s*%d\r\n%s", bulk("0"), len(keys), strings.Join(keys, ""))
+		case "PUBLISH":
+			subs := s.subscribers[args[1]]
+			for _, sub := range subs {
+				fmt.Fprintf(sub, "*3\r\n%s%s%s", bulk("message"), bulk(args[1]), bulk(args[2]))
+				sub.Flush()
+			}
+			fmt.Fprintf(w, ":%d\r\n", len(subs))
+		case "SUBSCRIBE":
+			s.subscribers[args[1]] = append(s.subscribers[args[1]], w)
+			fmt.Fprintf(w, "*3\r\n%s%s:1\r\n", bulk("subscribe"), bulk(args[1]))
+		default:
+			fmt.Fprintf(w, "-ERR unknown command '%s'\r\n", args[0])
+		}
+		w.Flush()
+		s.mu.Unlock()
+	}
+}
+
+func testBackend(t *testing.T, b Backend) {
+	if _, err := b.Get("dir", "/a"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expect ErrNotFound, got %v", err)
+	}
+	if err := b.Set("dir", "/a", []byte("a"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Set("dir", "/a/b", []byte("b"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Set("link", "/a/c", []byte("c"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Set("dir", "/expired", []byte("e"), time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond * 10)
+	if v, err := b.Get("dir", "/a"); err != nil || string(v) != "a" {
+		t.Fatalf("expect a, got %s %v", v, err)
+	}
+	if _, err := b.Get("dir", "/expired"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expect expired entry not found, got %v", err)
+	}
+	if err := b.Delete("dir", "/a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Get("dir", "/a"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expect deleted entry not found, got %v", err)
+	}
+	if err := b.Clear("dir"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Get("dir", "/a/b"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expect cleared entry not found, got %v", err)
+	}
+	if v, err := b.Get("link", "/a/c"); err != nil || string(v) != "c" {
+		t.Fatalf("expect other bucket not cleared, got %s %v", v, err)
+	}
+}
+
+func TestBoltBackend(t *testing.T) {
+	b, err := NewBoltBackend(filepath.Join(t.TempDir(), "cache.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	testBackend(t, b)
+}
+
+func TestRedisBackend(t *testing.T) {
+	s := newFakeRedis(t)
+	b, err := NewRedisBackend(RedisConfig{Address: s.ln.Addr().String(), Prefix: "test:"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	testBackend(t, b)
+}
+
+func TestRedisBackendInvalidation(t *testing.T) {
+	s := newFakeRedis(t)
+	cfg := RedisConfig{Address: s.ln.Addr().String(), Prefix: "test:"}
+	b1, err := NewRedisBackend(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b1.Close()
+	b2, err := NewRedisBackend(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b2.Close()
+
+	received := make(chan string, 2)
+	b1.Subscribe(func(bucket, key string) { received <- "b1 " + bucket + " " + key })
+	b2.Subscribe(func(bucket, key string) { received <- "b2 " + bucket + " " + key })
+	// wait for both subscriptions
+	for deadline := time.Now().Add(time.Second * 3); ; {
+		s.mu.Lock()
+		n := len(s.subscribers["test:invalidate"])
+		s.mu.Unlock()
+		if n == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("subscriptions timeout")
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	if err = b1.Publish("dir", "/a/b"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case msg := <-received:
+		if msg != "b2 dir /a/b" {
+			t.Fatalf("unexpected invalidation: %s", msg)
+		}
+	case <-time.After(time.Second * 3):
+		t.Fatal("invalidation timeout")
+	}
+	select {
+	case msg := <-received:
+		t.Fatalf("publisher shouldn't receive its own invalidation: %s", msg)
+	case <-time.After(time.Millisecond * 100):
+	}
+}
@@ -0,0 +1,136 @@
+package cache
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	bolt "go.etcd.io/bbolt"
+)
+
+// BoltBackend stores the cache in a local bbolt file. The file is locked by
+// the process which opens it, so it can't be shared by several instances and
+// the invalidations are never published.
+type BoltBackend struct {
+	db *bolt.DB
+}
+
+func NewBoltBackend(path string) (*BoltBackend, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o777); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second * 3})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open cache file %s", path)
+	}
+	b := &BoltBackend{db: db}
+	registerGC(b, b.GC)
+	return b, nil
+}
+
+// values are prefixed with the expiration time in unix nanoseconds
+func encodeBoltValue(value []byte, ttl time.Duration) []byte {
+	buf := make([]byte, 8+len(value))
+	binary.BigEndian.PutUint64(buf, uint64(time.Now().Add(ttl).UnixNano()))
+	copy(buf[8:], value)
+	return buf
+}
+
+func boltValueExpired(v []byte, now time.Time) bool {
+	return len(v) < 8 || int64(binary.BigEndian.Uint64(v)) < now.UnixNano()
+}
+
+func (b *BoltBackend) Get(bucket, key string) ([]byte, error) {
+	var value []byte
+	err := b.db.View(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket([]byte(bucket))
+		if bkt == nil {
+			return ErrNotFound
+		}
+		v := bkt.Get([]byte(key))
+		if v == nil || boltValueExpired(v, time.Now()) {
+			return ErrNotFound
+		}
+		// the slice is only valid during the transaction
+		value = make([]byte, len(v)-8)
+		copy(value, v[8:])
+		return nil
+	})
+	return value, err
+}
+
+func (b *BoltBackend) Set(bucket, key string, value []byte, ttl time.Duration) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bkt, err := tx.CreateBucketIfNotExists([]byte(bucket))
+		if err != nil {
+			return err
+		}
+		return bkt.Put([]byte(key), encodeBoltValue(value, ttl))
+	})
+}
+
+func (b *BoltBackend) Delete(bucket string, keys ...string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket([]byte(bucket))
+		if bkt == nil {
+			return nil
+		}
+		for _, key := range keys {
+			if err := bkt.Delete([]byte(key)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (b *BoltBackend) Clear(bucket string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		err := tx.DeleteBucket([]byte(bucket))
+		if errors.Is(err, bolt.ErrBucketNotFound) {
+			return nil
+		}
+		return err
+	})
+}
+
+func (b *BoltBackend) Publish(string, string) error {
+	return nil
+}
+
+func (b *BoltBackend) Subscribe(func(bucket, key string)) {}
+
+// GC removes the expired entries of all buckets
+func (b *BoltBackend) GC() {
+	now := time.Now()
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(_ []byte, bkt *bolt.Bucket) error {
+			c := bkt.Cursor()
+			for k, v := c.First(); k != nil; {
+				if boltValueExpired(v, now) {
+					if err := c.Delete(); err != nil {
+						return err
+					}
+					// the cursor points to the next key after delete
+					k, v = c.Seek(k)
+					continue
+				}
+				k, v = c.Next()
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		log.Errorf("failed to gc cache file: %+v", err)
+	}
+}
+
+func (b *BoltBackend) Close() error {
+	unregisterGC(b)
+	return b.db.Close()
+}
+
+var _ Backend = (*BoltBackend)(nil)
@@ -16,7 +16,7 @@ func NewKeyedCache[T any](ttl time.Duration) *KeyedCache[T] {
 		entries: make(map[string]*CacheEntry[T]),
 		ttl:     ttl,
 	}
-	gcFuncs = append(gcFuncs, c.GC)
+	registerGC(c, c.GC)
 	return c
 }
 
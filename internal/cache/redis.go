@@ -0,0 +1,370 @@
+package cache
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+type RedisConfig struct {
+	Address  string
+	Password string
+	DB       int
+	// Prefix is prepended to all keys and the invalidation channel,
+	// instances sharing the same prefix share the cache
+	Prefix string
+}
+
+const (
+	redisDialTimeout = time.Second * 5
+	redisIOTimeout   = time.Second * 10
+	redisMaxIdle     = 8
+)
+
+// RedisBackend stores the cache in a server speaking the redis protocol,
+// invalidations are broadcast to the other instances with PUBLISH/SUBSCRIBE.
+type RedisBackend struct {
+	cfg RedisConfig
+	// id identifies the invalidations published by this instance
+	id   string
+	idle chan *redisConn
+
+	mu       sync.Mutex
+	handlers []func(bucket, key string)
+	subConn  *redisConn
+	closed   bool
+	done     chan struct{}
+}
+
+func NewRedisBackend(cfg RedisConfig) (*RedisBackend, error) {
+	b := &RedisBackend{
+		cfg:  cfg,
+		id:   random.String(16),
+		idle: make(chan *redisConn, redisMaxIdle),
+		done: make(chan struct{}),
+	}
+	if _, err := b.do("PING"); err != nil {
+		return nil, errors.WithMessagef(err, "failed to connect to redis %s", cfg.Address)
+	}
+	return b, nil
+}
+
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+type redisConn struct {
+	net.Conn
+	r *bufio.Reader
+	w *bufio.Writer
+}
+
+func (b *RedisBackend) dial() (*redisConn, error) {
+	conn, err := net.DialTimeout("tcp", b.cfg.Address, redisDialTimeout)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	c := &redisConn{Conn: conn, r: bufio.NewReader(conn), w: bufio.NewWriter(conn)}
+	if b.cfg.Password != "" {
+		if _, err = c.do("AUTH", b.cfg.Password); err != nil {
+			_ = c.Close()
+			return nil, err
+		}
+	}
+	if b.cfg.DB != 0 {
+		if _, err = c.do("SELECT", strconv.Itoa(b.cfg.DB)); err != nil {
+			_ = c.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (c *redisConn) writeCommand(args ...string) error {
+	fmt.Fprintf(c.w, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(c.w, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	return c.w.Flush()
+}
+
+// readReply returns string, int64, []byte (nil for null bulk string),
+// []any (nil for null array) or redisError
+func (c *redisConn) readReply() (any, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("redis: empty reply")
+	}
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return redisError(line[1:]), nil
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return []byte(nil), err
+		}
+		buf := make([]byte, n+2)
+		if _, err = io.ReadFull(c.r, buf); err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return []any(nil), err
+		}
+		arr := make([]any, n)
+		for i := range arr {
+			if arr[i], err = c.readReply(); err != nil {
+				return nil, err
+			}
+		}
+		return arr, nil
+	default:
+		return nil, errors.Errorf("redis: unknown reply %q", line)
+	}
+}
+
+func (c *redisConn) do(args ...string) (any, error) {
+	_ = c.SetDeadline(time.Now().Add(redisIOTimeout))
+	if err := c.writeCommand(args...); err != nil {
+		return nil, err
+	}
+	reply, err := c.readReply()
+	if err != nil {
+		return nil, err
+	}
+	if e, ok := reply.(redisError); ok {
+		return nil, e
+	}
+	return reply, nil
+}
+
+func (b *RedisBackend) do(args ...string) (any, error) {
+	var c *redisConn
+	select {
+	case c = <-b.idle:
+	default:
+		var err error
+		if c, err = b.dial(); err != nil {
+			return nil, err
+		}
+	}
+	reply, err := c.do(args...)
+	var rErr redisError
+	if err != nil && !errors.As(err, &rErr) {
+		// the connection is broken
+		_ = c.Close()
+		return nil, errors.WithStack(err)
+	}
+	select {
+	case b.idle <- c:
+	default:
+		_ = c.Close()
+	}
+	return reply, err
+}
+
+func (b *RedisBackend) key(bucket, key string) string {
+	return b.cfg.Prefix + bucket + ":" + key
+}
+
+func (b *RedisBackend) channel() string {
+	return b.cfg.Prefix + "invalidate"
+}
+
+func (b *RedisBackend) Get(bucket, key string) ([]byte, error) {
+	reply, err := b.do("GET", b.key(bucket, key))
+	if err != nil {
+		return nil, err
+	}
+	value, _ := reply.([]byte)
+	if value == nil {
+		return nil, ErrNotFound
+	}
+	return value, nil
+}
+
+func (b *RedisBackend) Set(bucket, key string, value []byte, ttl time.Duration) error {
+	ms := max(ttl.Milliseconds(), 1)
+	_, err := b.do("SET", b.key(bucket, key), string(value), "PX", strconv.FormatInt(ms, 10))
+	return err
+}
+
+func (b *RedisBackend) Delete(bucket string, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	args := make([]string, 0, len(keys)+1)
+	args = append(args, "DEL")
+	for _, key := range keys {
+		args = append(args, b.key(bucket, key))
+	}
+	_, err := b.do(args...)
+	return err
+}
+
+// escape the glob special characters of a SCAN pattern
+var redisGlobEscaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
+
+func (b *RedisBackend) Clear(bucket string) error {
+	pattern := redisGlobEscaper.Replace(b.key(bucket, "")) + "*"
+	cursor := "0"
+	for {
+		reply, err := b.do("SCAN", cursor, "MATCH", pattern, "COUNT", "500")
+		if err != nil {
+			return err
+		}
+		arr, ok := reply.([]any)
+		if !ok || len(arr) != 2 {
+			return errors.Errorf("redis: unexpected SCAN reply %v", reply)
+		}
+		next, _ := arr[0].([]byte)
+		keys, _ := arr[1].([]any)
+		if len(keys) > 0 {
+			args := make([]string, 0, len(keys)+1)
+			args = append(args, "DEL")
+			for _, k := range keys {
+				if k, ok := k.([]byte); ok {
+					args = append(args, string(k))
+				}
+			}
+			if _, err = b.do(args...); err != nil {
+				return err
+			}
+		}
+		cursor = string(next)
+		if cursor == "0" || cursor == "" {
+			return nil
+		}
+	}
+}
+
+func (b *RedisBackend) Publish(bucket, key string) error {
+	_, err := b.do("PUBLISH", b.channel(), b.id+"\n"+bucket+"\n"+key)
+	return err
+}
+
+func (b *RedisBackend) Subscribe(handler func(bucket, key string)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, handler)
+	if len(b.handlers) == 1 {
+		go b.subscribe()
+	}
+}
+
+func (b *RedisBackend) notify(bucket, key string) {
+	b.mu.Lock()
+	handlers := b.handlers
+	b.mu.Unlock()
+	for _, handler := range handlers {
+		handler(bucket, key)
+	}
+}
+
+// subscribe listens to the invalidation channel until the backend is closed,
+// reconnecting with backoff when the connection is lost
+func (b *RedisBackend) subscribe() {
+	backoff := time.Second
+	for reconnect := false; ; reconnect = true {
+		err := b.listen(reconnect)
+		select {
+		case <-b.done:
+			return
+		default:
+		}
+		log.Warnf("redis cache subscription lost, retry in %s: %+v", backoff, err)
+		select {
+		case <-b.done:
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, time.Second*30)
+	}
+}
+
+func (b *RedisBackend) listen(reconnect bool) error {
+	c, err := b.dial()
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return c.Close()
+	}
+	b.subConn = c
+	b.mu.Unlock()
+	defer c.Close()
+	if _, err = c.do("SUBSCRIBE", b.channel()); err != nil {
+		return err
+	}
+	_ = c.SetDeadline(time.Time{})
+	if reconnect {
+		// messages published while disconnected are lost
+		b.notify("", "")
+	}
+	for {
+		reply, err := c.readReply()
+		if err != nil {
+			return err
+		}
+		arr, ok := reply.([]any)
+		if !ok || len(arr) != 3 {
+			continue
+		}
+		if kind, _ := arr[0].([]byte); string(kind) != "message" {
+			continue
+		}
+		payload, _ := arr[2].([]byte)
+		parts := strings.SplitN(string(payload), "\n", 3)
+		if len(parts) != 3 || parts[0] == b.id {
+			continue
+		}
+		b.notify(parts[1], parts[2])
+	}
+}
+
+func (b *RedisBackend) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	close(b.done)
+	if b.subConn != nil {
+		_ = b.subConn.Close()
+	}
+	b.mu.Unlock()
+	for {
+		select {
+		case c := <-b.idle:
+			_ = c.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+var _ Backend = (*RedisBackend)(nil)
@@ -16,7 +16,7 @@ func NewTypedCache[T any](ttl time.Duration) *TypedCache[T] {
 		entries: make(map[string]map[string]*CacheEntry[T]),
 		ttl:     ttl,
 	}
-	gcFuncs = append(gcFuncs, c.GC)
+	registerGC(c, c.GC)
 	return c
 }
 
@@ -1,6 +1,7 @@
 package cache
 
 import (
+	"sync"
 	"time"
 
 	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
@@ -9,15 +10,35 @@ import (
 
 var (
 	cacheGcCron *cron.Cron
-	gcFuncs     []func()
+	gcMu        sync.Mutex
+	// gcFuncs are the GC functions keyed by their cache, a closed cache unregisters its own
+	gcFuncs = make(map[any]func())
 )
 
+func registerGC(owner any, f func()) {
+	gcMu.Lock()
+	defer gcMu.Unlock()
+	gcFuncs[owner] = f
+}
+
+func unregisterGC(owner any) {
+	gcMu.Lock()
+	defer gcMu.Unlock()
+	delete(gcFuncs, owner)
+}
+
 func init() {
 	// TODO Move to bootstrap
 	cacheGcCron = cron.NewCron(time.Hour)
 	cacheGcCron.Do(func() {
 		log.Infof("Start cache GC")
+		gcMu.Lock()
+		funcs := make([]func(), 0, len(gcFuncs))
 		for _, f := range gcFuncs {
+			funcs = append(funcs, f)
+		}
+		gcMu.Unlock()
+		for _, f := range funcs {
 			f()
 		}
 	})
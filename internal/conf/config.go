@@ -73,6 +73,9 @@ type TasksConfig struct {
 	Move               TaskConfig `json:"move" envPrefix:"MOVE_"`
 	Decompress         TaskConfig `json:"decompress" envPrefix:"DECOMPRESS_"`
 	DecompressUpload   TaskConfig `json:"decompress_upload" envPrefix:"DECOMPRESS_UPLOAD_"`
+	Sync               TaskConfig `json:"sync" envPrefix:"SYNC_"`
+	Dedup              TaskConfig `json:"dedup" envPrefix:"DEDUP_"`
+	UsageScan          TaskConfig `json:"usage_scan" envPrefix:"USAGE_SCAN_"`
 	AllowRetryCanceled bool       `json:"allow_retry_canceled" env:"ALLOW_RETRY_CANCELED"`
 }
 
@@ -106,6 +109,21 @@ type SFTP struct {
 	Listen string `json:"listen" env:"LISTEN"`
 }
 
+type CacheRedis struct {
+	Address  string `json:"address" env:"ADDRESS"`
+	Password string `json:"password" env:"PASSWORD"`
+	DB       int    `json:"db" env:"DB"`
+	Prefix   string `json:"prefix" env:"PREFIX"`
+}
+
+type Cache struct {
+	// memory, disk or redis. disk can't be shared between instances,
+	// use redis for several instances behind a load balancer
+	Backend  string     `json:"backend" env:"BACKEND"`
+	DiskFile string     `json:"disk_file" env:"DISK_FILE"`
+	Redis    CacheRedis `json:"redis" envPrefix:"REDIS_"`
+}
+
 type Config struct {
 	Force                 bool        `json:"force" env:"FORCE"`
 	SiteURL               string      `json:"site_url" env:"SITE_URL"`
@@ -130,6 +148,7 @@ type Config struct {
 	S3                    S3          `json:"s3" envPrefix:"S3_"`
 	FTP                   FTP         `json:"ftp" envPrefix:"FTP_"`
 	SFTP                  SFTP        `json:"sftp" envPrefix:"SFTP_"`
+	Cache                 Cache       `json:"cache" envPrefix:"CACHE_"`
 	LastLaunchedVersion   string      `json:"last_launched_version"`
 	ProxyAddress          string      `json:"proxy_address" env:"PROXY_ADDRESS"`
 }
@@ -139,6 +158,7 @@ func DefaultConfig(dataDir string) *Config {
 	indexDir := filepath.Join(dataDir, "bleve")
 	logPath := filepath.Join(dataDir, "log/log.log")
 	dbPath := filepath.Join(dataDir, "data.db")
+	cachePath := filepath.Join(dataDir, "cache.db")
 	return &Config{
 		Scheme: Scheme{
 			Address:    "0.0.0.0",
@@ -216,6 +236,15 @@ func DefaultConfig(dataDir string) *Config {
 				Workers:  5,
 				MaxRetry: 2,
 			},
+			Sync: TaskConfig{
+				Workers: 2,
+			},
+			Dedup: TaskConfig{
+				Workers: 1,
+			},
+			UsageScan: TaskConfig{
+				Workers: 1,
+			},
 			AllowRetryCanceled: false,
 		},
 		Cors: Cors{
@@ -244,6 +273,14 @@ func DefaultConfig(dataDir string) *Config {
 			Enable: false,
 			Listen: ":5222",
 		},
+		Cache: Cache{
+			Backend:  "memory",
+			DiskFile: cachePath,
+			Redis: CacheRedis{
+				Address: "localhost:6379",
+				Prefix:  "openlist:",
+			},
+		},
 		LastLaunchedVersion: "",
 		ProxyAddress:        "",
 	}
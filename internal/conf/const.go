@@ -59,6 +59,22 @@ const (
 	HandleHookAfterWriting  = "handle_hook_after_writing"
 	HandleHookRateLimit     = "handle_hook_rate_limit"
 	IgnoreSystemFiles       = "ignore_system_files"
+	TransferVerify          = "transfer_verify"
+
+	// trash
+	TrashEnabled       = "trash_enabled"
+	TrashDir           = "trash_dir"
+	TrashAutoPurgeDays = "trash_auto_purge_days"
+
+	// version
+	VersionDir = "version_dir"
+
+	// audit
+	AuditEnabled       = "audit_enabled"
+	AuditRetentionDays = "audit_retention_days"
+
+	// webhook
+	WebhookDeliveryRetentionDays = "webhook_delivery_retention_days"
 
 	// index
 	SearchIndex     = "search_index"
@@ -97,6 +113,7 @@ const (
 
 	// single
 	Token         = "token"
+	APITokenKey   = "api_token_key"
 	IndexProgress = "index_progress"
 
 	// SSO
@@ -114,6 +131,7 @@ const (
 	SSODefaultDir        = "sso_default_dir"
 	SSODefaultPermission = "sso_default_permission"
 	SSOCompatibilityMode = "sso_compatibility_mode"
+	SSOOIDCGroupsKey     = "sso_oidc_groups_key"
 
 	// ldap
 	LdapLoginEnabled      = "ldap_login_enabled"
@@ -126,11 +144,22 @@ const (
 	LdapDefaultPermission = "ldap_default_permission"
 	LdapDefaultDir        = "ldap_default_dir"
 	LdapLoginTips         = "ldap_login_tips"
+	LdapGroupAttribute    = "ldap_group_attribute"
+
+	// smtp
+	SMTPHost       = "smtp_host"
+	SMTPPort       = "smtp_port"
+	SMTPEncryption = "smtp_encryption"
+	SMTPUsername   = "smtp_username"
+	SMTPPassword   = "smtp_password"
+	SMTPFrom       = "smtp_from"
 
 	// s3
 	S3Buckets         = "s3_buckets"
 	S3AccessKeyId     = "s3_access_key_id"
 	S3SecretAccessKey = "s3_secret_access_key"
+	S3MultipartExpire = "s3_multipart_expire"
+	S3MultipartLimit  = "s3_multipart_limit"
 
 	// qbittorrent
 	QbittorrentUrl      = "qbittorrent_url"
@@ -191,4 +220,13 @@ const (
 	PathKey
 	SharingIDKey
 	SkipHookKey
+	TransferVerifyKey
+	ProtocolKey
+	SignedLinkKey
+	// LockTokensKey holds the tokens of the WebDAV locks the request has claimed
+	LockTokensKey
+	// AnonymousKey marks a request without user that comes from a client, not from the system
+	AnonymousKey
+	// SignedKey marks a download whose sign was verified, the sign was given out after the checks
+	SignedKey
 )
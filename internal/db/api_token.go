@@ -0,0 +1,55 @@
+package db
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetAPITokensByUserId(userId uint, pageIndex, pageSize int) (tokens []model.APIToken, count int64, err error) {
+	tokenDB := db.Model(&model.APIToken{}).Where(columnName("user_id")+" = ?", userId)
+	if err := tokenDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get user's api tokens count")
+	}
+	if err := tokenDB.Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&tokens).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find user's api tokens")
+	}
+	return tokens, count, nil
+}
+
+func GetAPITokenById(id uint) (*model.APIToken, error) {
+	var t model.APIToken
+	if err := db.First(&t, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get api token")
+	}
+	return &t, nil
+}
+
+func GetAPITokenByKeyId(keyId string) (*model.APIToken, error) {
+	var t model.APIToken
+	if err := db.Where(columnName("key_id")+" = ?", keyId).First(&t).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get api token")
+	}
+	return &t, nil
+}
+
+func CreateAPIToken(t *model.APIToken) error {
+	return errors.WithStack(db.Create(t).Error)
+}
+
+func UpdateAPIToken(t *model.APIToken) error {
+	return errors.WithStack(db.Save(t).Error)
+}
+
+func UpdateAPITokenLastUsed(id uint, lastUsed time.Time) error {
+	return errors.WithStack(db.Model(&model.APIToken{}).Where(columnName("id")+" = ?", id).Update("last_used", lastUsed).Error)
+}
+
+func DeleteAPITokenById(id uint) error {
+	return errors.WithStack(db.Delete(&model.APIToken{}, id).Error)
+}
+
+func DeleteAPITokensByUserId(userId uint) error {
+	return errors.WithStack(db.Where(columnName("user_id")+" = ?", userId).Delete(&model.APIToken{}).Error)
+}
@@ -0,0 +1,73 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func CreateAuditLogs(logs []*model.AuditLog) error {
+	return errors.WithStack(db.CreateInBatches(logs, 100).Error)
+}
+
+func whereAuditLogs(q *model.AuditLogQuery) *gorm.DB {
+	tx := db.Model(&model.AuditLog{})
+	if q.Username != "" {
+		tx = tx.Where(columnName("username")+" = ?", q.Username)
+	}
+	if q.Operation != "" {
+		tx = tx.Where(columnName("operation")+" = ?", q.Operation)
+	}
+	if q.Protocol != "" {
+		tx = tx.Where(columnName("protocol")+" = ?", q.Protocol)
+	}
+	if q.IP != "" {
+		tx = tx.Where(columnName("ip")+" = ?", q.IP)
+	}
+	if q.Path != "" && q.Path != "/" {
+		p := utils.FixAndCleanPath(q.Path)
+		tx = tx.Where(db.Where(columnName("path")+" = ?", p).
+			Or(fmt.Sprintf("%s LIKE ?", columnName("path")), p+"/%").
+			Or(columnName("dst_path")+" = ?", p).
+			Or(fmt.Sprintf("%s LIKE ?", columnName("dst_path")), p+"/%"))
+	}
+	if q.Success != nil {
+		tx = tx.Where(columnName("success")+" = ?", *q.Success)
+	}
+	if q.From != nil {
+		tx = tx.Where(columnName("time")+" >= ?", *q.From)
+	}
+	if q.To != nil {
+		tx = tx.Where(columnName("time")+" < ?", *q.To)
+	}
+	return tx
+}
+
+// GetAuditLogs returns a page of the logs matching q, the latest first
+func GetAuditLogs(q *model.AuditLogQuery) (logs []model.AuditLog, count int64, err error) {
+	if err := whereAuditLogs(q).Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get audit logs count")
+	}
+	if err := whereAuditLogs(q).Order(columnName("id") + " DESC").Offset((q.Page - 1) * q.PerPage).Limit(q.PerPage).Find(&logs).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find audit logs")
+	}
+	return logs, count, nil
+}
+
+// EachAuditLogs calls fn with the logs matching q in batches, the oldest first
+func EachAuditLogs(q *model.AuditLogQuery, fn func(logs []model.AuditLog) error) error {
+	var logs []model.AuditLog
+	err := whereAuditLogs(q).Order(columnName("id")).FindInBatches(&logs, 1000, func(tx *gorm.DB, batch int) error {
+		return fn(logs)
+	}).Error
+	return errors.Wrapf(err, "failed find audit logs")
+}
+
+func DeleteAuditLogsBefore(t time.Time) (int64, error) {
+	res := db.Where(columnName("time")+" < ?", t).Delete(&model.AuditLog{})
+	return res.RowsAffected, errors.WithStack(res.Error)
+}
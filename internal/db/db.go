@@ -12,7 +12,7 @@ var db *gorm.DB
 
 func Init(d *gorm.DB) {
 	db = d
-	err := AutoMigrate(new(model.Storage), new(model.User), new(model.Meta), new(model.SettingItem), new(model.SearchNode), new(model.TaskItem), new(model.SSHPublicKey), new(model.SharingDB))
+	err := AutoMigrate(new(model.Storage), new(model.User), new(model.Meta), new(model.SettingItem), new(model.SearchNode), new(model.StorageIndex), new(model.TaskItem), new(model.SSHPublicKey), new(model.SharingDB), new(model.TrashItem), new(model.FileVersion), new(model.SyncJob), new(model.SyncRun), new(model.SyncSnapshotEntry), new(model.DuplicateScan), new(model.DuplicateSet), new(model.APIToken), new(model.Group), new(model.ACLRule), new(model.UserUsage), new(model.AuditLog), new(model.Webhook), new(model.WebhookDelivery), new(model.SharingAccess), new(model.SharingRecipient), new(model.SignedLink), new(model.S3ObjectMeta), new(model.WebDAVProp), new(model.WebDAVLock))
 	if err != nil {
 		log.Fatalf("failed migrate database: %s", err.Error())
 	}
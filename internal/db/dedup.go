@@ -0,0 +1,71 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetDuplicateScanById(id uint) (*model.DuplicateScan, error) {
+	var s model.DuplicateScan
+	if err := db.First(&s, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get duplicate scan")
+	}
+	return &s, nil
+}
+
+func GetDuplicateScans(pageIndex, pageSize int) (scans []model.DuplicateScan, count int64, err error) {
+	scanDB := db.Model(&model.DuplicateScan{})
+	if err := scanDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get duplicate scans count")
+	}
+	if err := scanDB.Order(columnName("id") + " DESC").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&scans).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find duplicate scans")
+	}
+	return scans, count, nil
+}
+
+func CreateDuplicateScan(s *model.DuplicateScan) error {
+	return errors.WithStack(db.Create(s).Error)
+}
+
+func UpdateDuplicateScan(s *model.DuplicateScan) error {
+	return errors.WithStack(db.Save(s).Error)
+}
+
+func DeleteDuplicateScanById(id uint) error {
+	if err := db.Where(columnName("scan_id")+" = ?", id).Delete(&model.DuplicateSet{}).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(db.Delete(&model.DuplicateScan{}, id).Error)
+}
+
+func GetDuplicateSetById(id uint) (*model.DuplicateSet, error) {
+	var s model.DuplicateSet
+	if err := db.First(&s, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get duplicate set")
+	}
+	return &s, nil
+}
+
+func GetDuplicateSetsByScanId(scanId uint, pageIndex, pageSize int) (sets []model.DuplicateSet, count int64, err error) {
+	setDB := db.Model(&model.DuplicateSet{}).Where(columnName("scan_id")+" = ?", scanId)
+	if err := setDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get duplicate sets count")
+	}
+	// the sets wasting the most space first
+	if err := setDB.Order(columnName("size") + " DESC").Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&sets).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find duplicate sets")
+	}
+	return sets, count, nil
+}
+
+func CreateDuplicateSets(sets []model.DuplicateSet) error {
+	if len(sets) == 0 {
+		return nil
+	}
+	return errors.WithStack(db.CreateInBatches(sets, 100).Error)
+}
+
+func UpdateDuplicateSet(s *model.DuplicateSet) error {
+	return errors.WithStack(db.Save(s).Error)
+}
@@ -0,0 +1,83 @@
+package db
+
+import (
+	stdpath "path"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func GetS3ObjectMeta(path string) (*model.S3ObjectMeta, error) {
+	m := model.S3ObjectMeta{Path: path}
+	if err := db.Where(m).First(&m).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get s3 object meta")
+	}
+	return &m, nil
+}
+
+func GetS3ObjectMetasByParent(parent string) (metas []model.S3ObjectMeta, err error) {
+	if err := db.Where(model.S3ObjectMeta{Parent: parent}).Find(&metas).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find s3 object metas")
+	}
+	return metas, nil
+}
+
+// SaveS3ObjectMeta creates the meta or replaces the one of the same path
+func SaveS3ObjectMeta(m *model.S3ObjectMeta) error {
+	return errors.WithStack(db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "path"}},
+		DoUpdates: clause.AssignmentColumns([]string{"parent", "metadata", "e_tag", "size", "modified"}),
+	}).Create(m).Error)
+}
+
+// MoveS3ObjectMetas moves the metas of src and of everything below it to dst, replacing the
+// ones dst had
+func MoveS3ObjectMetas(src, dst string) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		var metas []model.S3ObjectMeta
+		if err := whereUnder(tx, "path", src).Find(&metas).Error; err != nil || len(metas) == 0 {
+			return err
+		}
+		if err := whereUnder(tx, "path", dst).Delete(&model.S3ObjectMeta{}).Error; err != nil {
+			return err
+		}
+		for _, m := range metas {
+			p := dst + m.Path[len(src):]
+			err := tx.Model(&model.S3ObjectMeta{}).Where("id = ?", m.ID).
+				Updates(map[string]any{"path": p, "parent": stdpath.Dir(p)}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+// CopyS3ObjectMetas copies the metas of src and of everything below it to dst, a copied
+// object whose size or modified time changed has its meta ignored as stale
+func CopyS3ObjectMetas(src, dst string) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		var metas []model.S3ObjectMeta
+		if err := whereUnder(tx, "path", src).Find(&metas).Error; err != nil || len(metas) == 0 {
+			return err
+		}
+		paths := make([]string, 0, len(metas))
+		for i := range metas {
+			metas[i].ID = 0
+			metas[i].Path = dst + metas[i].Path[len(src):]
+			metas[i].Parent = stdpath.Dir(metas[i].Path)
+			paths = append(paths, metas[i].Path)
+		}
+		if err := tx.Where(columnName("path")+" IN ?", paths).Delete(&model.S3ObjectMeta{}).Error; err != nil {
+			return err
+		}
+		return tx.CreateInBatches(&metas, 100).Error
+	}))
+}
+
+// DeleteS3ObjectMetas deletes the meta of path and of everything below it
+func DeleteS3ObjectMetas(path string) error {
+	return errors.WithStack(whereUnder(db, "path", path).Delete(&model.S3ObjectMeta{}).Error)
+}
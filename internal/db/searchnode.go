@@ -55,37 +55,87 @@ func GetSearchNodesByParent(parent string) ([]model.SearchNode, error) {
 }
 
 func SearchNode(req model.SearchReq, useFullText bool) ([]model.SearchNode, int64, error) {
-	var searchDB *gorm.DB
-	if !useFullText || conf.Conf.Database.Type == "sqlite3" {
-		keywordsClause := db.Where("1 = 1")
-		for _, keyword := range strings.Fields(req.Keywords) {
-			keywordsClause = keywordsClause.Where("name LIKE ?", fmt.Sprintf("%%%s%%", keyword))
-		}
-		searchDB = db.Model(&model.SearchNode{}).Where(whereInParent(req.Parent)).Where(keywordsClause)
-	} else {
-		switch conf.Conf.Database.Type {
-		case "mysql":
-			searchDB = db.Model(&model.SearchNode{}).Where(whereInParent(req.Parent)).
-				Where("MATCH (name) AGAINST (? IN BOOLEAN MODE)", "'*"+req.Keywords+"*'")
-		case "postgres":
-			searchDB = db.Model(&model.SearchNode{}).Where(whereInParent(req.Parent)).
-				Where("to_tsvector(name) @@ to_tsquery(?)", strings.Join(strings.Fields(req.Keywords), " & "))
+	searchDB := db.Model(&model.SearchNode{}).Where(whereInParent(req.Parent))
+	if strings.TrimSpace(req.Keywords) != "" {
+		if !useFullText || conf.Conf.Database.Type == "sqlite3" {
+			for _, keyword := range strings.Fields(req.Keywords) {
+				searchDB = searchDB.Where("name LIKE ?", fmt.Sprintf("%%%s%%", keyword))
+			}
+		} else {
+			switch conf.Conf.Database.Type {
+			case "mysql":
+				searchDB = searchDB.Where("MATCH (name) AGAINST (? IN BOOLEAN MODE)", "'*"+req.Keywords+"*'")
+			case "postgres":
+				searchDB = searchDB.Where("to_tsvector(name) @@ to_tsquery(?)", strings.Join(strings.Fields(req.Keywords), " & "))
+			}
 		}
 	}
 
 	if req.Scope != 0 {
 		isDir := req.Scope == 1
-		searchDB.Where(db.Where("is_dir = ?", isDir))
+		searchDB = searchDB.Where(db.Where("is_dir = ?", isDir))
 	}
+	searchDB = whereSearchFilters(searchDB, req.Filters)
 
 	var count int64
 	if err := searchDB.Count(&count).Error; err != nil {
 		return nil, 0, errors.Wrapf(err, "failed get search items count")
 	}
 	var files []model.SearchNode
-	if err := searchDB.Order("name asc").Offset((req.Page - 1) * req.PerPage).Limit(req.PerPage).
+	if err := searchDB.Order(searchOrder(req)).Offset((req.Page - 1) * req.PerPage).Limit(req.PerPage).
 		Find(&files).Error; err != nil {
 		return nil, 0, err
 	}
 	return files, count, nil
 }
+
+func whereSearchFilters(tx *gorm.DB, f model.SearchFilters) *gorm.DB {
+	if f.MinSize != nil {
+		tx = tx.Where(fmt.Sprintf("%s >= ?", columnName("size")), *f.MinSize)
+	}
+	if f.MaxSize != nil {
+		tx = tx.Where(fmt.Sprintf("%s <= ?", columnName("size")), *f.MaxSize)
+	}
+	if f.ModifiedFrom != nil {
+		tx = tx.Where(fmt.Sprintf("%s >= ?", columnName("modified")), *f.ModifiedFrom)
+	}
+	if f.ModifiedTo != nil {
+		tx = tx.Where(fmt.Sprintf("%s < ?", columnName("modified")), *f.ModifiedTo)
+	}
+	if len(f.ObjTypes) > 0 {
+		tx = tx.Where(fmt.Sprintf("%s IN ?", columnName("obj_type")), f.ObjTypes)
+	}
+	for name, h := range f.Hashes {
+		// hashes are stored as a json object with sorted keys, e.g. {"md5":"...","sha1":"..."}
+		tx = tx.Where(fmt.Sprintf("%s LIKE ?", columnName("hashes")), fmt.Sprintf(`%%"%s":"%s"%%`, name, h))
+	}
+	return tx
+}
+
+func searchOrder(req model.SearchReq) string {
+	column := "name"
+	switch req.OrderBy {
+	case "size", "modified":
+		column = req.OrderBy
+	}
+	direction := "asc"
+	if req.OrderDirection == "desc" {
+		direction = "desc"
+	}
+	return fmt.Sprintf("%s %s", columnName(column), direction)
+}
+
+func GetStorageIndexes() (indexes []model.StorageIndex, err error) {
+	if err = db.Find(&indexes).Error; err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return indexes, nil
+}
+
+func SaveStorageIndex(index *model.StorageIndex) error {
+	return errors.WithStack(db.Save(index).Error)
+}
+
+func DeleteStorageIndex(storageId uint) error {
+	return errors.WithStack(db.Delete(&model.StorageIndex{}, storageId).Error)
+}
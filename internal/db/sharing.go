@@ -4,6 +4,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
 	"github.com/pkg/errors"
+	"gorm.io/gorm"
 )
 
 func GetSharingById(id string) (*model.SharingDB, error) {
@@ -60,8 +61,9 @@ func CreateSharing(s *model.SharingDB) (string, error) {
 	}
 }
 
+// UpdateSharing saves the sharing, the byte counters updated in place are left as they are
 func UpdateSharing(s *model.SharingDB) error {
-	return errors.WithStack(db.Save(s).Error)
+	return errors.WithStack(db.Omit("bytes_served", "uploaded_bytes").Save(s).Error)
 }
 
 func DeleteSharingById(id string) error {
@@ -69,6 +71,40 @@ func DeleteSharingById(id string) error {
 	return errors.WithStack(db.Where(s).Delete(&s).Error)
 }
 
+// AddSharingBytesServed adds n to the bytes served by the sharing in place, concurrent downloads lose none
+func AddSharingBytesServed(id string, n int64) error {
+	return errors.WithStack(db.Model(&model.SharingDB{ID: id}).
+		UpdateColumn("bytes_served", gorm.Expr(columnName("bytes_served")+" + ?", n)).Error)
+}
+
+// AddSharingAccessed counts an access to the sharing in place
+func AddSharingAccessed(id string) error {
+	return errors.WithStack(db.Model(&model.SharingDB{ID: id}).
+		UpdateColumn("accessed", gorm.Expr(columnName("accessed")+" + 1")).Error)
+}
+
+// AddSharingUploadedBytes adds n to the uploaded bytes of the sharing in place. With limited it
+// is refused, returning false, when that goes over the max total bytes of the sharing.
+func AddSharingUploadedBytes(id string, n int64, limited bool) (bool, error) {
+	tx := db.Model(&model.SharingDB{ID: id})
+	if limited {
+		maxCol, uploaded := columnName("max_total_bytes"), columnName("uploaded_bytes")
+		tx = tx.Where("("+maxCol+" <= 0 OR "+uploaded+" + ? <= "+maxCol+")", n)
+	}
+	res := tx.UpdateColumn("uploaded_bytes", gorm.Expr(columnName("uploaded_bytes")+" + ?", n))
+	if res.Error != nil {
+		return false, errors.WithStack(res.Error)
+	}
+	return res.RowsAffected > 0, nil
+}
+
 func DeleteSharingsByCreatorId(creatorId uint) error {
+	sharings := db.Model(&model.SharingDB{}).Select("id").Where("creator_id = ?", creatorId)
+	if err := db.Where(columnName("sharing_id")+" IN (?)", sharings).Delete(&model.SharingAccess{}).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	if err := db.Where(columnName("sharing_id")+" IN (?)", sharings).Delete(&model.SharingRecipient{}).Error; err != nil {
+		return errors.WithStack(err)
+	}
 	return errors.WithStack(db.Where("creator_id = ?", creatorId).Delete(&model.SharingDB{}).Error)
 }
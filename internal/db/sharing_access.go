@@ -0,0 +1,68 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func CreateSharingAccess(a *model.SharingAccess) error {
+	return errors.WithStack(db.Create(a).Error)
+}
+
+// GetSharingAccesses returns a page of the access log of the sharing, the latest first,
+// only the accesses of the recipient if it is not empty
+func GetSharingAccesses(sid, recipient string, pageIndex, pageSize int) (accesses []model.SharingAccess, count int64, err error) {
+	tx := db.Model(&model.SharingAccess{}).Where(columnName("sharing_id")+" = ?", sid)
+	if recipient != "" {
+		tx = tx.Where(columnName("recipient")+" = ?", recipient)
+	}
+	if err := tx.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get sharing accesses count")
+	}
+	if err := tx.Order(columnName("id") + " DESC").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&accesses).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find sharing accesses")
+	}
+	return accesses, count, nil
+}
+
+var sharingDownloadKinds = []string{model.SharingAccessDownload, model.SharingAccessArchive}
+
+// CountSharingDownloads counts the downloads of the file at path in the sharing, or of the file at inner in the archive at path
+func CountSharingDownloads(sid, path, inner string) (int64, error) {
+	var count int64
+	err := db.Model(&model.SharingAccess{}).
+		Where(columnName("sharing_id")+" = ? AND "+columnName("path")+" = ? AND "+columnName("inner_path")+" = ?", sid, path, inner).
+		Where(columnName("kind")+" IN ?", sharingDownloadKinds).
+		Count(&count).Error
+	return count, errors.Wrapf(err, "failed count sharing downloads")
+}
+
+func GetSharingStats(sid string) (*model.SharingStats, error) {
+	var stats model.SharingStats
+	where := db.Model(&model.SharingAccess{}).Where(columnName("sharing_id")+" = ?", sid)
+	err := where.Session(&gorm.Session{}).
+		Select("COUNT(*) AS accesses, COUNT(DISTINCT " + columnName("ip") + ") AS unique_visitors").
+		Scan(&stats).Error
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed get sharing stats")
+	}
+	err = where.Session(&gorm.Session{}).
+		Select(columnName("path")+" AS path, "+columnName("inner_path")+" AS inner_path, COUNT(*) AS downloads, SUM("+columnName("bytes")+") AS bytes").
+		Where(columnName("kind")+" IN ?", sharingDownloadKinds).
+		Group(columnName("path") + ", " + columnName("inner_path")).
+		Order("downloads DESC").
+		Scan(&stats.Files).Error
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed get sharing file stats")
+	}
+	for _, f := range stats.Files {
+		stats.Downloads += f.Downloads
+		stats.BytesServed += f.Bytes
+	}
+	return &stats, nil
+}
+
+func DeleteSharingAccesses(sid string) error {
+	return errors.WithStack(db.Where(columnName("sharing_id")+" = ?", sid).Delete(&model.SharingAccess{}).Error)
+}
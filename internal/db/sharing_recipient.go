@@ -0,0 +1,68 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func GetSharingRecipients(sid string) (recipients []model.SharingRecipient, err error) {
+	err = db.Where(columnName("sharing_id")+" = ?", sid).Order(columnName("id")).Find(&recipients).Error
+	return recipients, errors.Wrapf(err, "failed find sharing recipients")
+}
+
+func GetSharingRecipientById(id uint) (*model.SharingRecipient, error) {
+	var r model.SharingRecipient
+	if err := db.First(&r, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get sharing recipient")
+	}
+	return &r, nil
+}
+
+func GetSharingRecipientByEmail(sid, email string) (*model.SharingRecipient, error) {
+	var r model.SharingRecipient
+	if err := db.Where(columnName("sharing_id")+" = ? AND "+columnName("email")+" = ?", sid, email).First(&r).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get sharing recipient")
+	}
+	return &r, nil
+}
+
+func CreateSharingRecipient(r *model.SharingRecipient) error {
+	return errors.WithStack(db.Create(r).Error)
+}
+
+func UpdateSharingRecipient(r *model.SharingRecipient) error {
+	return errors.WithStack(db.Save(r).Error)
+}
+
+// CountSharingRecipientCodeAttempt counts an attempt at the code of the recipient, it reports
+// false without counting when there is no code or its attempts are used up
+func CountSharingRecipientCodeAttempt(id uint, maxAttempts int) (bool, error) {
+	res := db.Model(&model.SharingRecipient{}).
+		Where(columnName("id")+" = ? AND "+columnName("code_hash")+" <> '' AND "+columnName("code_attempts")+" < ?", id, maxAttempts).
+		UpdateColumn("code_attempts", gorm.Expr(columnName("code_attempts")+" + 1"))
+	if res.Error != nil {
+		return false, errors.Wrapf(res.Error, "failed count sharing recipient code attempt")
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// UseSharingRecipientCode clears the code of the recipient, it reports false when the code
+// was already used or replaced
+func UseSharingRecipientCode(id uint, codeHash string) (bool, error) {
+	res := db.Model(&model.SharingRecipient{}).
+		Where(columnName("id")+" = ? AND "+columnName("code_hash")+" = ?", id, codeHash).
+		UpdateColumns(map[string]any{"code_hash": "", "code_attempts": 0})
+	if res.Error != nil {
+		return false, errors.Wrapf(res.Error, "failed use sharing recipient code")
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func DeleteSharingRecipientById(id uint) error {
+	return errors.WithStack(db.Delete(&model.SharingRecipient{}, id).Error)
+}
+
+func DeleteSharingRecipients(sid string) error {
+	return errors.WithStack(db.Where(columnName("sharing_id")+" = ?", sid).Delete(&model.SharingRecipient{}).Error)
+}
@@ -0,0 +1,56 @@
+package db
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func GetSignedLinksByUserId(userId uint, pageIndex, pageSize int) (links []model.SignedLink, count int64, err error) {
+	linkDB := db.Model(&model.SignedLink{}).Where(columnName("user_id")+" = ?", userId)
+	if err := linkDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get user's signed links count")
+	}
+	if err := linkDB.Order(columnName("id") + " DESC").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&links).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find user's signed links")
+	}
+	return links, count, nil
+}
+
+func GetSignedLinkById(id uint) (*model.SignedLink, error) {
+	var l model.SignedLink
+	if err := db.First(&l, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get signed link")
+	}
+	return &l, nil
+}
+
+func CreateSignedLink(l *model.SignedLink) error {
+	return errors.WithStack(db.Create(l).Error)
+}
+
+// CountSignedLinkDownload counts a download of the link, it reports false without counting
+// when the link has no download left
+func CountSignedLinkDownload(id uint) (bool, error) {
+	res := db.Model(&model.SignedLink{}).
+		Where(columnName("id")+" = ? AND ("+columnName("max_downloads")+" = 0 OR "+columnName("downloads")+" < "+columnName("max_downloads")+")", id).
+		UpdateColumn("downloads", gorm.Expr(columnName("downloads")+" + 1"))
+	if res.Error != nil {
+		return false, errors.Wrapf(res.Error, "failed count signed link download")
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func DeleteSignedLinkById(id uint) error {
+	return errors.WithStack(db.Delete(&model.SignedLink{}, id).Error)
+}
+
+func DeleteSignedLinksByUserId(userId uint) error {
+	return errors.WithStack(db.Where(columnName("user_id")+" = ?", userId).Delete(&model.SignedLink{}).Error)
+}
+
+func DeleteSignedLinksExpiredBefore(t time.Time) error {
+	return errors.WithStack(db.Where(columnName("expires")+" < ?", t).Delete(&model.SignedLink{}).Error)
+}
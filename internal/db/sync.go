@@ -0,0 +1,98 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func GetSyncJobById(id uint) (*model.SyncJob, error) {
+	var j model.SyncJob
+	if err := db.First(&j, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get sync job")
+	}
+	return &j, nil
+}
+
+func GetSyncJobs(pageIndex, pageSize int) (jobs []model.SyncJob, count int64, err error) {
+	jobDB := db.Model(&model.SyncJob{})
+	if err := jobDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get sync jobs count")
+	}
+	if err := jobDB.Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&jobs).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find sync jobs")
+	}
+	return jobs, count, nil
+}
+
+func GetEnabledSyncJobs() (jobs []model.SyncJob, err error) {
+	if err := db.Where(columnName("disabled")+" = ?", false).Find(&jobs).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find enabled sync jobs")
+	}
+	return jobs, nil
+}
+
+func CreateSyncJob(j *model.SyncJob) error {
+	return errors.WithStack(db.Create(j).Error)
+}
+
+func UpdateSyncJob(j *model.SyncJob) error {
+	return errors.WithStack(db.Save(j).Error)
+}
+
+func DeleteSyncJobById(id uint) error {
+	if err := db.Where(columnName("job_id")+" = ?", id).Delete(&model.SyncRun{}).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	if err := DeleteSyncSnapshot(id); err != nil {
+		return err
+	}
+	return errors.WithStack(db.Delete(&model.SyncJob{}, id).Error)
+}
+
+func GetSyncRunsByJobId(jobId uint, pageIndex, pageSize int) (runs []model.SyncRun, count int64, err error) {
+	runDB := db.Model(&model.SyncRun{}).Where(columnName("job_id")+" = ?", jobId)
+	if err := runDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get sync runs count")
+	}
+	if err := runDB.Order(columnName("id") + " DESC").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&runs).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find sync runs")
+	}
+	return runs, count, nil
+}
+
+func CreateSyncRun(r *model.SyncRun) error {
+	return errors.WithStack(db.Create(r).Error)
+}
+
+func UpdateSyncRun(r *model.SyncRun) error {
+	return errors.WithStack(db.Save(r).Error)
+}
+
+func GetSyncSnapshot(jobId uint) (entries []model.SyncSnapshotEntry, err error) {
+	if err := db.Where(columnName("job_id")+" = ?", jobId).Find(&entries).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find sync snapshot")
+	}
+	return entries, nil
+}
+
+// SaveSyncSnapshot replaces the snapshot of the job with entries
+func SaveSyncSnapshot(jobId uint, entries []model.SyncSnapshotEntry) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where(columnName("job_id")+" = ?", jobId).Delete(&model.SyncSnapshotEntry{}).Error; err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+		for i := range entries {
+			entries[i].ID = 0
+			entries[i].JobId = jobId
+		}
+		return tx.CreateInBatches(entries, 100).Error
+	}))
+}
+
+func DeleteSyncSnapshot(jobId uint) error {
+	return errors.WithStack(db.Where(columnName("job_id")+" = ?", jobId).Delete(&model.SyncSnapshotEntry{}).Error)
+}
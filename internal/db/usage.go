@@ -0,0 +1,37 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// GetUserUsage returns the usage of the user, a user without any is returned empty
+func GetUserUsage(userId uint) (*model.UserUsage, error) {
+	u := model.UserUsage{UserId: userId}
+	if err := db.Where(u).Limit(1).Find(&u).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get user usage")
+	}
+	return &u, nil
+}
+
+// AddUserUsage adds the deltas to the usage of the user, the usage never goes below zero
+func AddUserUsage(userId uint, bytes, files int64) error {
+	if err := db.FirstOrCreate(&model.UserUsage{}, model.UserUsage{UserId: userId}).Error; err != nil {
+		return errors.Wrapf(err, "failed create user usage")
+	}
+	bytesCol, filesCol := columnName("bytes"), columnName("files")
+	err := db.Model(&model.UserUsage{}).Where(columnName("user_id")+" = ?", userId).Updates(map[string]any{
+		"bytes": gorm.Expr("CASE WHEN "+bytesCol+" + ? < 0 THEN 0 ELSE "+bytesCol+" + ? END", bytes, bytes),
+		"files": gorm.Expr("CASE WHEN "+filesCol+" + ? < 0 THEN 0 ELSE "+filesCol+" + ? END", files, files),
+	}).Error
+	return errors.Wrapf(err, "failed update user usage")
+}
+
+func SaveUserUsage(u *model.UserUsage) error {
+	return errors.WithStack(db.Save(u).Error)
+}
+
+func DeleteUserUsage(userId uint) error {
+	return errors.WithStack(db.Delete(&model.UserUsage{}, userId).Error)
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"gorm.io/gorm"
@@ -17,3 +18,12 @@ func columnName(name string) string {
 func addStorageOrder(db *gorm.DB) *gorm.DB {
 	return db.Order(fmt.Sprintf("%s, %s", columnName("order"), columnName("id")))
 }
+
+// likeEscaper escapes the LIKE metacharacters with !, which is an escape character every database takes
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// whereUnder matches the rows whose column is path or below it, the names may contain % and _
+func whereUnder(tx *gorm.DB, column, path string) *gorm.DB {
+	prefix := likeEscaper.Replace(strings.TrimSuffix(path, "/")) + "/%"
+	return tx.Where(columnName(column)+" = ? OR "+columnName(column)+" LIKE ? ESCAPE '!'", path, prefix)
+}
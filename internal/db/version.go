@@ -0,0 +1,31 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetFileVersionById(id uint) (*model.FileVersion, error) {
+	var v model.FileVersion
+	if err := db.First(&v, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get file version")
+	}
+	return &v, nil
+}
+
+// GetFileVersionsByPath returns the versions of a file, newest first
+func GetFileVersionsByPath(path string) (versions []model.FileVersion, err error) {
+	cond := model.FileVersion{Path: path}
+	if err := db.Where(cond).Order(columnName("created") + " DESC").Order(columnName("id") + " DESC").Find(&versions).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find file versions")
+	}
+	return versions, nil
+}
+
+func CreateFileVersion(v *model.FileVersion) error {
+	return errors.WithStack(db.Create(v).Error)
+}
+
+func DeleteFileVersionById(id uint) error {
+	return errors.WithStack(db.Delete(&model.FileVersion{}, id).Error)
+}
@@ -0,0 +1,47 @@
+package db
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func CreateWebDAVLock(l *model.WebDAVLock) error {
+	return errors.WithStack(db.Create(l).Error)
+}
+
+func GetWebDAVLock(token string) (*model.WebDAVLock, error) {
+	var l model.WebDAVLock
+	if err := db.Where("token = ?", token).First(&l).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get webdav lock")
+	}
+	return &l, nil
+}
+
+// GetWebDAVLocksOn returns the locks whose root is one of roots or is path or below it,
+// expired ones included
+func GetWebDAVLocksOn(roots []string, path string) (locks []model.WebDAVLock, err error) {
+	if err := whereUnder(db, "root", path).Or(columnName("root")+" IN ?", roots).
+		Find(&locks).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find webdav locks")
+	}
+	return locks, nil
+}
+
+func UpdateWebDAVLock(l *model.WebDAVLock) error {
+	return errors.WithStack(db.Save(l).Error)
+}
+
+func DeleteWebDAVLock(token string) error {
+	return errors.WithStack(db.Where("token = ?", token).Delete(&model.WebDAVLock{}).Error)
+}
+
+// DeleteWebDAVLocksUnder deletes the locks rooted at path or below it
+func DeleteWebDAVLocksUnder(path string) error {
+	return errors.WithStack(whereUnder(db, "root", path).Delete(&model.WebDAVLock{}).Error)
+}
+
+func DeleteExpiredWebDAVLocks(now time.Time) error {
+	return errors.WithStack(db.Where(columnName("expiry")+" <= ?", now).Delete(&model.WebDAVLock{}).Error)
+}
@@ -0,0 +1,88 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func GetWebDAVProps(path string) (props []model.WebDAVProp, err error) {
+	if err := db.Where(model.WebDAVProp{Path: path}).Find(&props).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find webdav props")
+	}
+	return props, nil
+}
+
+// SetWebDAVProps replaces the props of path with props
+func SetWebDAVProps(path string, props []model.WebDAVProp) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where(model.WebDAVProp{Path: path}).Delete(&model.WebDAVProp{}).Error; err != nil {
+			return err
+		}
+		for i := range props {
+			props[i].ID = 0
+			props[i].Path = path
+		}
+		if len(props) == 0 {
+			return nil
+		}
+		return tx.Create(&props).Error
+	}))
+}
+
+// MoveWebDAVProps moves the props of src and of everything below it to dst, replacing the
+// ones dst had
+func MoveWebDAVProps(src, dst string) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		props, err := findWebDAVPropsUnder(tx, src)
+		if err != nil || len(props) == 0 {
+			return err
+		}
+		if err := deleteWebDAVPropsUnder(tx, dst); err != nil {
+			return err
+		}
+		for _, p := range props {
+			err := tx.Model(&model.WebDAVProp{}).Where("id = ?", p.ID).
+				Update("path", dst+p.Path[len(src):]).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+// CopyWebDAVProps copies the props of src and of everything below it to dst. A path below
+// dst keeps its props unless the copy brings some, as merged dirs keep their existing files.
+func CopyWebDAVProps(src, dst string) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		props, err := findWebDAVPropsUnder(tx, src)
+		if err != nil || len(props) == 0 {
+			return err
+		}
+		paths := make([]string, 0, len(props))
+		for i := range props {
+			props[i].ID = 0
+			props[i].Path = dst + props[i].Path[len(src):]
+			paths = append(paths, props[i].Path)
+		}
+		if err := tx.Where(columnName("path")+" IN ?", paths).Delete(&model.WebDAVProp{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&props).Error
+	}))
+}
+
+// DeleteWebDAVProps deletes the props of path and of everything below it
+func DeleteWebDAVProps(path string) error {
+	return errors.WithStack(deleteWebDAVPropsUnder(db, path))
+}
+
+func findWebDAVPropsUnder(tx *gorm.DB, path string) (props []model.WebDAVProp, err error) {
+	err = whereUnder(tx, "path", path).Find(&props).Error
+	return props, err
+}
+
+func deleteWebDAVPropsUnder(tx *gorm.DB, path string) error {
+	return whereUnder(tx, "path", path).Delete(&model.WebDAVProp{}).Error
+}
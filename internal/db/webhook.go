@@ -0,0 +1,80 @@
+package db
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetWebhooks() ([]model.Webhook, error) {
+	var hooks []model.Webhook
+	if err := db.Order(columnName("id")).Find(&hooks).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find webhooks")
+	}
+	return hooks, nil
+}
+
+func GetWebhookById(id uint) (*model.Webhook, error) {
+	var w model.Webhook
+	if err := db.First(&w, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get webhook")
+	}
+	return &w, nil
+}
+
+func CreateWebhook(w *model.Webhook) error {
+	return errors.WithStack(db.Create(w).Error)
+}
+
+func UpdateWebhook(w *model.Webhook) error {
+	return errors.WithStack(db.Save(w).Error)
+}
+
+func DeleteWebhookById(id uint) error {
+	if err := db.Where(columnName("webhook_id")+" = ?", id).Delete(&model.WebhookDelivery{}).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(db.Delete(&model.Webhook{}, id).Error)
+}
+
+func CreateWebhookDelivery(d *model.WebhookDelivery) error {
+	return errors.WithStack(db.Create(d).Error)
+}
+
+func UpdateWebhookDelivery(d *model.WebhookDelivery) error {
+	return errors.WithStack(db.Save(d).Error)
+}
+
+func GetWebhookDeliveryById(id uint) (*model.WebhookDelivery, error) {
+	var d model.WebhookDelivery
+	if err := db.First(&d, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get webhook delivery")
+	}
+	return &d, nil
+}
+
+// GetWebhookDeliveries returns a page of the deliveries of the webhook, the latest first
+func GetWebhookDeliveries(webhookId uint, pageIndex, pageSize int) (deliveries []model.WebhookDelivery, count int64, err error) {
+	tx := db.Model(&model.WebhookDelivery{}).Where(columnName("webhook_id")+" = ?", webhookId)
+	if err := tx.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get webhook deliveries count")
+	}
+	if err := tx.Order(columnName("id") + " DESC").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&deliveries).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find webhook deliveries")
+	}
+	return deliveries, count, nil
+}
+
+func GetPendingWebhookDeliveries() ([]model.WebhookDelivery, error) {
+	var deliveries []model.WebhookDelivery
+	if err := db.Where(columnName("status")+" = ?", model.DeliveryPending).Order(columnName("id")).Find(&deliveries).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find pending webhook deliveries")
+	}
+	return deliveries, nil
+}
+
+func DeleteWebhookDeliveriesBefore(t time.Time) (int64, error) {
+	res := db.Where(columnName("created_at")+" < ? AND "+columnName("status")+" <> ?", t, model.DeliveryPending).Delete(&model.WebhookDelivery{})
+	return res.RowsAffected, errors.WithStack(res.Error)
+}
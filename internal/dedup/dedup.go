@@ -0,0 +1,308 @@
+package dedup
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/search"
+	"github.com/OpenListTeam/OpenList/v4/internal/task"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/tache"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// searchPageSize is the number of index nodes read at a time while collecting the candidates
+const searchPageSize = 1000
+
+type ScanTask struct {
+	task.TaskExtension
+	Status string   `json:"-"`
+	ScanId uint     `json:"scan_id"`
+	Paths  []string `json:"paths"`
+}
+
+func (t *ScanTask) GetName() string {
+	return fmt.Sprintf("find duplicates in %s", strings.Join(t.Paths, ", "))
+}
+
+func (t *ScanTask) GetStatus() string {
+	return t.Status
+}
+
+func (t *ScanTask) Run() error {
+	t.ClearEndTime()
+	t.SetStartTime(time.Now())
+	defer func() { t.SetEndTime(time.Now()) }()
+	scan, err := op.GetDuplicateScanById(t.ScanId)
+	if err != nil {
+		return err
+	}
+	scan.StartTime = time.Now()
+	scan.Status = "running"
+	if err = op.UpdateDuplicateScan(scan); err != nil {
+		return err
+	}
+	sets, err := t.find(t.Ctx(), scan)
+	if err == nil {
+		err = op.CreateDuplicateSets(sets)
+	}
+	end := time.Now()
+	scan.EndTime = &end
+	if err != nil {
+		scan.Status = "failed"
+		scan.Error = err.Error()
+	} else {
+		scan.Status = "succeeded"
+		scan.Sets = int64(len(sets))
+		for _, set := range sets {
+			scan.WastedBytes += set.Size * int64(len(set.Paths)-1)
+		}
+	}
+	if uErr := op.UpdateDuplicateScan(scan); uErr != nil {
+		log.Errorf("failed update duplicate scan %d: %+v", scan.ID, uErr)
+	}
+	return err
+}
+
+func (t *ScanTask) find(ctx context.Context, scan *model.DuplicateScan) ([]model.DuplicateSet, error) {
+	t.Status = "collecting files from the search index"
+	bySize, err := collect(ctx, scan.Paths, scan.MinSize)
+	if err != nil {
+		return nil, err
+	}
+	sizes := make([]int64, 0, len(bySize))
+	for size, files := range bySize {
+		scan.Files += int64(len(files))
+		if len(files) > 1 {
+			sizes = append(sizes, size)
+		}
+	}
+	// the largest files waste the most space
+	sort.Slice(sizes, func(i, j int) bool { return sizes[i] > sizes[j] })
+	var sets []model.DuplicateSet
+	for i, size := range sizes {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		t.Status = fmt.Sprintf("comparing files of %d bytes", size)
+		for _, set := range group(ctx, bySize[size], fs.PartialHash) {
+			set.ScanId = scan.ID
+			sets = append(sets, set)
+		}
+		t.SetProgress(float64(i+1) / float64(len(sizes)) * 100)
+	}
+	t.Status = fmt.Sprintf("found %d duplicate sets", len(sets))
+	return sets, nil
+}
+
+type candidate struct {
+	path string
+	size int64
+	// hashes are the indexed hashes formatted as <hash type>:<hash>
+	hashes []string
+}
+
+// collect reads the indexed files under paths of at least minSize bytes and groups them by size
+func collect(ctx context.Context, paths []string, minSize int64) (map[int64][]candidate, error) {
+	bySize := make(map[int64][]candidate)
+	seen := make(map[string]struct{})
+	for _, p := range paths {
+		for page := 1; ; page++ {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			nodes, total, err := search.Search(ctx, model.SearchReq{
+				Parent:  p,
+				Scope:   2,
+				Filters: model.SearchFilters{MinSize: &minSize},
+				PageReq: model.PageReq{Page: page, PerPage: searchPageSize},
+			})
+			if err != nil {
+				return nil, errors.WithMessagef(err, "failed search files in [%s]", p)
+			}
+			for i := range nodes {
+				node := &nodes[i]
+				nodePath := stdpath.Join(node.Parent, node.Name)
+				if _, ok := seen[nodePath]; ok || node.IsDir {
+					continue
+				}
+				seen[nodePath] = struct{}{}
+				bySize[node.Size] = append(bySize[node.Size], candidate{
+					path:   nodePath,
+					size:   node.Size,
+					hashes: node.HashList(),
+				})
+			}
+			if len(nodes) == 0 || int64(page*searchPageSize) >= total {
+				break
+			}
+		}
+	}
+	return bySize, nil
+}
+
+// group splits files of the same size into sets of identical files. Files sharing an indexed hash
+// are the same, the ones without any hash are compared by partialHash, which is then also computed
+// for one file of every hashed group so both kinds can end up in the same set.
+func group(ctx context.Context, files []candidate, partialHash func(ctx context.Context, path string) (string, error)) []model.DuplicateSet {
+	parent := make([]int, len(files))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	owners := make(map[string]int)
+	join := func(i int, key string) {
+		if j, ok := owners[key]; ok {
+			parent[find(i)] = find(j)
+		} else {
+			owners[key] = i
+		}
+	}
+	var unhashed []int
+	for i := range files {
+		for _, h := range files[i].hashes {
+			join(i, h)
+		}
+		if len(files[i].hashes) == 0 {
+			unhashed = append(unhashed, i)
+		}
+	}
+	hashedRoot := make([]int, len(files))
+	for i := range files {
+		hashedRoot[i] = find(i)
+	}
+	partial := make(map[int]string)
+	if len(unhashed) > 0 {
+		toHash := unhashed
+		for i := range files {
+			if len(files[i].hashes) > 0 && hashedRoot[i] == i {
+				toHash = append(toHash, i)
+			}
+		}
+		for _, i := range toHash {
+			if ctx.Err() != nil {
+				break
+			}
+			h, err := partialHash(ctx, files[i].path)
+			if err != nil {
+				// the file can't be compared, leave it alone
+				continue
+			}
+			partial[i] = "partial:" + h
+			join(i, partial[i])
+		}
+	}
+
+	members := make(map[int][]int)
+	var roots []int
+	for i := range files {
+		if len(files[i].hashes) == 0 && partial[i] == "" {
+			continue
+		}
+		r := find(i)
+		if _, ok := members[r]; !ok {
+			roots = append(roots, r)
+		}
+		members[r] = append(members[r], i)
+	}
+	var sets []model.DuplicateSet
+	for _, r := range roots {
+		idx := members[r]
+		if len(idx) < 2 {
+			continue
+		}
+		set := model.DuplicateSet{Size: files[idx[0]].size}
+		hashedRoots := make(map[int]struct{})
+		for _, i := range idx {
+			set.Paths = append(set.Paths, files[i].path)
+			if len(files[i].hashes) == 0 {
+				set.Partial = true
+			} else {
+				hashedRoots[hashedRoot[i]] = struct{}{}
+			}
+		}
+		set.Partial = set.Partial || len(hashedRoots) > 1
+		set.Hash = commonHash(files, idx, partial, set.Partial)
+		sort.Strings(set.Paths)
+		sets = append(sets, set)
+	}
+	return sets
+}
+
+// commonHash returns the hash shared by the most files of the set
+func commonHash(files []candidate, idx []int, partial map[int]string, isPartial bool) string {
+	if isPartial {
+		for _, i := range idx {
+			if partial[i] != "" {
+				return partial[i]
+			}
+		}
+	}
+	count := make(map[string]int)
+	best := ""
+	for _, i := range idx {
+		for _, h := range files[i].hashes {
+			count[h]++
+			if count[h] > count[best] || count[h] == count[best] && h < best {
+				best = h
+			}
+		}
+	}
+	return best
+}
+
+var ScanTaskManager *tache.Manager[*ScanTask]
+
+// StartScan adds a task finding the duplicate files under paths and returns immediately
+func StartScan(ctx context.Context, paths []string, minSize int64) (task.TaskExtensionInfo, error) {
+	if ScanTaskManager == nil {
+		return nil, errors.New("duplicate scan task manager is not initialized")
+	}
+	if len(paths) == 0 {
+		paths = []string{"/"}
+	}
+	for i := range paths {
+		paths[i] = utils.FixAndCleanPath(paths[i])
+	}
+	if minSize < 1 {
+		// empty files are all the same
+		minSize = 1
+	}
+	creator, _ := ctx.Value(conf.UserKey).(*model.User)
+	scan := &model.DuplicateScan{
+		Paths:     paths,
+		MinSize:   minSize,
+		StartTime: time.Now(),
+		Status:    "pending",
+	}
+	if creator != nil {
+		scan.Creator = creator.Username
+	}
+	if err := op.CreateDuplicateScan(scan); err != nil {
+		return nil, err
+	}
+	t := &ScanTask{
+		TaskExtension: task.TaskExtension{
+			Creator: creator,
+		},
+		ScanId: scan.ID,
+		Paths:  paths,
+	}
+	ScanTaskManager.Add(t)
+	return t, nil
+}
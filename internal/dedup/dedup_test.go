@@ -0,0 +1,58 @@
+package dedup
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+func TestGroup(t *testing.T) {
+	files := []candidate{
+		{path: "/a/1.mkv", size: 10, hashes: []string{"md5:aa"}},
+		{path: "/b/1.mkv", size: 10, hashes: []string{"md5:aa", "sha1:bb"}},
+		{path: "/c/1.mkv", size: 10, hashes: []string{"sha1:bb"}},
+		{path: "/a/2.mkv", size: 10, hashes: []string{"md5:cc"}},
+		{path: "/d/1.mkv", size: 10},
+		{path: "/d/2.mkv", size: 10},
+		{path: "/d/3.mkv", size: 10},
+		{path: "/d/broken.mkv", size: 10},
+	}
+	partial := map[string]string{
+		"/a/1.mkv": "x",
+		"/a/2.mkv": "y",
+		"/d/1.mkv": "x",
+		"/d/2.mkv": "z",
+		"/d/3.mkv": "z",
+	}
+	var hashed []string
+	partialHash := func(ctx context.Context, path string) (string, error) {
+		hashed = append(hashed, path)
+		if h, ok := partial[path]; ok {
+			return h, nil
+		}
+		return "", errors.New("failed")
+	}
+	want := []model.DuplicateSet{
+		{Size: 10, Hash: "partial:x", Partial: true, Paths: []string{"/a/1.mkv", "/b/1.mkv", "/c/1.mkv", "/d/1.mkv"}},
+		{Size: 10, Hash: "partial:z", Partial: true, Paths: []string{"/d/2.mkv", "/d/3.mkv"}},
+	}
+	got := group(context.Background(), files, partialHash)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("group() = %+v, want %+v", got, want)
+	}
+	// only one file of every hashed group is hashed
+	if len(hashed) != 6 {
+		t.Errorf("partial hashed %v, want 6 files", hashed)
+	}
+
+	got = group(context.Background(), files[:4], partialHash)
+	want = []model.DuplicateSet{
+		{Size: 10, Hash: "md5:aa", Paths: []string{"/a/1.mkv", "/b/1.mkv", "/c/1.mkv"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("group() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,73 @@
+package dedup
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// Resolve keeps the file at keep and removes the other files of the set, or replaces them
+// with hard links to keep. Files whose size changed since the scan are left alone.
+func Resolve(ctx context.Context, set *model.DuplicateSet, keep, action string, allowPartial bool) error {
+	if set.Resolved {
+		return errors.Errorf("duplicate set %d is already resolved", set.ID)
+	}
+	if set.Partial && !allowPartial {
+		return errors.Errorf("duplicate set %d is only compared by the head and the tail of the files", set.ID)
+	}
+	if !utils.SliceContains(set.Paths, keep) {
+		return errors.Errorf("[%s] is not in duplicate set %d", keep, set.ID)
+	}
+	if action != model.DuplicateActionRemove && action != model.DuplicateActionHardLink {
+		return errors.Errorf("unknown action: %s", action)
+	}
+	if err := checkSize(ctx, keep, set.Size); err != nil {
+		return err
+	}
+	var failed []string
+	for _, p := range set.Paths {
+		if p == keep {
+			continue
+		}
+		err := checkSize(ctx, p, set.Size)
+		if err == nil {
+			if action == model.DuplicateActionRemove {
+				err = fs.Remove(ctx, p)
+			} else {
+				err = fs.HardLink(ctx, keep, p)
+			}
+		}
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", p, err))
+		}
+	}
+	set.Resolved = len(failed) == 0
+	set.Result = fmt.Sprintf("%s, kept %s", action, keep)
+	if len(failed) > 0 {
+		set.Result += "\nfailed:\n" + strings.Join(failed, "\n")
+	}
+	if err := op.UpdateDuplicateSet(set); err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		return errors.Errorf("%d of %d files failed", len(failed), len(set.Paths)-1)
+	}
+	return nil
+}
+
+func checkSize(ctx context.Context, path string, size int64) error {
+	obj, err := fs.Get(ctx, path, &fs.GetArgs{NoLog: true})
+	if err != nil {
+		return err
+	}
+	if obj.IsDir() || obj.GetSize() != size {
+		return errors.Errorf("[%s] changed since the scan", path)
+	}
+	return nil
+}
@@ -76,6 +76,11 @@ type Remove interface {
 	Remove(ctx context.Context, obj model.Obj) error
 }
 
+type HardLink interface {
+	// HardLink replaces dstObj with a hard link to srcObj, both are files of the storage
+	HardLink(ctx context.Context, srcObj, dstObj model.Obj) error
+}
+
 type Put interface {
 	// Put a file (provided as a FileStreamer) into the driver
 	// Besides the most basic upload functionality, the following features also need to be implemented:
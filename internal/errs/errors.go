@@ -26,6 +26,13 @@ var (
 	WrongShareCode  = errors.New("wrong share code")
 	InvalidSharing  = errors.New("invalid sharing")
 	SharingNotFound = errors.New("sharing not found")
+	// UploadOnlySharing is returned by every read of a sharing collecting uploads
+	UploadOnlySharing = errors.New("sharing only accepts uploads")
+	NotUploadSharing  = errors.New("sharing does not accept uploads")
+	// SharingLimitExceeded is returned by the downloads of a sharing beyond its bandwidth or downloads per file
+	SharingLimitExceeded = errors.New("sharing download limit exceeded")
+	// RecipientTokenRequired is returned by a restricted sharing accessed without a valid recipient token
+	RecipientTokenRequired = errors.New("sharing is only accessible to its recipients")
 )
 
 // NewErr wrap constant error with an extra message
@@ -4,4 +4,6 @@ import "errors"
 
 var (
 	PermissionDenied = errors.New("permission denied")
+	QuotaExceeded    = errors.New("storage quota exceeded")
+	Locked           = errors.New("locked by a webdav client")
 )
@@ -0,0 +1,30 @@
+package fs
+
+import (
+	"context"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/pkg/errors"
+)
+
+// checkACL checks the acl rules for the user in ctx, a ctx without user is the system itself
+// unless it is marked anonymous. The internal dirs of the storages are out of reach for both.
+func checkACL(ctx context.Context, operation string, paths ...string) error {
+	if err := checkInternal(ctx, paths...); err != nil {
+		return err
+	}
+	user, ok := ctx.Value(conf.UserKey).(*model.User)
+	anonymous, _ := ctx.Value(conf.AnonymousKey).(bool)
+	if !ok && !anonymous {
+		return nil
+	}
+	for _, p := range paths {
+		if ok && !op.ACLAllows(user, operation, p) || !ok && !op.ACLAllowsAnonymous(operation, p) {
+			return errors.WithMessagef(errs.PermissionDenied, "%s of [%s] is denied", operation, p)
+		}
+	}
+	return nil
+}
@@ -348,6 +348,9 @@ var ArchiveContentUploadTaskManager = &archiveContentUploadTaskManagerType{
 }
 
 func archiveMeta(ctx context.Context, path string, args model.ArchiveMetaArgs) (*model.ArchiveMetaProvider, error) {
+	if err := checkACL(ctx, model.ACLRead, path); err != nil {
+		return nil, err
+	}
 	storage, actualPath, err := op.GetStorageAndActualPath(path)
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed get storage")
@@ -356,6 +359,9 @@ func archiveMeta(ctx context.Context, path string, args model.ArchiveMetaArgs) (
 }
 
 func archiveList(ctx context.Context, path string, args model.ArchiveListArgs) ([]model.Obj, error) {
+	if err := checkACL(ctx, model.ACLRead, path); err != nil {
+		return nil, err
+	}
 	storage, actualPath, err := op.GetStorageAndActualPath(path)
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed get storage")
@@ -364,6 +370,12 @@ func archiveList(ctx context.Context, path string, args model.ArchiveListArgs) (
 }
 
 func archiveDecompress(ctx context.Context, srcObjPath, dstDirPath string, args model.ArchiveDecompressArgs, lazyCache ...bool) (task.TaskExtensionInfo, error) {
+	if err := checkACL(ctx, model.ACLDecompress, srcObjPath); err != nil {
+		return nil, err
+	}
+	if err := checkACL(ctx, model.ACLUpload, dstDirPath); err != nil {
+		return nil, err
+	}
 	srcStorage, srcObjActualPath, err := op.GetStorageAndActualPath(srcObjPath)
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed get src storage")
@@ -422,6 +434,9 @@ func archiveDecompress(ctx context.Context, srcObjPath, dstDirPath string, args
 }
 
 func archiveDriverExtract(ctx context.Context, path string, args model.ArchiveInnerArgs) (*model.Link, model.Obj, error) {
+	if err := checkACL(ctx, model.ACLRead, path); err != nil {
+		return nil, nil, err
+	}
 	storage, actualPath, err := op.GetStorageAndActualPath(path)
 	if err != nil {
 		return nil, nil, errors.WithMessage(err, "failed get storage")
@@ -430,6 +445,9 @@ func archiveDriverExtract(ctx context.Context, path string, args model.ArchiveIn
 }
 
 func archiveInternalExtract(ctx context.Context, path string, args model.ArchiveInnerArgs) (io.ReadCloser, int64, error) {
+	if err := checkACL(ctx, model.ACLRead, path); err != nil {
+		return nil, 0, err
+	}
 	storage, actualPath, err := op.GetStorageAndActualPath(path)
 	if err != nil {
 		return nil, 0, errors.WithMessage(err, "failed get storage")
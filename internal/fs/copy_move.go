@@ -7,16 +7,20 @@ import (
 	"time"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
 	"github.com/OpenListTeam/OpenList/v4/internal/stream"
 	"github.com/OpenListTeam/OpenList/v4/internal/task"
 	"github.com/OpenListTeam/OpenList/v4/internal/task_group"
+	"github.com/OpenListTeam/OpenList/v4/internal/webhook"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
 	"github.com/OpenListTeam/tache"
 	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 )
 
 type taskType uint8
@@ -43,6 +47,9 @@ const (
 type FileTransferTask struct {
 	TaskData
 	TaskType taskType
+	// Verify checks every copied file against its source, see verifyTransfer
+	Verify   bool   `json:"verify"`
+	Checksum string `json:"checksum,omitempty"`
 	groupID  string
 }
 
@@ -80,10 +87,38 @@ func (t *FileTransferTask) Run() error {
 
 func (t *FileTransferTask) OnSucceeded() {
 	task_group.TransferCoordinator.Done(context.WithoutCancel(t.Ctx()), t.groupID, true)
+	t.emit(nil)
 }
 
 func (t *FileTransferTask) OnFailed() {
 	task_group.TransferCoordinator.Done(context.WithoutCancel(t.Ctx()), t.groupID, false)
+	t.emit(t.GetErr())
+}
+
+// emit tells the webhooks the outcome of the task, a dir is done once its own task has
+// added the tasks of its entries
+func (t *FileTransferTask) emit(err error) {
+	e := &webhook.Event{
+		Path:    stdpath.Join(t.SrcStorageMp, t.SrcActualPath),
+		DstPath: stdpath.Join(t.DstStorageMp, t.DstActualPath),
+	}
+	if err == nil {
+		transferProps(t.TaskType, e.Path, e.DstPath)
+		if t.TaskType == move {
+			removeLocks(e.Path)
+		}
+	}
+	switch {
+	case t.TaskType == move && err == nil:
+		e.Event = model.EventMove
+	case t.TaskType == move:
+		return
+	case err == nil:
+		e.Event = model.EventCopySucceeded
+	default:
+		e.Event, e.Error = model.EventCopyFailed, err.Error()
+	}
+	webhook.Emit(t.Ctx(), e)
 }
 
 func (t *FileTransferTask) SetRetry(retry int, maxRetry int) {
@@ -101,6 +136,23 @@ func (t *FileTransferTask) SetRetry(retry int, maxRetry int) {
 }
 
 func transfer(ctx context.Context, taskType taskType, srcObjPath, dstDirPath string, skipHook ...bool) (task.TaskExtensionInfo, error) {
+	operation := model.ACLCopy
+	if taskType == move {
+		operation = model.ACLMove
+	}
+	if err := checkACL(ctx, operation, srcObjPath); err != nil {
+		return nil, err
+	}
+	if err := checkACL(ctx, model.ACLUpload, stdpath.Join(dstDirPath, stdpath.Base(srcObjPath))); err != nil {
+		return nil, err
+	}
+	lockedPaths := []string{stdpath.Join(dstDirPath, stdpath.Base(srcObjPath))}
+	if taskType == move {
+		lockedPaths = append(lockedPaths, srcObjPath)
+	}
+	if err := checkLock(ctx, lockedPaths...); err != nil {
+		return nil, err
+	}
 	srcStorage, srcObjActualPath, err := op.GetStorageAndActualPath(srcObjPath)
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed get src storage")
@@ -115,9 +167,13 @@ func transfer(ctx context.Context, taskType taskType, srcObjPath, dstDirPath str
 			ctx = context.WithValue(ctx, conf.SkipHookKey, struct{}{})
 		}
 		if taskType == copy || taskType == merge {
-			err = op.Copy(ctx, srcStorage, srcObjActualPath, dstDirActualPath)
-			if !errors.Is(err, errs.NotImplement) && !errors.Is(err, errs.NotSupport) {
-				return nil, err
+			// the storage copies dirs without telling their size, users with a quota
+			// copy file by file instead so that every file is checked
+			if !op.HasQuota(ctx) {
+				err = op.Copy(ctx, srcStorage, srcObjActualPath, dstDirActualPath)
+				if !errors.Is(err, errs.NotImplement) && !errors.Is(err, errs.NotSupport) {
+					return nil, err
+				}
 			}
 		} else {
 			err = op.Move(ctx, srcStorage, srcObjActualPath, dstDirActualPath)
@@ -138,6 +194,7 @@ func transfer(ctx context.Context, taskType taskType, srcObjPath, dstDirPath str
 			DstStorageMp:  dstStorage.GetStorage().MountPath,
 		},
 		TaskType: taskType,
+		Verify:   ctx.Value(conf.TransferVerifyKey) != nil || setting.GetBool(conf.TransferVerify),
 	}
 
 	t.groupID = stdpath.Join(t.DstStorageMp, t.DstActualPath)
@@ -222,6 +279,7 @@ func (t *FileTransferTask) RunWithNextTaskCallback(f func(nextTask *FileTransfer
 
 			err = f(&FileTransferTask{
 				TaskType: t.TaskType,
+				Verify:   t.Verify,
 				TaskData: TaskData{
 					TaskExtension: task.TaskExtension{
 						Creator: t.Creator,
@@ -245,22 +303,47 @@ func (t *FileTransferTask) RunWithNextTaskCallback(f func(nextTask *FileTransfer
 	}
 
 	t.Status = "getting src object link"
-	link, srcObj, err := op.Link(t.Ctx(), t.SrcStorage, t.SrcActualPath, model.LinkArgs{})
+	ss, err := linkStream(t.Ctx(), t.SrcStorage, t.SrcActualPath)
 	if err != nil {
-		return errors.WithMessagef(err, "failed get [%s] link", t.SrcActualPath)
+		return err
+	}
+	t.SetTotalBytes(ss.GetSize())
+	t.Status = "uploading"
+	err = op.Put(context.WithValue(t.Ctx(), conf.SkipHookKey, struct{}{}), t.DstStorage, t.DstActualPath, ss, t.SetProgress)
+	if err != nil || !t.Verify {
+		return err
+	}
+	t.Status = "verifying"
+	dstActualPath := stdpath.Join(t.DstActualPath, srcObj.GetName())
+	checksum, err := verifyTransfer(t.Ctx(), t.SrcStorage, t.SrcActualPath, srcObj, t.DstStorage, dstActualPath)
+	if err != nil {
+		// the broken copy must not be kept, or the src of a move would be removed as if it was transferred
+		if rmErr := op.Remove(context.WithoutCancel(t.Ctx()), t.DstStorage, dstActualPath); rmErr != nil {
+			log.Errorf("failed remove unverified file [%s]%s: %+v", t.DstStorageMp, dstActualPath, rmErr)
+		}
+		return errors.WithMessage(err, "verification failed")
+	}
+	t.Checksum = checksum
+	t.Status = "verified " + checksum
+	return nil
+}
+
+// linkStream opens the file at actualPath as a stream that can be put into another storage
+func linkStream(ctx context.Context, storage driver.Driver, actualPath string) (model.FileStreamer, error) {
+	link, obj, err := op.Link(ctx, storage, actualPath, model.LinkArgs{})
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed get [%s] link", actualPath)
 	}
 	// any link provided is seekable
 	ss, err := stream.NewSeekableStream(&stream.FileStream{
-		Obj: srcObj,
-		Ctx: t.Ctx(),
+		Obj: obj,
+		Ctx: ctx,
 	}, link)
 	if err != nil {
 		_ = link.Close()
-		return errors.WithMessagef(err, "failed get [%s] stream", t.SrcActualPath)
+		return nil, errors.WithMessagef(err, "failed get [%s] stream", actualPath)
 	}
-	t.SetTotalBytes(ss.GetSize())
-	t.Status = "uploading"
-	return op.Put(context.WithValue(t.Ctx(), conf.SkipHookKey, struct{}{}), t.DstStorage, t.DstActualPath, ss, t.SetProgress)
+	return ss, nil
 }
 
 var (
@@ -0,0 +1,76 @@
+package fs
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/http_range"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// partialHashChunk is the size read from both the head and the tail of a file by partialHash
+const partialHashChunk = 64 * 1024
+
+// partialHash hashes the size, the head and the tail of the file at path, it is cheap
+// to compute for large files but only tells that two files are very likely the same.
+func partialHash(ctx context.Context, path string) (string, error) {
+	if err := checkInternal(ctx, path); err != nil {
+		return "", err
+	}
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return "", errors.WithMessage(err, "failed get storage")
+	}
+	ss, err := linkStream(ctx, storage, actualPath)
+	if err != nil {
+		return "", err
+	}
+	defer ss.Close()
+	if ss.IsDir() {
+		return "", errors.WithStack(errs.NotFile)
+	}
+	size := ss.GetSize()
+	h := md5.New()
+	_ = binary.Write(h, binary.BigEndian, size)
+	ranges := []http_range.Range{{Start: 0, Length: size}}
+	if size > 2*partialHashChunk {
+		ranges = []http_range.Range{
+			{Start: 0, Length: partialHashChunk},
+			{Start: size - partialHashChunk, Length: partialHashChunk},
+		}
+	}
+	for _, r := range ranges {
+		reader, err := ss.RangeRead(r)
+		if err != nil {
+			return "", errors.WithMessagef(err, "failed read [%s]", path)
+		}
+		if _, err = utils.CopyWithBuffer(h, io.LimitReader(reader, r.Length)); err != nil {
+			return "", errors.WithMessagef(err, "failed read [%s]", path)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hardLink(ctx context.Context, srcPath, dstPath string) error {
+	if err := checkInternal(ctx, srcPath, dstPath); err != nil {
+		return err
+	}
+	srcStorage, srcActualPath, err := op.GetStorageAndActualPath(srcPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed get src storage")
+	}
+	dstStorage, dstActualPath, err := op.GetStorageAndActualPath(dstPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed get dst storage")
+	}
+	if srcStorage.GetStorage() != dstStorage.GetStorage() {
+		return errors.New("hard links can't cross storages")
+	}
+	return op.HardLink(ctx, srcStorage, srcActualPath, dstActualPath)
+}
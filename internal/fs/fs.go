@@ -3,14 +3,17 @@ package fs
 import (
 	"context"
 	"io"
+	stdpath "path"
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/driver"
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/task"
+	"github.com/OpenListTeam/OpenList/v4/internal/webhook"
 	"github.com/pkg/errors"
 )
 
@@ -22,6 +25,7 @@ type ListArgs struct {
 	Refresh            bool
 	NoLog              bool
 	WithStorageDetails bool
+	SkipHook           bool
 }
 
 func List(ctx context.Context, path string, args *ListArgs) ([]model.Obj, error) {
@@ -65,6 +69,7 @@ func MakeDir(ctx context.Context, path string) error {
 	if err != nil {
 		log.Errorf("failed make dir %s: %+v", path, err)
 	}
+	audit.Log(ctx, model.AuditMakeDir, path, "", err)
 	return err
 }
 
@@ -73,6 +78,13 @@ func Move(ctx context.Context, srcPath, dstDirPath string, skipHook ...bool) (ta
 	if err != nil {
 		log.Errorf("failed move %s to %s: %+v", srcPath, dstDirPath, err)
 	}
+	audit.Log(ctx, model.AuditMove, srcPath, dstDirPath, err)
+	// a move between storages is a task, it tells when it is done
+	if err == nil && req == nil {
+		transferProps(move, srcPath, dstDirPath)
+		removeLocks(srcPath)
+		webhook.Emit(ctx, &webhook.Event{Event: model.EventMove, Path: srcPath, DstPath: dstDirPath})
+	}
 	return req, err
 }
 
@@ -81,6 +93,11 @@ func Copy(ctx context.Context, srcObjPath, dstDirPath string, skipHook ...bool)
 	if err != nil {
 		log.Errorf("failed copy %s to %s: %+v", srcObjPath, dstDirPath, err)
 	}
+	audit.Log(ctx, model.AuditCopy, srcObjPath, dstDirPath, err)
+	if err == nil && res == nil {
+		transferProps(copy, srcObjPath, dstDirPath)
+		webhook.Emit(ctx, &webhook.Event{Event: model.EventCopySucceeded, Path: srcObjPath, DstPath: dstDirPath})
+	}
 	return res, err
 }
 
@@ -89,6 +106,18 @@ func Merge(ctx context.Context, srcObjPath, dstDirPath string, skipHook ...bool)
 	if err != nil {
 		log.Errorf("failed merge %s to %s: %+v", srcObjPath, dstDirPath, err)
 	}
+	audit.Log(ctx, model.AuditMerge, srcObjPath, dstDirPath, err)
+	if err == nil && res == nil {
+		transferProps(merge, srcObjPath, dstDirPath)
+	}
+	return res, err
+}
+
+func PlanTransfer(ctx context.Context, srcDirPath, dstDirPath string, names []string, merge, compareHash bool) (*model.TransferPlan, error) {
+	res, err := planTransfer(ctx, srcDirPath, dstDirPath, names, merge, compareHash)
+	if err != nil {
+		log.Errorf("failed plan transfer %s to %s: %+v", srcDirPath, dstDirPath, err)
+	}
 	return res, err
 }
 
@@ -97,6 +126,13 @@ func Rename(ctx context.Context, srcPath, dstName string, skipHook ...bool) erro
 	if err != nil {
 		log.Errorf("failed rename %s to %s: %+v", srcPath, dstName, err)
 	}
+	dstPath := stdpath.Join(stdpath.Dir(srcPath), dstName)
+	audit.Log(ctx, model.AuditRename, srcPath, dstPath, err)
+	if err == nil {
+		renameProps(srcPath, dstPath)
+		removeLocks(srcPath)
+		webhook.Emit(ctx, &webhook.Event{Event: model.EventRename, Path: srcPath, DstPath: dstPath})
+	}
 	return err
 }
 
@@ -105,22 +141,99 @@ func Remove(ctx context.Context, path string) error {
 	if err != nil {
 		log.Errorf("failed remove %s: %+v", path, err)
 	}
+	audit.Log(ctx, model.AuditRemove, path, "", err)
+	if err == nil {
+		removeProps(path)
+		removeLocks(path)
+		webhook.Emit(ctx, &webhook.Event{Event: model.EventRemove, Path: path})
+	}
+	return err
+}
+
+// PartialHash hashes the head and the tail of the file, see partialHash
+func PartialHash(ctx context.Context, path string) (string, error) {
+	res, err := partialHash(ctx, path)
+	if err != nil {
+		log.Errorf("failed partial hash %s: %+v", path, err)
+	}
+	return res, err
+}
+
+// HardLink replaces the file at dstPath with a hard link to srcPath, both must be on the same storage
+func HardLink(ctx context.Context, srcPath, dstPath string) error {
+	err := hardLink(ctx, srcPath, dstPath)
+	if err != nil {
+		log.Errorf("failed hard link %s to %s: %+v", dstPath, srcPath, err)
+	}
+	audit.Log(ctx, model.AuditHardLink, srcPath, dstPath, err)
+	return err
+}
+
+func RestoreTrash(ctx context.Context, item *model.TrashItem) error {
+	err := restoreTrash(ctx, item)
+	if err != nil {
+		log.Errorf("failed restore trash item %d to %s: %+v", item.ID, item.Path, err)
+	}
+	audit.Log(ctx, model.AuditRestoreTrash, item.Path, "", err)
+	return err
+}
+
+func PurgeTrash(ctx context.Context, item *model.TrashItem) error {
+	err := purgeTrash(ctx, item)
+	if err != nil {
+		log.Errorf("failed purge trash item %d of %s: %+v", item.ID, item.Path, err)
+	}
+	audit.Log(ctx, model.AuditPurgeTrash, item.Path, "", err)
+	return err
+}
+
+func PurgeExpiredTrash(ctx context.Context) error {
+	err := purgeExpiredTrash(ctx)
+	if err != nil {
+		log.Errorf("failed purge expired trash: %+v", err)
+	}
+	return err
+}
+
+func RestoreVersion(ctx context.Context, v *model.FileVersion) error {
+	err := restoreVersion(ctx, v)
+	if err != nil {
+		log.Errorf("failed restore version %d of %s: %+v", v.ID, v.Path, err)
+	}
+	audit.Log(ctx, model.AuditRestoreVersion, v.Path, "", err)
 	return err
 }
 
+// GetVersionPath returns the mount path of the stored content of a version
+func GetVersionPath(v *model.FileVersion) (string, error) {
+	storage, err := getStorageById(v.StorageId)
+	if err != nil {
+		return "", errors.WithMessage(err, "failed get storage")
+	}
+	return stdpath.Join(storage.GetStorage().MountPath, v.VersionPath, stdpath.Base(v.Path)), nil
+}
+
 func PutDirectly(ctx context.Context, dstDirPath string, file model.FileStreamer, skipHook ...bool) error {
+	name, size := file.GetName(), file.GetSize()
 	err := putDirectly(ctx, dstDirPath, file, skipHook...)
 	if err != nil {
 		log.Errorf("failed put %s: %+v", dstDirPath, err)
 	}
+	audit.Record(ctx, &model.AuditLog{Operation: model.AuditUpload, Path: stdpath.Join(dstDirPath, name), Size: size}, err)
+	if err == nil {
+		webhook.Emit(ctx, &webhook.Event{Event: model.EventUpload, Path: stdpath.Join(dstDirPath, name), Size: size})
+	}
 	return err
 }
 
 func PutAsTask(ctx context.Context, dstDirPath string, file model.FileStreamer) (task.TaskExtensionInfo, error) {
+	name, size := file.GetName(), file.GetSize()
 	t, err := putAsTask(ctx, dstDirPath, file)
 	if err != nil {
 		log.Errorf("failed put %s: %+v", dstDirPath, err)
 	}
+	// the outcome is the one of adding the task, the upload itself is in the task list
+	audit.Record(ctx, &model.AuditLog{Operation: model.AuditUpload, Path: stdpath.Join(dstDirPath, name), Size: size, Detail: "as task"}, err)
 	return t, err
 }
 
@@ -145,6 +258,7 @@ func ArchiveDecompress(ctx context.Context, srcObjPath, dstDirPath string, args
 	if err != nil {
 		log.Errorf("failed decompress [%s]%s: %+v", srcObjPath, args.InnerPath, err)
 	}
+	audit.Log(ctx, model.AuditDecompress, srcObjPath, dstDirPath, err)
 	return t, err
 }
 
@@ -184,6 +298,21 @@ func Other(ctx context.Context, args model.FsOtherArgs) (interface{}, error) {
 }
 
 func PutURL(ctx context.Context, path, dstName, urlStr string) error {
+	err := putURL(ctx, path, dstName, urlStr)
+	if err != nil {
+		log.Errorf("failed put url %s to %s: %+v", urlStr, stdpath.Join(path, dstName), err)
+	}
+	audit.Record(ctx, &model.AuditLog{Operation: model.AuditPutURL, Path: stdpath.Join(path, dstName), Detail: urlStr}, err)
+	return err
+}
+
+func putURL(ctx context.Context, path, dstName, urlStr string) error {
+	if err := checkACL(ctx, model.ACLUpload, stdpath.Join(path, dstName)); err != nil {
+		return err
+	}
+	if err := checkLock(ctx, stdpath.Join(path, dstName)); err != nil {
+		return err
+	}
 	storage, dstDirActualPath, err := op.GetStorageAndActualPath(path)
 	if err != nil {
 		return errors.WithMessage(err, "failed get storage")
@@ -12,6 +12,9 @@ import (
 
 func get(ctx context.Context, path string, args *GetArgs) (model.Obj, error) {
 	path = utils.FixAndCleanPath(path)
+	if err := checkACL(ctx, model.ACLList, path); err != nil {
+		return nil, err
+	}
 	// maybe a virtual file
 	if path != "/" {
 		dir, name := stdpath.Split(path)
@@ -16,6 +16,9 @@ import (
 func list(ctx context.Context, path string, args *ListArgs) ([]model.Obj, error) {
 	meta, _ := ctx.Value(conf.MetaKey).(*model.Meta)
 	user, _ := ctx.Value(conf.UserKey).(*model.User)
+	if err := checkACL(ctx, model.ACLList, path); err != nil {
+		return nil, err
+	}
 	virtualFiles := op.GetStorageVirtualFilesWithDetailsByPath(ctx, path, !args.WithStorageDetails, args.Refresh, "")
 	storage, actualPath, err := op.GetStorageAndActualPath(path)
 	if err != nil && len(virtualFiles) == 0 {
@@ -28,6 +31,7 @@ func list(ctx context.Context, path string, args *ListArgs) ([]model.Obj, error)
 			ReqPath:            path,
 			Refresh:            args.Refresh,
 			WithStorageDetails: args.WithStorageDetails,
+			SkipHook:           args.SkipHook,
 		})
 		if err != nil {
 			if !args.NoLog {
@@ -37,6 +41,7 @@ func list(ctx context.Context, path string, args *ListArgs) ([]model.Obj, error)
 				return nil, errors.WithMessage(err, "failed get objs")
 			}
 		}
+		_objs = filterInternalDirs(actualPath, _objs)
 	}
 
 	om := model.NewObjMerge()
@@ -62,7 +67,7 @@ func filterReadableObjs(objs []model.Obj, user *model.User, reqPath string, pare
 		} else {
 			meta = parentMeta
 		}
-		if common.CanRead(user, meta, objPath) {
+		if common.CanRead(user, meta, objPath) && op.ACLAllows(user, model.ACLList, objPath) {
 			result = append(result, obj)
 		}
 	}
@@ -0,0 +1,40 @@
+package fs
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// checkLock fails with errs.Locked if a WebDAV lock applies to one of paths or to a path below
+// it, but the locks whose tokens the request has claimed
+func checkLock(ctx context.Context, paths ...string) error {
+	tokens, _ := ctx.Value(conf.LockTokensKey).([]string)
+	now := time.Now()
+	for _, p := range paths {
+		locks, err := op.GetWebDAVLocks(now, p)
+		if err != nil {
+			return err
+		}
+		for _, l := range locks {
+			if !slices.Contains(tokens, l.Token) {
+				return errors.WithMessagef(errs.Locked, "[%s] is locked", l.Root)
+			}
+		}
+	}
+	return nil
+}
+
+// removeLocks drops the WebDAV locks of path and below once it is removed or moved away,
+// RFC 4918 ends the locks with their resource and doesn't move them with it
+func removeLocks(path string) {
+	if err := op.DeleteWebDAVLocksUnder(path); err != nil {
+		log.Warnf("failed remove webdav locks of %s: %+v", path, err)
+	}
+}
@@ -2,6 +2,7 @@ package fs
 
 import (
 	"context"
+	stdpath "path"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/driver"
@@ -13,6 +14,9 @@ import (
 )
 
 func makeDir(ctx context.Context, path string) error {
+	if err := checkACL(ctx, model.ACLUpload, path); err != nil {
+		return err
+	}
 	storage, actualPath, err := op.GetStorageAndActualPath(path)
 	if err != nil {
 		return errors.WithMessage(err, "failed get storage")
@@ -21,6 +25,13 @@ func makeDir(ctx context.Context, path string) error {
 }
 
 func rename(ctx context.Context, srcPath, dstName string, skipHook ...bool) error {
+	dstPath := stdpath.Join(stdpath.Dir(srcPath), dstName)
+	if err := checkACL(ctx, model.ACLRename, srcPath, dstPath); err != nil {
+		return err
+	}
+	if err := checkLock(ctx, srcPath, dstPath); err != nil {
+		return err
+	}
 	storage, srcActualPath, err := op.GetStorageAndActualPath(srcPath)
 	if err != nil {
 		return errors.WithMessage(err, "failed get storage")
@@ -32,14 +43,26 @@ func rename(ctx context.Context, srcPath, dstName string, skipHook ...bool) erro
 }
 
 func remove(ctx context.Context, path string) error {
+	if err := checkACL(ctx, model.ACLDelete, path); err != nil {
+		return err
+	}
+	if err := checkLock(ctx, path); err != nil {
+		return err
+	}
 	storage, actualPath, err := op.GetStorageAndActualPath(path)
 	if err != nil {
 		return errors.WithMessage(err, "failed get storage")
 	}
+	if trashed, err := trash(ctx, storage, path, actualPath); trashed {
+		return err
+	}
 	return op.Remove(ctx, storage, actualPath)
 }
 
 func other(ctx context.Context, args model.FsOtherArgs) (interface{}, error) {
+	if err := checkInternal(ctx, args.Path); err != nil {
+		return nil, err
+	}
 	storage, actualPath, err := op.GetStorageAndActualPath(args.Path)
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed get storage")
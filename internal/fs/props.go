@@ -0,0 +1,46 @@
+package fs
+
+import (
+	stdpath "path"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	log "github.com/sirupsen/logrus"
+)
+
+// transferProps makes the WebDAV dead props and the S3 object metas of srcPath follow it once
+// it has been moved or copied into dstDirPath
+func transferProps(t taskType, srcPath, dstDirPath string) {
+	dstPath := stdpath.Join(dstDirPath, stdpath.Base(srcPath))
+	var err, metaErr error
+	if t == move {
+		err = op.MoveWebDAVProps(srcPath, dstPath)
+		metaErr = op.MoveS3ObjectMetas(srcPath, dstPath)
+	} else {
+		err = op.CopyWebDAVProps(srcPath, dstPath)
+		metaErr = op.CopyS3ObjectMetas(srcPath, dstPath)
+	}
+	if err != nil {
+		log.Warnf("failed %s webdav props of %s to %s: %+v", t, srcPath, dstPath, err)
+	}
+	if metaErr != nil {
+		log.Warnf("failed %s s3 object metas of %s to %s: %+v", t, srcPath, dstPath, metaErr)
+	}
+}
+
+func renameProps(srcPath, dstPath string) {
+	if err := op.MoveWebDAVProps(srcPath, dstPath); err != nil {
+		log.Warnf("failed rename webdav props of %s to %s: %+v", srcPath, dstPath, err)
+	}
+	if err := op.MoveS3ObjectMetas(srcPath, dstPath); err != nil {
+		log.Warnf("failed rename s3 object metas of %s to %s: %+v", srcPath, dstPath, err)
+	}
+}
+
+func removeProps(path string) {
+	if err := op.DeleteWebDAVProps(path); err != nil {
+		log.Warnf("failed remove webdav props of %s: %+v", path, err)
+	}
+	if err := op.DeleteS3ObjectMetas(path); err != nil {
+		log.Warnf("failed remove s3 object metas of %s: %+v", path, err)
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/task"
 	"github.com/OpenListTeam/OpenList/v4/internal/task_group"
+	"github.com/OpenListTeam/OpenList/v4/internal/webhook"
 	"github.com/OpenListTeam/tache"
 	"github.com/pkg/errors"
 )
@@ -39,11 +40,13 @@ func (t *UploadTask) Run() error {
 	t.ClearEndTime()
 	t.SetStartTime(time.Now())
 	defer func() { t.SetEndTime(time.Now()) }()
-	return op.Put(context.WithValue(t.Ctx(), conf.SkipHookKey, struct{}{}), t.storage, t.dstDirActualPath, t.file, t.SetProgress)
+	return putWithVersion(context.WithValue(t.Ctx(), conf.SkipHookKey, struct{}{}), t.storage, t.dstDirActualPath, t.file, t.SetProgress)
 }
 
 func (t *UploadTask) OnSucceeded() {
-	task_group.TransferCoordinator.Done(context.WithoutCancel(t.Ctx()), stdpath.Join(t.storage.GetStorage().MountPath, t.dstDirActualPath), true)
+	dstDirPath := stdpath.Join(t.storage.GetStorage().MountPath, t.dstDirActualPath)
+	task_group.TransferCoordinator.Done(context.WithoutCancel(t.Ctx()), dstDirPath, true)
+	webhook.Emit(t.Ctx(), &webhook.Event{Event: model.EventUpload, Path: stdpath.Join(dstDirPath, t.file.GetName()), Size: t.file.GetSize()})
 }
 
 func (t *UploadTask) OnFailed() {
@@ -62,6 +65,14 @@ var UploadTaskManager *tache.Manager[*UploadTask]
 
 // putAsTask add as a put task and return immediately
 func putAsTask(ctx context.Context, dstDirPath string, file model.FileStreamer) (task.TaskExtensionInfo, error) {
+	if err := checkACL(ctx, model.ACLUpload, stdpath.Join(dstDirPath, file.GetName())); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	if err := checkLock(ctx, stdpath.Join(dstDirPath, file.GetName())); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
 	storage, dstDirActualPath, err := op.GetStorageAndActualPath(dstDirPath)
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed get storage")
@@ -69,6 +80,11 @@ func putAsTask(ctx context.Context, dstDirPath string, file model.FileStreamer)
 	if storage.Config().NoUpload {
 		return nil, errors.WithStack(errs.UploadNotSupported)
 	}
+	// checked again when the task puts the file, failing here spares the upload
+	if err := op.CheckQuota(ctx, stdpath.Join(dstDirPath, file.GetName()), max(file.GetSize(), 0), 1); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
 	if file.NeedStore() {
 		_, err := file.CacheFullAndWriter(nil, nil)
 		if err != nil {
@@ -95,6 +111,14 @@ func putAsTask(ctx context.Context, dstDirPath string, file model.FileStreamer)
 
 // putDirect put the file and return after finish
 func putDirectly(ctx context.Context, dstDirPath string, file model.FileStreamer, skipHook ...bool) error {
+	if err := checkACL(ctx, model.ACLUpload, stdpath.Join(dstDirPath, file.GetName())); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := checkLock(ctx, stdpath.Join(dstDirPath, file.GetName())); err != nil {
+		_ = file.Close()
+		return err
+	}
 	storage, dstDirActualPath, err := op.GetStorageAndActualPath(dstDirPath)
 	if err != nil {
 		_ = file.Close()
@@ -107,13 +131,23 @@ func putDirectly(ctx context.Context, dstDirPath string, file model.FileStreamer
 	if utils.IsBool(skipHook...) {
 		ctx = context.WithValue(ctx, conf.SkipHookKey, struct{}{})
 	}
-	return op.Put(ctx, storage, dstDirActualPath, file, nil)
+	return putWithVersion(ctx, storage, dstDirActualPath, file, nil)
 }
 
 func getDirectUploadInfo(ctx context.Context, tool, dstDirPath, dstName string, fileSize int64) (any, error) {
+	if err := checkACL(ctx, model.ACLUpload, stdpath.Join(dstDirPath, dstName)); err != nil {
+		return nil, err
+	}
+	if err := checkLock(ctx, stdpath.Join(dstDirPath, dstName)); err != nil {
+		return nil, err
+	}
 	storage, dstDirActualPath, err := op.GetStorageAndActualPath(dstDirPath)
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed get storage")
 	}
+	// the storage receives the file directly, it is only counted by a rescan
+	if err := op.CheckQuota(ctx, stdpath.Join(dstDirPath, dstName), fileSize, 1); err != nil {
+		return nil, err
+	}
 	return op.GetDirectUploadInfo(ctx, tool, storage, dstDirActualPath, dstName, fileSize)
 }
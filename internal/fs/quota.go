@@ -0,0 +1,92 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/task"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/tache"
+	"github.com/pkg/errors"
+)
+
+// UsageScanTask counts what a user stores under its base path and replaces the tracked usage,
+// it fixes the drift left by removed dirs, direct uploads and changes made outside of OpenList.
+type UsageScanTask struct {
+	task.TaskExtension
+	Status   string `json:"-"`
+	UserId   uint   `json:"user_id"`
+	Username string `json:"username"`
+}
+
+func (t *UsageScanTask) GetName() string {
+	return fmt.Sprintf("rescan storage usage of %s", t.Username)
+}
+
+func (t *UsageScanTask) GetStatus() string {
+	return t.Status
+}
+
+func (t *UsageScanTask) Run() error {
+	t.ClearEndTime()
+	t.SetStartTime(time.Now())
+	defer func() { t.SetEndTime(time.Now()) }()
+	user, err := op.GetUserById(t.UserId)
+	if err != nil {
+		return err
+	}
+	basePath := user.GetBasePath()
+	// nothing is filtered when there is no user, every stored file counts
+	ctx := context.WithValue(t.Ctx(), conf.UserKey, nil)
+	root, err := Get(ctx, basePath, &GetArgs{})
+	if err != nil {
+		return errors.WithMessagef(err, "failed get base path [%s]", basePath)
+	}
+	var bytes, files int64
+	err = WalkFS(ctx, -1, basePath, root, func(reqPath string, info model.Obj) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			bytes += info.GetSize()
+			files++
+			t.Status = fmt.Sprintf("counted %d files", files)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	t.Status = fmt.Sprintf("%d files of %d bytes", files, bytes)
+	return op.SetUsage(user.ID, bytes, files)
+}
+
+var UsageScanTaskManager *tache.Manager[*UsageScanTask]
+
+// RescanUsage adds a task recounting the usage of the user and returns immediately
+func RescanUsage(ctx context.Context, user *model.User) (task.TaskExtensionInfo, error) {
+	if UsageScanTaskManager == nil {
+		return nil, errors.New("usage scan task manager is not initialized")
+	}
+	running := UsageScanTaskManager.GetByCondition(func(t *UsageScanTask) bool {
+		return t.UserId == user.ID &&
+			!utils.SliceContains([]tache.State{tache.StateSucceeded, tache.StateFailed, tache.StateCanceled}, t.GetState())
+	})
+	if len(running) > 0 {
+		return nil, errors.Errorf("usage of user [%s] is already being rescanned", user.Username)
+	}
+	creator, _ := ctx.Value(conf.UserKey).(*model.User)
+	t := &UsageScanTask{
+		TaskExtension: task.TaskExtension{
+			Creator: creator,
+		},
+		UserId:   user.ID,
+		Username: user.Username,
+	}
+	UsageScanTaskManager.Add(t)
+	return t, nil
+}
@@ -0,0 +1,567 @@
+package fs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	stdpath "path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/task"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/tache"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// mtimeTolerance absorbs the precision loss of modified times on some storages
+const mtimeTolerance = 2 * time.Second
+
+type SyncTask struct {
+	task.TaskExtension
+	Status string `json:"-"`
+	JobId  uint   `json:"job_id"`
+	DryRun bool   `json:"dry_run"`
+	job    *model.SyncJob
+}
+
+func (t *SyncTask) GetName() string {
+	name := fmt.Sprintf("sync job %d", t.JobId)
+	if t.job != nil {
+		name = fmt.Sprintf("sync %s %s to %s", t.job.Mode, t.job.SrcPath, t.job.DstPath)
+	}
+	if t.DryRun {
+		name += " (dry run)"
+	}
+	return name
+}
+
+func (t *SyncTask) GetStatus() string {
+	return t.Status
+}
+
+func (t *SyncTask) Run() error {
+	t.ClearEndTime()
+	t.SetStartTime(time.Now())
+	defer func() { t.SetEndTime(time.Now()) }()
+	job, err := op.GetSyncJobById(t.JobId)
+	if err != nil {
+		return err
+	}
+	t.job = job
+	run := &model.SyncRun{
+		JobId:     job.ID,
+		DryRun:    t.DryRun,
+		StartTime: time.Now(),
+		Status:    "running",
+	}
+	if err = op.CreateSyncRun(run); err != nil {
+		return err
+	}
+	ctx := context.WithValue(t.Ctx(), conf.SkipHookKey, struct{}{})
+	report, err := t.sync(ctx, job)
+	end := time.Now()
+	run.EndTime = &end
+	switch {
+	case err != nil:
+		run.Status = "failed"
+		run.Error = err.Error()
+	case report.Failed > 0:
+		run.Status = "partially failed"
+		err = errors.Errorf("%d of %d actions failed", report.Failed, len(report.Actions))
+	default:
+		run.Status = "succeeded"
+	}
+	if report != nil {
+		if b, mErr := json.Marshal(report); mErr == nil {
+			run.Report = string(b)
+		}
+	}
+	if uErr := op.UpdateSyncRun(run); uErr != nil {
+		log.Errorf("failed update sync run %d: %+v", run.ID, uErr)
+	}
+	if !t.DryRun {
+		job.LastRun = &end
+		job.LastStatus = run.Status
+		if uErr := op.UpdateSyncJob(job); uErr != nil {
+			log.Errorf("failed update sync job %d: %+v", job.ID, uErr)
+		}
+	}
+	return err
+}
+
+func (t *SyncTask) sync(ctx context.Context, job *model.SyncJob) (*model.SyncReport, error) {
+	srcStorage, srcActualPath, err := op.GetStorageAndActualPath(job.SrcPath)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get src storage")
+	}
+	dstStorage, dstActualPath, err := op.GetStorageAndActualPath(job.DstPath)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get dst storage")
+	}
+	t.Status = "walking src"
+	srcObjs, err := walkTree(ctx, srcStorage, srcActualPath, true)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed walk src [%s]", job.SrcPath)
+	}
+	t.Status = "walking dst"
+	dstObjs, err := walkTree(ctx, dstStorage, dstActualPath, true)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed walk dst [%s]", job.DstPath)
+	}
+	var base map[string]model.SyncSnapshotEntry
+	if job.Mode == model.SyncModeTwoWay {
+		if base, err = op.GetSyncSnapshot(job.ID); err != nil {
+			return nil, err
+		}
+	}
+	report := planSync(job, srcObjs, dstObjs, base)
+	if t.DryRun {
+		t.Status = "planned"
+		return report, nil
+	}
+	s := &syncer{
+		srcStorage: srcStorage, srcRoot: srcActualPath,
+		dstStorage: dstStorage, dstRoot: dstActualPath,
+	}
+	for i := range report.Actions {
+		if err = ctx.Err(); err != nil {
+			return report, err
+		}
+		a := &report.Actions[i]
+		t.Status = fmt.Sprintf("%s %s", a.Op, a.Path)
+		if err = s.apply(ctx, a); err != nil {
+			a.Error = err.Error()
+			report.Failed++
+			log.Errorf("sync job %d failed %s %s: %+v", job.ID, a.Op, a.Path, err)
+		}
+		t.SetProgress(float64(i+1) / float64(len(report.Actions)) * 100)
+	}
+	if job.Mode == model.SyncModeTwoWay {
+		t.Status = "saving snapshot"
+		if err = saveSyncSnapshot(ctx, job, srcStorage, srcActualPath, dstStorage, dstActualPath); err != nil {
+			return report, errors.WithMessage(err, "failed save snapshot")
+		}
+	}
+	t.Status = "done"
+	return report, nil
+}
+
+// saveSyncSnapshot records the paths both sides have in common after a run, for the next run
+// to tell deletions from new files. A path whose sides differ, like an unsolved conflict or a
+// failed copy, is left out to be planned again.
+func saveSyncSnapshot(ctx context.Context, job *model.SyncJob, srcStorage driver.Driver, srcActualPath string, dstStorage driver.Driver, dstActualPath string) error {
+	srcObjs, err := walkTree(ctx, srcStorage, srcActualPath, true)
+	if err != nil {
+		return err
+	}
+	dstObjs, err := walkTree(ctx, dstStorage, dstActualPath, true)
+	if err != nil {
+		return err
+	}
+	var entries []model.SyncSnapshotEntry
+	for rel, src := range srcObjs {
+		dst, ok := dstObjs[rel]
+		if !ok || src.IsDir() != dst.IsDir() || (!src.IsDir() && !sameContent(src, dst, job.CompareHash)) {
+			continue
+		}
+		entries = append(entries, model.SyncSnapshotEntry{
+			Path:        rel,
+			IsDir:       src.IsDir(),
+			SrcSize:     src.GetSize(),
+			SrcModified: src.ModTime(),
+			DstSize:     dst.GetSize(),
+			DstModified: dst.ModTime(),
+		})
+	}
+	return op.SaveSyncSnapshot(job.ID, entries)
+}
+
+// walkTree lists all objects under root recursively, keyed by the path relative to root
+func walkTree(ctx context.Context, storage driver.Driver, root string, refresh bool) (map[string]model.Obj, error) {
+	res := make(map[string]model.Obj)
+	var walk func(rel string) error
+	walk = func(rel string) error {
+		dirPath := stdpath.Join(root, rel)
+		objs, err := op.List(ctx, storage, dirPath, model.ListArgs{Refresh: refresh})
+		if err != nil {
+			return err
+		}
+		for _, obj := range objs {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if isInternalPath(stdpath.Join(dirPath, obj.GetName())) {
+				continue
+			}
+			objRel := stdpath.Join(rel, obj.GetName())
+			res[objRel] = obj
+			if obj.IsDir() {
+				if err := walk(objRel); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk("/"); err != nil {
+		if errs.IsObjectNotFound(err) {
+			// the root will be created while syncing
+			return res, nil
+		}
+		return nil, err
+	}
+	return res, nil
+}
+
+// sameContent compares two files by hash if both expose a common one,
+// otherwise by size and modified time
+func sameContent(src, dst model.Obj, compareHash bool) bool {
+	if src.GetSize() != dst.GetSize() {
+		return false
+	}
+	if compareHash {
+		dstHash := dst.GetHash()
+		for ht, h := range src.GetHash().All() {
+			if other := dstHash.GetHash(ht); h != "" && other != "" {
+				return strings.EqualFold(h, other)
+			}
+		}
+	}
+	diff := src.ModTime().Sub(dst.ModTime())
+	return diff <= mtimeTolerance && diff >= -mtimeTolerance
+}
+
+// planSync plans the actions making dst up to date with src. A two way job plans them against
+// base, the snapshot of the last run, see planTwoWay.
+func planSync(job *model.SyncJob, srcObjs, dstObjs map[string]model.Obj, base map[string]model.SyncSnapshotEntry) *model.SyncReport {
+	if job.Mode == model.SyncModeTwoWay {
+		return planTwoWay(job, srcObjs, dstObjs, base)
+	}
+	report := &model.SyncReport{}
+	add := func(action, path string, obj model.Obj, reason string) {
+		report.Actions = append(report.Actions, model.SyncAction{
+			Op: action, Path: path, IsDir: obj.IsDir(), Size: obj.GetSize(), Reason: reason,
+		})
+	}
+	for rel, src := range srcObjs {
+		dst, ok := dstObjs[rel]
+		if !ok {
+			add(model.SyncOpCopyToDst, rel, src, "new")
+			continue
+		}
+		if src.IsDir() != dst.IsDir() {
+			if job.Mode == model.SyncModeMirror {
+				add(model.SyncOpDeleteDst, rel, dst, "type changed")
+				add(model.SyncOpCopyToDst, rel, src, "type changed")
+			} else {
+				add(model.SyncOpConflict, rel, src, "file and dir with the same name")
+			}
+			continue
+		}
+		if src.IsDir() {
+			continue
+		}
+		if sameContent(src, dst, job.CompareHash) {
+			report.Identical++
+			continue
+		}
+		switch {
+		case job.Mode == model.SyncModeMirror:
+			add(model.SyncOpCopyToDst, rel, src, "changed")
+		case src.ModTime().After(dst.ModTime()):
+			add(model.SyncOpCopyToDst, rel, src, "src is newer")
+		default:
+			add(model.SyncOpConflict, rel, src, "dst is newer")
+		}
+	}
+	if job.Mode == model.SyncModeMirror {
+		for rel, dst := range dstObjs {
+			if _, ok := srcObjs[rel]; ok {
+				continue
+			}
+			// removing the parent dir is enough
+			if parent := stdpath.Dir(rel); parent != "/" {
+				if _, ok := srcObjs[parent]; !ok {
+					if p, ok := dstObjs[parent]; ok && p.IsDir() {
+						continue
+					}
+				}
+			}
+			add(model.SyncOpDeleteDst, rel, dst, "not in src")
+		}
+	}
+	sortSyncActions(report.Actions)
+	return report
+}
+
+// snapshotMatches reports whether obj is still what the snapshot recorded of one side
+func snapshotMatches(obj model.Obj, isDir bool, size int64, modified time.Time) bool {
+	if obj.IsDir() != isDir {
+		return false
+	}
+	if isDir {
+		return true
+	}
+	diff := obj.ModTime().Sub(modified)
+	return obj.GetSize() == size && diff <= mtimeTolerance && diff >= -mtimeTolerance
+}
+
+// planTwoWay plans a two way sync. A path of the snapshot that is gone from one side has been
+// deleted there, and the deletion is propagated unless the other side changed it since. Only
+// a path changed on both sides since the snapshot, or differing on both without a snapshot,
+// is a conflict solved by the conflict policy of the job.
+func planTwoWay(job *model.SyncJob, srcObjs, dstObjs map[string]model.Obj, base map[string]model.SyncSnapshotEntry) *model.SyncReport {
+	report := &model.SyncReport{}
+	add := func(action, path string, obj model.Obj, reason string) {
+		report.Actions = append(report.Actions, model.SyncAction{
+			Op: action, Path: path, IsDir: obj.IsDir(), Size: obj.GetSize(), Reason: reason,
+		})
+	}
+	paths := make(map[string]struct{}, len(srcObjs)+len(dstObjs))
+	for rel := range srcObjs {
+		paths[rel] = struct{}{}
+	}
+	for rel := range dstObjs {
+		paths[rel] = struct{}{}
+	}
+	for rel := range paths {
+		src, inSrc := srcObjs[rel]
+		dst, inDst := dstObjs[rel]
+		b, inBase := base[rel]
+		srcChanged := inSrc && (!inBase || !snapshotMatches(src, b.IsDir, b.SrcSize, b.SrcModified))
+		dstChanged := inDst && (!inBase || !snapshotMatches(dst, b.IsDir, b.DstSize, b.DstModified))
+		switch {
+		case inSrc && !inDst:
+			if inBase && !srcChanged {
+				add(model.SyncOpDeleteSrc, rel, src, "deleted in dst")
+			} else if inBase {
+				add(model.SyncOpCopyToDst, rel, src, "changed in src, deleted in dst")
+			} else {
+				add(model.SyncOpCopyToDst, rel, src, "new")
+			}
+		case inDst && !inSrc:
+			if inBase && !dstChanged {
+				add(model.SyncOpDeleteDst, rel, dst, "deleted in src")
+			} else if inBase {
+				add(model.SyncOpCopyToSrc, rel, dst, "changed in dst, deleted in src")
+			} else {
+				add(model.SyncOpCopyToSrc, rel, dst, "new")
+			}
+		case src.IsDir() != dst.IsDir():
+			add(model.SyncOpConflict, rel, src, "file and dir with the same name")
+		case src.IsDir():
+		case sameContent(src, dst, job.CompareHash):
+			report.Identical++
+		case srcChanged && !dstChanged:
+			add(model.SyncOpCopyToDst, rel, src, "changed in src")
+		case dstChanged && !srcChanged:
+			add(model.SyncOpCopyToSrc, rel, dst, "changed in dst")
+		default:
+			srcNewer := src.ModTime().After(dst.ModTime())
+			switch {
+			case job.ConflictPolicy == model.SyncConflictSrc,
+				job.ConflictPolicy == model.SyncConflictNewer && srcNewer:
+				add(model.SyncOpCopyToDst, rel, src, "changed on both sides, keep src")
+			case job.ConflictPolicy == model.SyncConflictDst,
+				job.ConflictPolicy == model.SyncConflictNewer && !srcNewer:
+				add(model.SyncOpCopyToSrc, rel, dst, "changed on both sides, keep dst")
+			default:
+				add(model.SyncOpConflict, rel, src, "changed on both sides")
+			}
+		}
+	}
+	report.Actions = collapseDeletions(report.Actions)
+	sortSyncActions(report.Actions)
+	return report
+}
+
+// collapseDeletions drops the deletions of the entries of a deleted dir, removing the dir is
+// enough. A dir keeping a changed entry is copied back to the side it was deleted from instead.
+func collapseDeletions(actions []model.SyncAction) []model.SyncAction {
+	deleted := make(map[string]bool)
+	for _, a := range actions {
+		if a.IsDir && isSyncDeletion(a.Op) {
+			deleted[a.Path] = true
+		}
+	}
+	for _, a := range actions {
+		if isSyncDeletion(a.Op) {
+			continue
+		}
+		for p := stdpath.Dir(a.Path); p != "/"; p = stdpath.Dir(p) {
+			delete(deleted, p)
+		}
+	}
+	res := actions[:0]
+	for _, a := range actions {
+		if isSyncDeletion(a.Op) {
+			if deletedParent(deleted, a.Path) {
+				continue
+			}
+			if a.IsDir && !deleted[a.Path] {
+				a.Reason = "kept for its changed entries"
+				if a.Op == model.SyncOpDeleteSrc {
+					a.Op = model.SyncOpCopyToDst
+				} else {
+					a.Op = model.SyncOpCopyToSrc
+				}
+			}
+		}
+		res = append(res, a)
+	}
+	return res
+}
+
+func deletedParent(deleted map[string]bool, path string) bool {
+	for p := stdpath.Dir(path); p != "/"; p = stdpath.Dir(p) {
+		if deleted[p] {
+			return true
+		}
+	}
+	return false
+}
+
+// sortSyncActions puts parents before their children, deletions before copies of the same path
+func sortSyncActions(actions []model.SyncAction) {
+	sort.SliceStable(actions, func(i, j int) bool {
+		a, b := actions[i], actions[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return isSyncDeletion(a.Op) && !isSyncDeletion(b.Op)
+	})
+}
+
+func isSyncDeletion(op string) bool {
+	return op == model.SyncOpDeleteDst || op == model.SyncOpDeleteSrc
+}
+
+type syncer struct {
+	srcStorage, dstStorage driver.Driver
+	srcRoot, dstRoot       string
+}
+
+func (s *syncer) apply(ctx context.Context, a *model.SyncAction) error {
+	switch a.Op {
+	case model.SyncOpCopyToDst:
+		return syncCopy(ctx, s.srcStorage, stdpath.Join(s.srcRoot, a.Path), s.dstStorage, stdpath.Join(s.dstRoot, a.Path), a.IsDir)
+	case model.SyncOpCopyToSrc:
+		return syncCopy(ctx, s.dstStorage, stdpath.Join(s.dstRoot, a.Path), s.srcStorage, stdpath.Join(s.srcRoot, a.Path), a.IsDir)
+	case model.SyncOpDeleteDst:
+		return syncRemove(ctx, s.dstStorage, stdpath.Join(s.dstRoot, a.Path))
+	case model.SyncOpDeleteSrc:
+		return syncRemove(ctx, s.srcStorage, stdpath.Join(s.srcRoot, a.Path))
+	}
+	return nil
+}
+
+func syncRemove(ctx context.Context, storage driver.Driver, actualPath string) error {
+	path := stdpath.Join(storage.GetStorage().MountPath, actualPath)
+	if trashed, err := trash(ctx, storage, path, actualPath); trashed {
+		return err
+	}
+	return op.Remove(ctx, storage, actualPath)
+}
+
+func syncCopy(ctx context.Context, srcStorage driver.Driver, srcActualPath string, dstStorage driver.Driver, dstActualPath string, isDir bool) error {
+	if isDir {
+		return op.MakeDir(ctx, dstStorage, dstActualPath)
+	}
+	ss, err := linkStream(ctx, srcStorage, srcActualPath)
+	if err != nil {
+		return err
+	}
+	return putWithVersion(ctx, dstStorage, stdpath.Dir(dstActualPath), ss, nil)
+}
+
+var SyncTaskManager *tache.Manager[*SyncTask]
+
+// RunSyncJob adds a task running the sync job and returns immediately
+func RunSyncJob(ctx context.Context, job *model.SyncJob, dryRun bool) (task.TaskExtensionInfo, error) {
+	if SyncTaskManager == nil {
+		return nil, errors.New("sync task manager is not initialized")
+	}
+	if !dryRun {
+		running := SyncTaskManager.GetByCondition(func(t *SyncTask) bool {
+			return t.JobId == job.ID && !t.DryRun &&
+				!utils.SliceContains([]tache.State{tache.StateSucceeded, tache.StateFailed, tache.StateCanceled}, t.GetState())
+		})
+		if len(running) > 0 {
+			return nil, errors.Errorf("sync job %d is already running", job.ID)
+		}
+	}
+	creator, _ := ctx.Value(conf.UserKey).(*model.User)
+	t := &SyncTask{
+		TaskExtension: task.TaskExtension{
+			Creator: creator,
+		},
+		JobId:  job.ID,
+		DryRun: dryRun,
+		job:    job,
+	}
+	SyncTaskManager.Add(t)
+	return t, nil
+}
+
+type syncSchedule struct {
+	spec     string
+	schedule *cron.Schedule
+	next     time.Time
+}
+
+var (
+	syncSchedules   = make(map[uint]*syncSchedule)
+	syncSchedulesMu sync.Mutex
+)
+
+// ScheduleSyncJobs starts the enabled sync jobs whose cron schedule is due at now,
+// it should be called about once a minute.
+func ScheduleSyncJobs(now time.Time) {
+	jobs, err := op.GetEnabledSyncJobs()
+	if err != nil {
+		log.Errorf("failed get sync jobs: %+v", err)
+		return
+	}
+	syncSchedulesMu.Lock()
+	defer syncSchedulesMu.Unlock()
+	seen := make(map[uint]struct{}, len(jobs))
+	for i := range jobs {
+		job := &jobs[i]
+		if job.Cron == "" {
+			continue
+		}
+		seen[job.ID] = struct{}{}
+		s, ok := syncSchedules[job.ID]
+		if !ok || s.spec != job.Cron {
+			schedule, err := cron.ParseSchedule(job.Cron)
+			if err != nil {
+				log.Errorf("invalid cron of sync job %d: %+v", job.ID, err)
+				continue
+			}
+			s = &syncSchedule{spec: job.Cron, schedule: schedule, next: schedule.Next(now)}
+			syncSchedules[job.ID] = s
+		}
+		if s.next.IsZero() || now.Before(s.next) {
+			continue
+		}
+		s.next = s.schedule.Next(now)
+		if _, err := RunSyncJob(context.Background(), job, false); err != nil {
+			log.Warnf("failed start scheduled sync job %d: %+v", job.ID, err)
+		}
+	}
+	for id := range syncSchedules {
+		if _, ok := seen[id]; !ok {
+			delete(syncSchedules, id)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package fs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+func TestPlanSync(t *testing.T) {
+	now := time.Now()
+	file := func(name string, size int64, mod time.Time) model.Obj {
+		return &model.Object{Name: name, Size: size, Modified: mod}
+	}
+	dir := func(name string) model.Obj {
+		return &model.Object{Name: name, IsFolder: true, Modified: now}
+	}
+	src := map[string]model.Obj{
+		"/a.txt":   file("a.txt", 1, now),
+		"/b.txt":   file("b.txt", 2, now),
+		"/d":       dir("d"),
+		"/d/c.txt": file("c.txt", 3, now),
+	}
+	dst := map[string]model.Obj{
+		"/a.txt":   file("a.txt", 1, now),
+		"/b.txt":   file("b.txt", 5, now.Add(time.Hour)),
+		"/e":       dir("e"),
+		"/e/f.txt": file("f.txt", 4, now),
+	}
+	tests := []struct {
+		job  model.SyncJob
+		want []model.SyncAction
+	}{
+		{
+			job: model.SyncJob{Mode: model.SyncModeUpdate},
+			want: []model.SyncAction{
+				{Op: model.SyncOpConflict, Path: "/b.txt"},
+				{Op: model.SyncOpCopyToDst, Path: "/d"},
+				{Op: model.SyncOpCopyToDst, Path: "/d/c.txt"},
+			},
+		},
+		{
+			job: model.SyncJob{Mode: model.SyncModeMirror},
+			want: []model.SyncAction{
+				{Op: model.SyncOpCopyToDst, Path: "/b.txt"},
+				{Op: model.SyncOpCopyToDst, Path: "/d"},
+				{Op: model.SyncOpCopyToDst, Path: "/d/c.txt"},
+				{Op: model.SyncOpDeleteDst, Path: "/e"},
+			},
+		},
+		{
+			job: model.SyncJob{Mode: model.SyncModeTwoWay, ConflictPolicy: model.SyncConflictNewer},
+			want: []model.SyncAction{
+				{Op: model.SyncOpCopyToSrc, Path: "/b.txt"},
+				{Op: model.SyncOpCopyToDst, Path: "/d"},
+				{Op: model.SyncOpCopyToDst, Path: "/d/c.txt"},
+				{Op: model.SyncOpCopyToSrc, Path: "/e"},
+				{Op: model.SyncOpCopyToSrc, Path: "/e/f.txt"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		report := planSync(&tt.job, src, dst, nil)
+		if report.Identical != 1 {
+			t.Errorf("%s: expected 1 identical file, got %d", tt.job.Mode, report.Identical)
+		}
+		if len(report.Actions) != len(tt.want) {
+			t.Fatalf("%s: expected %d actions, got %+v", tt.job.Mode, len(tt.want), report.Actions)
+		}
+		for i, a := range report.Actions {
+			if a.Op != tt.want[i].Op || a.Path != tt.want[i].Path {
+				t.Errorf("%s: action %d expected %s %s, got %s %s", tt.job.Mode, i, tt.want[i].Op, tt.want[i].Path, a.Op, a.Path)
+			}
+		}
+	}
+}
+
+func TestPlanTwoWayWithSnapshot(t *testing.T) {
+	now := time.Now()
+	file := func(name string, size int64, mod time.Time) model.Obj {
+		return &model.Object{Name: name, Size: size, Modified: mod}
+	}
+	dir := func(name string) model.Obj {
+		return &model.Object{Name: name, IsFolder: true, Modified: now}
+	}
+	synced := func(size int64) model.SyncSnapshotEntry {
+		return model.SyncSnapshotEntry{SrcSize: size, SrcModified: now, DstSize: size, DstModified: now}
+	}
+	base := map[string]model.SyncSnapshotEntry{
+		"/deleted-in-dst.txt": synced(1),
+		"/deleted-in-src.txt": synced(1),
+		"/edited-in-src.txt":  synced(1),
+		"/edited-in-dst.txt":  synced(1),
+		"/edited-both.txt":    synced(1),
+		"/edited-deleted.txt": synced(1),
+		"/d":                  {IsDir: true},
+		"/d/a.txt":            synced(1),
+		"/k":                  {IsDir: true},
+		"/k/a.txt":            synced(1),
+		"/k/b.txt":            synced(1),
+	}
+	src := map[string]model.Obj{
+		"/deleted-in-dst.txt": file("deleted-in-dst.txt", 1, now),
+		"/edited-in-src.txt":  file("edited-in-src.txt", 2, now.Add(time.Hour)),
+		"/edited-in-dst.txt":  file("edited-in-dst.txt", 1, now),
+		"/edited-both.txt":    file("edited-both.txt", 2, now.Add(time.Hour)),
+		"/edited-deleted.txt": file("edited-deleted.txt", 2, now.Add(time.Hour)),
+		"/d":                  dir("d"),
+		"/d/a.txt":            file("a.txt", 1, now),
+		"/k":                  dir("k"),
+		"/k/a.txt":            file("a.txt", 1, now),
+		"/k/b.txt":            file("b.txt", 2, now.Add(time.Hour)),
+	}
+	dst := map[string]model.Obj{
+		"/deleted-in-src.txt": file("deleted-in-src.txt", 1, now),
+		"/edited-in-src.txt":  file("edited-in-src.txt", 1, now),
+		"/edited-in-dst.txt":  file("edited-in-dst.txt", 2, now.Add(time.Hour)),
+		"/edited-both.txt":    file("edited-both.txt", 3, now.Add(time.Minute)),
+	}
+	want := []model.SyncAction{
+		{Op: model.SyncOpDeleteSrc, Path: "/d"},
+		{Op: model.SyncOpDeleteSrc, Path: "/deleted-in-dst.txt"},
+		{Op: model.SyncOpDeleteDst, Path: "/deleted-in-src.txt"},
+		{Op: model.SyncOpConflict, Path: "/edited-both.txt"},
+		{Op: model.SyncOpCopyToDst, Path: "/edited-deleted.txt"},
+		{Op: model.SyncOpCopyToSrc, Path: "/edited-in-dst.txt"},
+		{Op: model.SyncOpCopyToDst, Path: "/edited-in-src.txt"},
+		{Op: model.SyncOpCopyToDst, Path: "/k"},
+		{Op: model.SyncOpDeleteSrc, Path: "/k/a.txt"},
+		{Op: model.SyncOpCopyToDst, Path: "/k/b.txt"},
+	}
+	job := &model.SyncJob{Mode: model.SyncModeTwoWay, ConflictPolicy: model.SyncConflictSkip}
+	report := planSync(job, src, dst, base)
+	if len(report.Actions) != len(want) {
+		t.Fatalf("expected %d actions, got %+v", len(want), report.Actions)
+	}
+	for i, a := range report.Actions {
+		if a.Op != want[i].Op || a.Path != want[i].Path {
+			t.Errorf("action %d expected %s %s, got %s %s", i, want[i].Op, want[i].Path, a.Op, a.Path)
+		}
+	}
+}
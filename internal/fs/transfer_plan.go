@@ -0,0 +1,162 @@
+package fs
+
+import (
+	"context"
+	stdpath "path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/pkg/errors"
+)
+
+// planTransfer walks the named objects of srcDirPath and their counterparts in dstDirPath
+// and reports what a copy (or a merge if merge is true) would do, without changing anything.
+func planTransfer(ctx context.Context, srcDirPath, dstDirPath string, names []string, merge, compareHash bool) (*model.TransferPlan, error) {
+	srcStorage, srcDirActualPath, err := op.GetStorageAndActualPath(srcDirPath)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get src storage")
+	}
+	dstStorage, dstDirActualPath, err := op.GetStorageAndActualPath(dstDirPath)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get dst storage")
+	}
+	plan := &model.TransferPlan{}
+	for _, name := range names {
+		srcObjs, err := walkTransferObj(ctx, srcStorage, srcDirActualPath, name)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed walk src [%s]", stdpath.Join(srcDirPath, name))
+		}
+		if len(srcObjs) == 0 {
+			return nil, errors.WithMessagef(errs.ObjectNotFound, "src [%s]", stdpath.Join(srcDirPath, name))
+		}
+		dstObjs, err := walkTransferObj(ctx, dstStorage, dstDirActualPath, name)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed walk dst [%s]", stdpath.Join(dstDirPath, name))
+		}
+		diffTransfer(plan, srcObjs, dstObjs, merge, compareHash)
+	}
+	sort.Slice(plan.Entries, func(i, j int) bool {
+		return plan.Entries[i].Path < plan.Entries[j].Path
+	})
+	return plan, nil
+}
+
+// walkTransferObj returns the object named name in dirActualPath and everything under it,
+// keyed by the path relative to dirActualPath. It is empty if the object does not exist.
+func walkTransferObj(ctx context.Context, storage driver.Driver, dirActualPath, name string) (map[string]model.Obj, error) {
+	rel := stdpath.Join("/", name)
+	actualPath := stdpath.Join(dirActualPath, name)
+	res := make(map[string]model.Obj)
+	obj, err := op.Get(ctx, storage, actualPath)
+	if err != nil {
+		if errs.IsObjectNotFound(err) {
+			return res, nil
+		}
+		return nil, err
+	}
+	res[rel] = obj
+	if !obj.IsDir() {
+		return res, nil
+	}
+	// list without refreshing, the same as FileTransferTask does
+	objs, err := walkTree(ctx, storage, actualPath, false)
+	if err != nil {
+		return nil, err
+	}
+	for p, o := range objs {
+		res[stdpath.Join(rel, p)] = o
+	}
+	return res, nil
+}
+
+func diffTransfer(plan *model.TransferPlan, srcObjs, dstObjs map[string]model.Obj, merge, compareHash bool) {
+	add := func(rel string, src, dst model.Obj, status, action string) {
+		e := model.TransferPlanEntry{Path: rel, Status: status, Action: action}
+		if src != nil {
+			e.IsDir = src.IsDir()
+			e.SrcSize = src.GetSize()
+			e.SrcModified = modifiedOf(src)
+		}
+		if dst != nil {
+			e.IsDir = e.IsDir || src == nil && dst.IsDir()
+			e.DstSize = dst.GetSize()
+			e.DstModified = modifiedOf(dst)
+		}
+		if action == model.TransferActionCopy && !e.IsDir {
+			plan.CopyBytes += e.SrcSize
+		}
+		switch status {
+		case model.TransferEntryNew:
+			plan.New++
+		case model.TransferEntryIdentical:
+			plan.Identical++
+		case model.TransferEntryConflict:
+			plan.Conflict++
+		case model.TransferEntryDstOnly:
+			plan.DstOnly++
+		}
+		plan.Entries = append(plan.Entries, e)
+	}
+	// skipped is the named object a merge skips with everything under it
+	skipped := ""
+	for rel := range srcObjs {
+		if dst, ok := dstObjs[rel]; ok && merge && !dst.IsDir() && stdpath.Dir(rel) == "/" {
+			skipped = rel
+		}
+	}
+	for rel, src := range srcObjs {
+		dst, ok := dstObjs[rel]
+		switch {
+		case !ok && skipped != "" && strings.HasPrefix(rel, skipped+"/"):
+			add(rel, src, nil, model.TransferEntryNew, model.TransferActionSkip)
+		case !ok:
+			add(rel, src, nil, model.TransferEntryNew, model.TransferActionCopy)
+		case src.IsDir() && dst.IsDir():
+			// the dir is merged into, only its children matter
+			add(rel, src, dst, model.TransferEntryIdentical, model.TransferActionNone)
+		case src.IsDir() != dst.IsDir():
+			// a merge skips the named objects whose dst is a file, as the handler does, but the
+			// task only skips the entries that are files on both sides
+			action := model.TransferActionCopy
+			if rel == skipped {
+				action = model.TransferActionSkip
+			}
+			add(rel, src, dst, model.TransferEntryConflict, action)
+		default:
+			status := model.TransferEntryConflict
+			if sameContent(src, dst, compareHash) {
+				status = model.TransferEntryIdentical
+			}
+			action := model.TransferActionCopy
+			if merge {
+				action = model.TransferActionSkip
+			}
+			add(rel, src, dst, status, action)
+		}
+	}
+	for rel, dst := range dstObjs {
+		if _, ok := srcObjs[rel]; ok {
+			continue
+		}
+		// only report the topmost dst only dir
+		if _, ok := dstObjs[stdpath.Dir(rel)]; ok {
+			if _, ok := srcObjs[stdpath.Dir(rel)]; !ok {
+				continue
+			}
+		}
+		add(rel, nil, dst, model.TransferEntryDstOnly, model.TransferActionNone)
+	}
+}
+
+func modifiedOf(obj model.Obj) *time.Time {
+	t := obj.ModTime()
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
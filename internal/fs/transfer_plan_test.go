@@ -0,0 +1,72 @@
+package fs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+func TestDiffTransfer(t *testing.T) {
+	now := time.Now()
+	src := map[string]model.Obj{
+		"/d":       &model.Object{Name: "d", IsFolder: true},
+		"/d/a.txt": &model.Object{Name: "a.txt", Size: 1, Modified: now},
+		"/d/b.txt": &model.Object{Name: "b.txt", Size: 2, Modified: now},
+		"/d/c.txt": &model.Object{Name: "c.txt", Size: 3, Modified: now},
+	}
+	dst := map[string]model.Obj{
+		"/d":         &model.Object{Name: "d", IsFolder: true},
+		"/d/a.txt":   &model.Object{Name: "a.txt", Size: 1, Modified: now},
+		"/d/b.txt":   &model.Object{Name: "b.txt", Size: 5, Modified: now},
+		"/d/e":       &model.Object{Name: "e", IsFolder: true},
+		"/d/e/f.txt": &model.Object{Name: "f.txt", Size: 4, Modified: now},
+	}
+	for _, merge := range []bool{false, true} {
+		plan := &model.TransferPlan{}
+		diffTransfer(plan, src, dst, merge, false)
+		if plan.New != 1 || plan.Identical != 2 || plan.Conflict != 1 || plan.DstOnly != 1 {
+			t.Fatalf("merge %v: unexpected counts %+v", merge, plan)
+		}
+		wantBytes := int64(1 + 2 + 3)
+		if merge {
+			wantBytes = 3
+		}
+		if plan.CopyBytes != wantBytes {
+			t.Errorf("merge %v: expected %d bytes to copy, got %d", merge, wantBytes, plan.CopyBytes)
+		}
+	}
+}
+
+func TestDiffTransferMergeTypeMismatch(t *testing.T) {
+	now := time.Now()
+	src := map[string]model.Obj{
+		"/d":     &model.Object{Name: "d", IsFolder: true},
+		"/d/x":   &model.Object{Name: "x", IsFolder: true},
+		"/d/x/a": &model.Object{Name: "a", Size: 1, Modified: now},
+		"/f":     &model.Object{Name: "f", IsFolder: true},
+		"/f/b":   &model.Object{Name: "b", Size: 4, Modified: now},
+	}
+	dst := map[string]model.Obj{
+		"/d":   &model.Object{Name: "d", IsFolder: true},
+		"/d/x": &model.Object{Name: "x", Size: 2, Modified: now},
+		"/f":   &model.Object{Name: "f", Size: 3, Modified: now},
+	}
+	plan := &model.TransferPlan{}
+	diffTransfer(plan, src, dst, true, false)
+	want := map[string]string{
+		"/d":     model.TransferActionNone,
+		"/d/x":   model.TransferActionCopy,
+		"/d/x/a": model.TransferActionCopy,
+		"/f":     model.TransferActionSkip,
+		"/f/b":   model.TransferActionSkip,
+	}
+	for _, e := range plan.Entries {
+		if e.Action != want[e.Path] {
+			t.Errorf("%s: expected action %s, got %s", e.Path, want[e.Path], e.Action)
+		}
+	}
+	if len(plan.Entries) != len(want) {
+		t.Errorf("expected %d entries, got %+v", len(want), plan.Entries)
+	}
+}
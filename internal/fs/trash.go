@@ -0,0 +1,217 @@
+package fs
+
+import (
+	"context"
+	stdpath "path"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Trashed objects are kept inside the storage they were removed from, under
+// /<trash_dir>/<holder>/<name>, so that moving them in and out of the trash
+// is a cheap same-storage move for every driver that supports it.
+// File versions are kept the same way under /<version_dir>.
+
+func trashEnabled() bool {
+	return setting.GetBool(conf.TrashEnabled)
+}
+
+func trashDirName() string {
+	return strings.Trim(setting.GetStr(conf.TrashDir, ".openlist_trash"), "/")
+}
+
+// isInternalPath reports whether actualPath is the trash or versions dir of a storage or inside them
+func isInternalPath(actualPath string) bool {
+	for _, name := range []string{trashDirName(), versionDirName()} {
+		if name != "" && utils.IsSubPath("/"+name, actualPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkInternal keeps clients out of the trash and versions dirs, they are only reached through
+// the trash and version apis. A ctx without user is the system itself unless it is marked anonymous.
+func checkInternal(ctx context.Context, paths ...string) error {
+	_, ok := ctx.Value(conf.UserKey).(*model.User)
+	anonymous, _ := ctx.Value(conf.AnonymousKey).(bool)
+	if !ok && !anonymous {
+		return nil
+	}
+	for _, p := range paths {
+		if _, actualPath, err := op.GetStorageAndActualPath(p); err == nil && isInternalPath(actualPath) {
+			return errors.WithStack(errs.ObjectNotFound)
+		}
+	}
+	return nil
+}
+
+// canHide reports whether the storage is able to move objects into its internal dirs
+func canHide(storage driver.Driver) bool {
+	switch storage.(type) {
+	case driver.Mkdir, driver.MkdirResult:
+	default:
+		return false
+	}
+	switch storage.(type) {
+	case driver.Move, driver.MoveResult:
+		return true
+	}
+	return false
+}
+
+// filterInternalDirs hides the trash and versions dirs from the root listing of a storage
+func filterInternalDirs(actualPath string, objs []model.Obj) []model.Obj {
+	if !utils.PathEqual(actualPath, "/") {
+		return objs
+	}
+	var hidden []string
+	if name := trashDirName(); name != "" && trashEnabled() {
+		hidden = append(hidden, name)
+	}
+	if name := versionDirName(); name != "" {
+		hidden = append(hidden, name)
+	}
+	if len(hidden) == 0 {
+		return objs
+	}
+	res := make([]model.Obj, 0, len(objs))
+	for _, obj := range objs {
+		if obj.IsDir() && utils.SliceContains(hidden, obj.GetName()) {
+			continue
+		}
+		res = append(res, obj)
+	}
+	return res
+}
+
+// trash moves the object into the trash of its storage, it returns false
+// if the object should be removed permanently instead.
+func trash(ctx context.Context, storage driver.Driver, path, actualPath string) (bool, error) {
+	if !trashEnabled() || trashDirName() == "" || isInternalPath(actualPath) || !canHide(storage) {
+		return false, nil
+	}
+	obj, err := op.Get(ctx, storage, actualPath, true)
+	if err != nil {
+		if errs.IsObjectNotFound(err) {
+			return true, nil
+		}
+		return true, errors.WithMessage(err, "failed to get object")
+	}
+	if model.ObjHasMask(obj, model.NoRemove) {
+		return true, errors.WithStack(errs.PermissionDenied)
+	}
+	holder := holderPath(trashDirName())
+	if err = op.MakeDir(ctx, storage, holder); err != nil {
+		return true, errors.WithMessage(err, "failed to make trash dir")
+	}
+	if err = op.Move(ctx, storage, actualPath, holder); err != nil {
+		if rmErr := op.Remove(ctx, storage, holder); rmErr != nil {
+			log.Warnf("failed to clean trash dir %s: %+v", holder, rmErr)
+		}
+		return true, errors.WithMessage(err, "failed to move object to trash")
+	}
+	item := &model.TrashItem{
+		StorageId:  storage.GetStorage().ID,
+		Path:       path,
+		TrashPath:  holder,
+		Name:       obj.GetName(),
+		IsDir:      obj.IsDir(),
+		Size:       obj.GetSize(),
+		DeleteTime: time.Now(),
+	}
+	if user, ok := ctx.Value(conf.UserKey).(*model.User); ok {
+		item.DeleterId = user.ID
+		item.Deleter = user.Username
+	}
+	if err = op.CreateTrashItem(item); err != nil {
+		return true, errors.WithMessagef(err, "object was moved to [%s]%s but failed to record it", storage.GetStorage().MountPath, holder)
+	}
+	return true, nil
+}
+
+// holderPath returns a new unique dir under the internal dir to hold a single object
+func holderPath(dirName string) string {
+	return stdpath.Join("/", dirName, time.Now().Format("20060102150405")+"_"+random.String(8))
+}
+
+func getStorageById(id uint) (driver.Driver, error) {
+	s, err := db.GetStorageById(id)
+	if err != nil {
+		return nil, err
+	}
+	return op.GetStorageByMountPath(s.MountPath)
+}
+
+func restoreTrash(ctx context.Context, item *model.TrashItem) error {
+	storage, err := getStorageById(item.StorageId)
+	if err != nil {
+		return errors.WithMessage(err, "failed get storage")
+	}
+	if !utils.IsSubPath(storage.GetStorage().MountPath, item.Path) {
+		return errors.Errorf("storage of [%s] has been remounted to [%s]", item.Path, storage.GetStorage().MountPath)
+	}
+	dstActualPath := utils.FixAndCleanPath(strings.TrimPrefix(item.Path, storage.GetStorage().MountPath))
+	if _, err = op.Get(ctx, storage, dstActualPath); err == nil {
+		return errors.WithStack(errs.ObjectAlreadyExists)
+	} else if !errs.IsObjectNotFound(err) {
+		return errors.WithMessage(err, "failed to check restore path")
+	}
+	dstDirActualPath := stdpath.Dir(dstActualPath)
+	if err = op.MakeDir(ctx, storage, dstDirActualPath); err != nil {
+		return errors.WithMessage(err, "failed to make restore dir")
+	}
+	if err = op.Move(ctx, storage, stdpath.Join(item.TrashPath, item.Name), dstDirActualPath); err != nil {
+		return errors.WithMessage(err, "failed to move object out of trash")
+	}
+	if err = op.Remove(ctx, storage, item.TrashPath); err != nil {
+		log.Warnf("failed to clean trash dir %s: %+v", item.TrashPath, err)
+	}
+	return op.DeleteTrashItemById(item.ID)
+}
+
+func purgeTrash(ctx context.Context, item *model.TrashItem) error {
+	if _, err := db.GetStorageById(item.StorageId); errors.Is(err, gorm.ErrRecordNotFound) {
+		// the storage has been deleted, only the record is left to drop
+		log.Warnf("storage of trash item %d no longer exists, drop the record only", item.ID)
+		return op.DeleteTrashItemById(item.ID)
+	}
+	storage, err := getStorageById(item.StorageId)
+	if err != nil {
+		return errors.WithMessage(err, "failed get storage")
+	}
+	if err = op.Remove(ctx, storage, item.TrashPath); err != nil {
+		return errors.WithMessage(err, "failed to remove trashed object")
+	}
+	return op.DeleteTrashItemById(item.ID)
+}
+
+func purgeExpiredTrash(ctx context.Context) error {
+	days := setting.GetInt(conf.TrashAutoPurgeDays, 30)
+	if days <= 0 {
+		return nil
+	}
+	items, err := op.GetTrashItemsBefore(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		if err = purgeTrash(ctx, &items[i]); err != nil {
+			log.Errorf("failed purge trash item %d [%s]: %+v", items[i].ID, items[i].Path, err)
+		}
+	}
+	return nil
+}
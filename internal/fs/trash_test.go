@@ -0,0 +1,145 @@
+package fs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/OpenListTeam/OpenList/v4/drivers/local"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupLocalStorage mounts a fresh temp dir containing a.txt with the Local driver
+func setupLocalStorage(t *testing.T, mountPath string) (context.Context, string) {
+	dB, err := gorm.Open(sqlite.Open("file:fs_trash?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %+v", err)
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	db.Init(dB)
+	for _, item := range []model.SettingItem{
+		{Key: conf.TrashEnabled, Value: "true", Type: conf.TypeBool},
+		{Key: conf.TrashDir, Value: ".trash", Type: conf.TypeString},
+		{Key: conf.TrashAutoPurgeDays, Value: "30", Type: conf.TypeNumber},
+		{Key: conf.VersionDir, Value: ".versions", Type: conf.TypeString},
+	} {
+		if err := op.SaveSettingItem(&item); err != nil {
+			t.Fatalf("failed to save setting: %+v", err)
+		}
+	}
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	_, err = op.CreateStorage(ctx, model.Storage{
+		Driver:    "Local",
+		MountPath: mountPath,
+		Addition:  `{"root_folder_path":"` + filepath.ToSlash(root) + `"}`,
+	})
+	if err != nil {
+		t.Fatalf("failed to create storage: %+v", err)
+	}
+	t.Cleanup(func() {
+		if s, err := db.GetStorageByMountPath(mountPath); err == nil {
+			_ = op.DeleteStorageById(ctx, s.ID)
+		}
+	})
+	user := &model.User{ID: 1, Username: "admin", Role: model.ADMIN}
+	return context.WithValue(ctx, conf.UserKey, user), root
+}
+
+func TestTrashRemoveRestorePurge(t *testing.T) {
+	ctx, root := setupLocalStorage(t, "/trash_test")
+
+	if err := Remove(ctx, "/trash_test/a.txt"); err != nil {
+		t.Fatalf("failed to remove: %+v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "a.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected a.txt to be moved out, got err: %v", err)
+	}
+	objs, err := List(ctx, "/trash_test", &ListArgs{Refresh: true})
+	if err != nil {
+		t.Fatalf("failed to list: %+v", err)
+	}
+	for _, obj := range objs {
+		if obj.GetName() == ".trash" {
+			t.Errorf("expected trash dir to be hidden from listing")
+		}
+	}
+
+	items, total, err := op.GetTrashItems(1, 10)
+	if err != nil || total != 1 {
+		t.Fatalf("expected 1 trash item, got %d, err: %+v", total, err)
+	}
+	item := items[0]
+	if item.Path != "/trash_test/a.txt" || item.Name != "a.txt" || item.Deleter != "admin" || item.Size != 5 {
+		t.Errorf("unexpected trash item: %+v", item)
+	}
+	if _, err := os.Stat(filepath.Join(root, item.TrashPath, "a.txt")); err != nil {
+		t.Fatalf("expected a.txt in trash: %v", err)
+	}
+
+	if err = RestoreTrash(ctx, &item); err != nil {
+		t.Fatalf("failed to restore: %+v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "a.txt")); err != nil {
+		t.Fatalf("expected a.txt to be restored: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, item.TrashPath)); !os.IsNotExist(err) {
+		t.Errorf("expected trash holder to be cleaned, got err: %v", err)
+	}
+
+	if err = Remove(ctx, "/trash_test/a.txt"); err != nil {
+		t.Fatalf("failed to remove: %+v", err)
+	}
+	items, _, err = op.GetTrashItems(1, 10)
+	if err != nil || len(items) != 1 {
+		t.Fatalf("expected 1 trash item, got %d, err: %+v", len(items), err)
+	}
+	if err = PurgeTrash(ctx, &items[0]); err != nil {
+		t.Fatalf("failed to purge: %+v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, items[0].TrashPath)); !os.IsNotExist(err) {
+		t.Errorf("expected trashed object to be purged, got err: %v", err)
+	}
+	if _, total, _ = op.GetTrashItems(1, 10); total != 0 {
+		t.Errorf("expected empty trash, got %d items", total)
+	}
+}
+
+func TestTrashUnreachableByPath(t *testing.T) {
+	ctx, _ := setupLocalStorage(t, "/trash_hidden")
+	if err := Remove(ctx, "/trash_hidden/a.txt"); err != nil {
+		t.Fatalf("failed to remove: %+v", err)
+	}
+	items, _, err := op.GetTrashItems(1, 10)
+	if err != nil || len(items) != 1 {
+		t.Fatalf("expected 1 trash item, got %d, err: %+v", len(items), err)
+	}
+	t.Cleanup(func() { _ = PurgeTrash(context.Background(), &items[0]) })
+	user := &model.User{ID: 2, Username: "bob", Role: model.GENERAL, BasePath: "/", Permission: 0xffff}
+	userCtx := context.WithValue(context.Background(), conf.UserKey, user)
+	holder := "/trash_hidden" + items[0].TrashPath
+	if _, err := List(userCtx, "/trash_hidden/.trash", &ListArgs{NoLog: true}); err == nil {
+		t.Errorf("expected the trash dir not to be listed")
+	}
+	if _, err := List(userCtx, holder, &ListArgs{NoLog: true}); err == nil {
+		t.Errorf("expected the trash holder not to be listed")
+	}
+	if _, err := Get(userCtx, holder+"/a.txt", &GetArgs{NoLog: true}); err == nil {
+		t.Errorf("expected the trashed file not to be got")
+	}
+	if _, _, err := Link(userCtx, holder+"/a.txt", model.LinkArgs{}); err == nil {
+		t.Errorf("expected the trashed file not to be downloaded")
+	}
+	if _, err := Get(context.Background(), holder+"/a.txt", &GetArgs{NoLog: true}); err != nil {
+		t.Errorf("expected the system to reach the trashed file: %+v", err)
+	}
+}
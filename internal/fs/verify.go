@@ -0,0 +1,75 @@
+package fs
+
+import (
+	"context"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// recomputableHashes can be computed from the content alone, other hash types
+// (e.g. gcid) need extra params and are only compared when both sides expose them
+var recomputableHashes = []*utils.HashType{utils.MD5, utils.SHA1, utils.SHA256}
+
+// verifyTransfer checks the file copied to dstActualPath against srcObj. Hashes exposed by both
+// storages are compared directly, otherwise the missing ones are computed by downloading the files.
+// It returns the checksum that was verified, formatted as <hash type>:<hash>.
+func verifyTransfer(ctx context.Context, srcStorage driver.Driver, srcActualPath string, srcObj model.Obj, dstStorage driver.Driver, dstActualPath string) (string, error) {
+	dstObj, err := op.Get(ctx, dstStorage, dstActualPath)
+	if err != nil {
+		return "", errors.WithMessage(err, "failed get dst file")
+	}
+	if dstObj.IsDir() {
+		return "", errors.WithStack(errs.NotFile)
+	}
+	if srcObj.GetSize() != dstObj.GetSize() {
+		return "", errors.Errorf("size mismatch: src %d, dst %d", srcObj.GetSize(), dstObj.GetSize())
+	}
+	srcHash, dstHash := srcObj.GetHash(), dstObj.GetHash()
+	for ht, h := range srcHash.All() {
+		if other := dstHash.GetHash(ht); h != "" && other != "" {
+			return compareHash(ht, h, other)
+		}
+	}
+	var types []*utils.HashType
+	for _, ht := range recomputableHashes {
+		if srcHash.GetHash(ht) != "" {
+			types = append(types, ht)
+		}
+	}
+	if len(types) == 0 {
+		types = []*utils.HashType{utils.MD5}
+		if srcHash, err = hashFile(ctx, srcStorage, srcActualPath, types); err != nil {
+			return "", errors.WithMessage(err, "failed hash src file")
+		}
+	}
+	if dstHash, err = hashFile(ctx, dstStorage, dstActualPath, types[:1]); err != nil {
+		return "", errors.WithMessage(err, "failed hash dst file")
+	}
+	return compareHash(types[0], srcHash.GetHash(types[0]), dstHash.GetHash(types[0]))
+}
+
+func compareHash(ht *utils.HashType, src, dst string) (string, error) {
+	if !strings.EqualFold(src, dst) {
+		return "", errors.Errorf("%s mismatch: src %s, dst %s", ht.Name, src, dst)
+	}
+	return ht.Name + ":" + strings.ToLower(src), nil
+}
+
+func hashFile(ctx context.Context, storage driver.Driver, actualPath string, types []*utils.HashType) (utils.HashInfo, error) {
+	ss, err := linkStream(ctx, storage, actualPath)
+	if err != nil {
+		return utils.HashInfo{}, err
+	}
+	defer ss.Close()
+	h := utils.NewMultiHasher(types)
+	if _, err = utils.CopyWithBuffer(h, ss); err != nil {
+		return utils.HashInfo{}, err
+	}
+	return *h.GetHashInfo(), nil
+}
@@ -0,0 +1,42 @@
+package fs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+func TestVerifyTransfer(t *testing.T) {
+	ctx, srcRoot := setupLocalStorage(t, "/verify_src")
+	_, dstRoot := setupLocalStorage(t, "/verify_dst")
+	if err := os.Remove(filepath.Join(dstRoot, "a.txt")); err != nil {
+		t.Fatal(err)
+	}
+	ctx = context.WithValue(ctx, conf.NoTaskKey, struct{}{})
+	ctx = context.WithValue(ctx, conf.TransferVerifyKey, struct{}{})
+	if _, err := Copy(ctx, "/verify_src/a.txt", "/verify_dst"); err != nil {
+		t.Fatalf("failed to copy: %+v", err)
+	}
+	if b, err := os.ReadFile(filepath.Join(dstRoot, "a.txt")); err != nil || string(b) != "hello" {
+		t.Fatalf("expected a.txt to be copied, got %q, err: %v", b, err)
+	}
+
+	// same size but different content must be caught by the hash
+	if err := os.WriteFile(filepath.Join(dstRoot, "a.txt"), []byte("world"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	srcStorage, _, _ := op.GetStorageAndActualPath("/verify_src")
+	dstStorage, _, _ := op.GetStorageAndActualPath("/verify_dst")
+	srcObj, err := op.Get(ctx, srcStorage, "/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	op.Cache.DeleteDirectory(dstStorage, "/")
+	if _, err = verifyTransfer(ctx, srcStorage, "/a.txt", srcObj, dstStorage, "/a.txt"); err == nil {
+		t.Errorf("expected a mismatch between %s and %s", srcRoot, dstRoot)
+	}
+}
@@ -0,0 +1,173 @@
+package fs
+
+import (
+	"context"
+	stdpath "path"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+func versionDirName() string {
+	return strings.Trim(setting.GetStr(conf.VersionDir, ".openlist_versions"), "/")
+}
+
+// versionsToKeep returns how many old revisions of the file at path should be kept
+func versionsToKeep(path string) int {
+	dirPath := stdpath.Dir(path)
+	meta, err := op.GetNearestMeta(dirPath)
+	if err != nil || meta == nil || meta.Versions <= 0 {
+		return 0
+	}
+	if !common.MetaCoversPath(meta.Path, dirPath, meta.VSub) {
+		return 0
+	}
+	return meta.Versions
+}
+
+// saveVersion moves the existing file at actualPath into the versions dir,
+// it returns nil if there is nothing worth keeping.
+func saveVersion(ctx context.Context, storage driver.Driver, actualPath string) (*model.FileVersion, error) {
+	obj, err := op.Get(ctx, storage, actualPath, true)
+	if err != nil {
+		if errs.IsObjectNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.WithMessage(err, "failed to get existing file")
+	}
+	if obj.IsDir() || obj.GetSize() == 0 {
+		return nil, nil
+	}
+	holder := holderPath(versionDirName())
+	if err = op.MakeDir(ctx, storage, holder); err != nil {
+		return nil, errors.WithMessage(err, "failed to make version dir")
+	}
+	if err = op.Move(ctx, storage, actualPath, holder); err != nil {
+		if rmErr := op.Remove(ctx, storage, holder); rmErr != nil {
+			log.Warnf("failed to clean version dir %s: %+v", holder, rmErr)
+		}
+		return nil, errors.WithMessage(err, "failed to move existing file to versions")
+	}
+	v := &model.FileVersion{
+		StorageId:   storage.GetStorage().ID,
+		Path:        stdpath.Join(storage.GetStorage().MountPath, actualPath),
+		VersionPath: holder,
+		Size:        obj.GetSize(),
+		Modified:    obj.ModTime(),
+		Created:     time.Now(),
+	}
+	if user, ok := ctx.Value(conf.UserKey).(*model.User); ok {
+		v.CreatorId = user.ID
+		v.Creator = user.Username
+	}
+	if err = op.CreateFileVersion(v); err != nil {
+		return nil, errors.WithMessagef(err, "file was moved to [%s]%s but failed to record it", storage.GetStorage().MountPath, holder)
+	}
+	return v, nil
+}
+
+// dropVersion moves a version back in place of the file it was saved from
+func dropVersion(ctx context.Context, storage driver.Driver, v *model.FileVersion) error {
+	actualPath := utils.FixAndCleanPath(strings.TrimPrefix(v.Path, storage.GetStorage().MountPath))
+	err := op.Move(ctx, storage, stdpath.Join(v.VersionPath, stdpath.Base(actualPath)), stdpath.Dir(actualPath))
+	if err != nil {
+		return err
+	}
+	if err = op.Remove(ctx, storage, v.VersionPath); err != nil {
+		log.Warnf("failed to clean version dir %s: %+v", v.VersionPath, err)
+	}
+	return op.DeleteFileVersionById(v.ID)
+}
+
+func pruneVersions(ctx context.Context, storage driver.Driver, path string, keep int) {
+	versions, err := op.GetFileVersionsByPath(path)
+	if err != nil {
+		log.Errorf("failed get versions of %s: %+v", path, err)
+		return
+	}
+	for i := keep; i < len(versions); i++ {
+		if err = op.Remove(ctx, storage, versions[i].VersionPath); err != nil {
+			log.Errorf("failed remove version %d of %s: %+v", versions[i].ID, path, err)
+			continue
+		}
+		if err = op.DeleteFileVersionById(versions[i].ID); err != nil {
+			log.Errorf("failed delete version %d of %s: %+v", versions[i].ID, path, err)
+		}
+	}
+}
+
+// putWithVersion keeps the file to be overwritten as a version before putting the new one
+func putWithVersion(ctx context.Context, storage driver.Driver, dstDirActualPath string, file model.FileStreamer, up driver.UpdateProgress) error {
+	dstActualPath := stdpath.Join(utils.FixAndCleanPath(dstDirActualPath), file.GetName())
+	path := stdpath.Join(storage.GetStorage().MountPath, dstActualPath)
+	keep := versionsToKeep(path)
+	if keep <= 0 || storage.Config().OnlyIndices || versionDirName() == "" || isInternalPath(dstActualPath) || !canHide(storage) {
+		return op.Put(ctx, storage, dstDirActualPath, file, up)
+	}
+	v, err := saveVersion(ctx, storage, dstActualPath)
+	if err != nil {
+		_ = file.Close()
+		return errors.WithMessage(err, "failed to save version")
+	}
+	err = op.Put(ctx, storage, dstDirActualPath, file, up)
+	if err != nil {
+		if v != nil {
+			if rErr := dropVersion(context.WithoutCancel(ctx), storage, v); rErr != nil {
+				log.Errorf("failed recover old file %s from version: %+v", path, rErr)
+			}
+		}
+		return err
+	}
+	pruneVersions(ctx, storage, path, keep)
+	return nil
+}
+
+func restoreVersion(ctx context.Context, v *model.FileVersion) error {
+	storage, err := getStorageById(v.StorageId)
+	if err != nil {
+		return errors.WithMessage(err, "failed get storage")
+	}
+	if !utils.IsSubPath(storage.GetStorage().MountPath, v.Path) {
+		return errors.Errorf("storage of [%s] has been remounted to [%s]", v.Path, storage.GetStorage().MountPath)
+	}
+	actualPath := utils.FixAndCleanPath(strings.TrimPrefix(v.Path, storage.GetStorage().MountPath))
+	if obj, err := op.Get(ctx, storage, actualPath); err == nil && obj.IsDir() {
+		return errors.WithStack(errs.NotFile)
+	}
+	// keep the current content as a version too, so that restoring can be undone
+	current, err := saveVersion(ctx, storage, actualPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed to save current version")
+	}
+	if current == nil {
+		// the current file is empty, it must be cleared before moving the version back
+		if err = op.Remove(ctx, storage, actualPath); err != nil {
+			return errors.WithMessage(err, "failed to remove current file")
+		}
+	}
+	if err = op.MakeDir(ctx, storage, stdpath.Dir(actualPath)); err != nil {
+		return errors.WithMessage(err, "failed to make dir")
+	}
+	if err = dropVersion(ctx, storage, v); err != nil {
+		if current != nil {
+			if rErr := dropVersion(context.WithoutCancel(ctx), storage, current); rErr != nil {
+				log.Errorf("failed recover current file %s from version: %+v", v.Path, rErr)
+			}
+		}
+		return errors.WithMessage(err, "failed to move version back")
+	}
+	if keep := versionsToKeep(v.Path); keep > 0 {
+		pruneVersions(ctx, storage, v.Path, keep)
+	}
+	return nil
+}
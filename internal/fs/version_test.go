@@ -0,0 +1,119 @@
+package fs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+)
+
+func putString(ctx context.Context, t *testing.T, dstDirPath, name, content string) {
+	file := &stream.FileStream{
+		Obj: &model.Object{
+			Name:     name,
+			Size:     int64(len(content)),
+			Modified: time.Now(),
+		},
+		Reader: strings.NewReader(content),
+	}
+	if err := PutDirectly(ctx, dstDirPath, file); err != nil {
+		t.Fatalf("failed to put %s: %+v", name, err)
+	}
+}
+
+func TestVersionOverwriteAndRestore(t *testing.T) {
+	ctx, root := setupLocalStorage(t, "/version_test")
+	if err := op.CreateMeta(&model.Meta{Path: "/version_test", Versions: 2, VSub: true}); err != nil {
+		t.Fatalf("failed to create meta: %+v", err)
+	}
+	t.Cleanup(func() {
+		if m, err := op.GetMetaByPath("/version_test"); err == nil {
+			_ = op.DeleteMetaById(m.ID)
+		}
+	})
+
+	putString(ctx, t, "/version_test", "a.txt", "second")
+	putString(ctx, t, "/version_test", "a.txt", "third")
+	putString(ctx, t, "/version_test", "a.txt", "fourth")
+
+	versions, err := op.GetFileVersionsByPath("/version_test/a.txt")
+	if err != nil {
+		t.Fatalf("failed to get versions: %+v", err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("expected 2 versions to be kept, got %d", len(versions))
+	}
+	read := func(p string) string {
+		b, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", p, err)
+		}
+		return string(b)
+	}
+	if got := read(filepath.Join(root, versions[0].VersionPath, "a.txt")); got != "third" {
+		t.Errorf("expected newest version to be %q, got %q", "third", got)
+	}
+	if got := read(filepath.Join(root, versions[1].VersionPath, "a.txt")); got != "second" {
+		t.Errorf("expected oldest kept version to be %q, got %q", "second", got)
+	}
+
+	if err = RestoreVersion(ctx, &versions[1]); err != nil {
+		t.Fatalf("failed to restore version: %+v", err)
+	}
+	if got := read(filepath.Join(root, "a.txt")); got != "second" {
+		t.Errorf("expected restored content %q, got %q", "second", got)
+	}
+	versions, err = op.GetFileVersionsByPath("/version_test/a.txt")
+	if err != nil || len(versions) != 2 {
+		t.Fatalf("expected 2 versions after restore, got %d, err: %+v", len(versions), err)
+	}
+	if got := read(filepath.Join(root, versions[0].VersionPath, "a.txt")); got != "fourth" {
+		t.Errorf("expected content before restore to be kept as %q, got %q", "fourth", got)
+	}
+}
+
+func TestVersionsUnreachableByPath(t *testing.T) {
+	ctx, _ := setupLocalStorage(t, "/version_hidden")
+	if err := op.CreateMeta(&model.Meta{Path: "/version_hidden", Versions: 1}); err != nil {
+		t.Fatalf("failed to create meta: %+v", err)
+	}
+	t.Cleanup(func() {
+		if m, err := op.GetMetaByPath("/version_hidden"); err == nil {
+			_ = op.DeleteMetaById(m.ID)
+		}
+	})
+	putString(ctx, t, "/version_hidden", "a.txt", "second")
+	versions, err := op.GetFileVersionsByPath("/version_hidden/a.txt")
+	if err != nil || len(versions) != 1 {
+		t.Fatalf("expected 1 version, got %d, err: %+v", len(versions), err)
+	}
+	versionPath, err := GetVersionPath(&versions[0])
+	if err != nil {
+		t.Fatalf("failed to get version path: %+v", err)
+	}
+	user := &model.User{ID: 2, Username: "bob", Role: model.GENERAL, BasePath: "/", Permission: 0xffff}
+	userCtx := context.WithValue(context.Background(), conf.UserKey, user)
+	if _, err := List(userCtx, "/version_hidden/.versions", &ListArgs{NoLog: true}); err == nil {
+		t.Errorf("expected the versions dir not to be listed")
+	}
+	if _, err := Get(userCtx, versionPath, &GetArgs{NoLog: true}); err == nil {
+		t.Errorf("expected the stored version not to be got")
+	}
+	if _, _, err := Link(userCtx, versionPath, model.LinkArgs{}); err == nil {
+		t.Errorf("expected the stored version not to be downloaded")
+	}
+	anonymousCtx := context.WithValue(context.Background(), conf.AnonymousKey, true)
+	if _, _, err := Link(anonymousCtx, versionPath, model.LinkArgs{}); err == nil {
+		t.Errorf("expected the stored version not to be downloaded anonymously")
+	}
+	if _, err := Get(context.Background(), versionPath, &GetArgs{NoLog: true}); err != nil {
+		t.Errorf("expected the system to reach the stored version: %+v", err)
+	}
+}
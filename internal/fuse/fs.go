@@ -1,170 +1,426 @@
-package fuse
+//go:build fuse
 
-import "github.com/winfsp/cgofuse/fuse"
+package fuse
 
+import (
+	"context"
+	"math"
+	"os"
+	stdpath "path"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/winfsp/cgofuse/fuse"
+)
+
+const invalidHandle = ^uint64(0)
+
+// Fs exposes the OpenList virtual file system, rooted at RootFolder, through FUSE.
+// Reads are served by ranged requests against the storage links, writes are
+// buffered in a local staging file and uploaded when the handle is released.
 type Fs struct {
-	RootFolder string
 	fuse.FileSystemBase
-}
-
-func (fs *Fs) Init() {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Destroy() {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Statfs(path string, stat *fuse.Statfs_t) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Mknod(path string, mode uint32, dev uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Mkdir(path string, mode uint32) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Unlink(path string) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Rmdir(path string) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Link(oldpath string, newpath string) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Symlink(target string, newpath string) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Readlink(path string) (int, string) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Rename(oldpath string, newpath string) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Chmod(path string, mode uint32) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Chown(path string, uid uint32, gid uint32) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Utimens(path string, tmsp []fuse.Timespec) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Access(path string, mask uint32) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Create(path string, flags int, mode uint32) (int, uint64) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Open(path string, flags int) (int, uint64) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Getattr(path string, stat *fuse.Stat_t, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Truncate(path string, size int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Read(path string, buff []byte, ofst int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Write(path string, buff []byte, ofst int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Flush(path string, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Release(path string, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Fsync(path string, datasync bool, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Opendir(path string) (int, uint64) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Readdir(path string, fill func(name string, stat *fuse.Stat_t, ofst int64) bool, ofst int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Releasedir(path string, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Fsyncdir(path string, datasync bool, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Setxattr(path string, name string, value []byte, flags int) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Getxattr(path string, name string) (int, []byte) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Removexattr(path string, name string) int {
-	//TODO implement me
-	panic("implement me")
-}
+	RootFolder string
+	StagingDir string
+
+	ctx     context.Context
+	uid     uint32
+	gid     uint32
+	mu      sync.Mutex
+	nextFh  uint64
+	handles map[uint64]handle
+	// staged files are written locally but not uploaded yet
+	staged map[string]*writeHandle
+}
+
+func NewFs(ctx context.Context, rootFolder, stagingDir string) *Fs {
+	uid, gid := uint32(os.Getuid()), uint32(os.Getgid())
+	return &Fs{
+		RootFolder: rootFolder,
+		StagingDir: stagingDir,
+		ctx:        context.WithValue(ctx, conf.NoTaskKey, struct{}{}),
+		uid:        uid,
+		gid:        gid,
+		handles:    make(map[uint64]handle),
+		staged:     make(map[string]*writeHandle),
+	}
+}
+
+func (f *Fs) fullPath(path string) string {
+	return stdpath.Join(f.RootFolder, path)
+}
+
+func (f *Fs) addHandle(h handle) uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextFh++
+	f.handles[f.nextFh] = h
+	return f.nextFh
+}
+
+func (f *Fs) getHandle(fh uint64) handle {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.handles[fh]
+}
+
+func (f *Fs) popHandle(fh uint64) handle {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	h := f.handles[fh]
+	delete(f.handles, fh)
+	return h
+}
 
-func (fs *Fs) Listxattr(path string, fill func(name string) bool) int {
-	//TODO implement me
-	panic("implement me")
+func (f *Fs) getStaged(path string) *writeHandle {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.staged[path]
+}
+
+func (f *Fs) setStaged(path string, h *writeHandle) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if h == nil {
+		delete(f.staged, path)
+	} else {
+		f.staged[path] = h
+	}
+}
+
+func (f *Fs) Init() {
+	if err := os.MkdirAll(f.StagingDir, 0o777); err != nil {
+		log.Errorf("[fuse] failed to create staging dir [%s]: %+v", f.StagingDir, err)
+	}
+}
+
+func (f *Fs) Destroy() {
+	f.mu.Lock()
+	handles := f.handles
+	f.handles = make(map[uint64]handle)
+	f.mu.Unlock()
+	for _, h := range handles {
+		if err := h.release(f.ctx); err != nil {
+			log.Errorf("[fuse] failed to release handle of [%s]: %+v", h.path(), err)
+		}
+	}
+}
+
+func (f *Fs) Statfs(path string, stat *fuse.Statfs_t) int {
+	// the total capacity of a mount point is not known in general, report a large one
+	// so that clients don't refuse to write
+	stat.Bsize = 4096
+	stat.Frsize = 4096
+	stat.Blocks = math.MaxInt32
+	stat.Bfree = math.MaxInt32
+	stat.Bavail = math.MaxInt32
+	stat.Files = math.MaxInt32
+	stat.Ffree = math.MaxInt32
+	stat.Favail = math.MaxInt32
+	stat.Namemax = 255
+	return 0
+}
+
+func (f *Fs) Mknod(path string, mode uint32, dev uint64) int {
+	if mode&fuse.S_IFMT != fuse.S_IFREG && mode&fuse.S_IFMT != 0 {
+		return -fuse.ENOSYS
+	}
+	errc, fh := f.Create(path, fuse.O_WRONLY, mode)
+	if errc != 0 {
+		return errc
+	}
+	return f.Release(path, fh)
+}
+
+func (f *Fs) Mkdir(path string, mode uint32) int {
+	return errno(fs.MakeDir(f.ctx, f.fullPath(path)))
+}
+
+func (f *Fs) Unlink(path string) int {
+	if h := f.getStaged(path); h != nil {
+		h.discard()
+		f.setStaged(path, nil)
+	}
+	return errno(fs.Remove(f.ctx, f.fullPath(path)))
+}
+
+func (f *Fs) Rmdir(path string) int {
+	objs, err := fs.List(f.ctx, f.fullPath(path), &fs.ListArgs{NoLog: true})
+	if err != nil {
+		return errno(err)
+	}
+	if len(objs) > 0 {
+		return -fuse.ENOTEMPTY
+	}
+	return errno(fs.Remove(f.ctx, f.fullPath(path)))
+}
+
+func (f *Fs) Rename(oldpath string, newpath string) int {
+	if f.getStaged(oldpath) != nil {
+		// the file is still being written, it's not in the storage yet
+		return -fuse.EBUSY
+	}
+	return errno(replace(fsRenamer{ctx: f.ctx}, f.fullPath(oldpath), f.fullPath(newpath)))
+}
+
+func (f *Fs) Utimens(path string, tmsp []fuse.Timespec) int {
+	// modification time is decided by the storage
+	return 0
+}
+
+func (f *Fs) Access(path string, mask uint32) int {
+	return 0
+}
+
+func (f *Fs) Create(path string, flags int, mode uint32) (int, uint64) {
+	if h := f.getStaged(path); h != nil && h.reopen() {
+		if err := h.truncate(0); err != nil {
+			return errno(err), invalidHandle
+		}
+		return 0, f.addHandle(h)
+	}
+	h, err := newWriteHandle(f, path, nil)
+	if err != nil {
+		return errno(err), invalidHandle
+	}
+	h.dirty = true
+	f.setStaged(path, h)
+	return 0, f.addHandle(h)
+}
+
+func (f *Fs) Open(path string, flags int) (int, uint64) {
+	if h := f.getStaged(path); h != nil {
+		if flags&fuse.O_ACCMODE == fuse.O_RDONLY {
+			return 0, f.addHandle(stagedReadHandle{h})
+		}
+		if !h.reopen() {
+			return -fuse.EBUSY, invalidHandle
+		}
+		if flags&fuse.O_TRUNC != 0 {
+			if err := h.truncate(0); err != nil {
+				return errno(err), invalidHandle
+			}
+		}
+		return 0, f.addHandle(h)
+	}
+	obj, err := fs.Get(f.ctx, f.fullPath(path), &fs.GetArgs{NoLog: true})
+	if err != nil {
+		return errno(err), invalidHandle
+	}
+	if obj.IsDir() {
+		return -fuse.EISDIR, invalidHandle
+	}
+	if flags&fuse.O_ACCMODE == fuse.O_RDONLY {
+		return 0, f.addHandle(&readHandle{fs: f, p: path, obj: obj})
+	}
+	// read-modify-write: copy the original content into the staging file
+	// unless it's going to be truncated anyway
+	var origin model.Obj
+	if flags&fuse.O_TRUNC == 0 && obj.GetSize() > 0 {
+		origin = obj
+	}
+	h, err := newWriteHandle(f, path, origin)
+	if err != nil {
+		return errno(err), invalidHandle
+	}
+	if flags&fuse.O_TRUNC != 0 {
+		h.dirty = true
+	}
+	f.setStaged(path, h)
+	return 0, f.addHandle(h)
+}
+
+func (f *Fs) Getattr(path string, stat *fuse.Stat_t, fh uint64) int {
+	if h := f.getStaged(path); h != nil {
+		size, err := h.size()
+		if err != nil {
+			return errno(err)
+		}
+		f.fillStat(stat, false, size, h.modTime)
+		return 0
+	}
+	obj, err := fs.Get(f.ctx, f.fullPath(path), &fs.GetArgs{NoLog: true})
+	if err != nil {
+		return errno(err)
+	}
+	f.fillStat(stat, obj.IsDir(), obj.GetSize(), obj.ModTime())
+	return 0
+}
+
+func (f *Fs) Truncate(path string, size int64, fh uint64) int {
+	if h, ok := f.getHandle(fh).(*writeHandle); ok {
+		return errno(h.truncate(size))
+	}
+	if h := f.getStaged(path); h != nil {
+		return errno(h.truncate(size))
+	}
+	var origin model.Obj
+	if size > 0 {
+		obj, err := fs.Get(f.ctx, f.fullPath(path), &fs.GetArgs{NoLog: true})
+		if err != nil {
+			return errno(err)
+		}
+		origin = obj
+	}
+	h, err := newWriteHandle(f, path, origin)
+	if err != nil {
+		return errno(err)
+	}
+	if err = h.truncate(size); err == nil {
+		err = h.upload(f.ctx)
+	}
+	h.discard()
+	return errno(err)
+}
+
+func (f *Fs) Read(path string, buff []byte, ofst int64, fh uint64) int {
+	h := f.getHandle(fh)
+	if h == nil {
+		return -fuse.EBADF
+	}
+	n, err := h.readAt(f.ctx, buff, ofst)
+	if err != nil && n == 0 {
+		return errno(err)
+	}
+	return n
+}
+
+func (f *Fs) Write(path string, buff []byte, ofst int64, fh uint64) int {
+	h, ok := f.getHandle(fh).(*writeHandle)
+	if !ok {
+		return -fuse.EBADF
+	}
+	n, err := h.writeAt(buff, ofst)
+	if err != nil {
+		return errno(err)
+	}
+	return n
+}
+
+func (f *Fs) Flush(path string, fh uint64) int {
+	// upload on flush so that close(2) reports the error
+	if h, ok := f.getHandle(fh).(*writeHandle); ok {
+		return errno(h.upload(f.ctx))
+	}
+	return 0
+}
+
+func (f *Fs) Release(path string, fh uint64) int {
+	h := f.popHandle(fh)
+	if h == nil {
+		return -fuse.EBADF
+	}
+	return errno(h.release(f.ctx))
+}
+
+func (f *Fs) Fsync(path string, datasync bool, fh uint64) int {
+	if h, ok := f.getHandle(fh).(*writeHandle); ok {
+		return errno(h.upload(f.ctx))
+	}
+	return 0
+}
+
+func (f *Fs) Opendir(path string) (int, uint64) {
+	obj, err := fs.Get(f.ctx, f.fullPath(path), &fs.GetArgs{NoLog: true})
+	if err != nil {
+		return errno(err), invalidHandle
+	}
+	if !obj.IsDir() {
+		return -fuse.ENOTDIR, invalidHandle
+	}
+	return 0, invalidHandle
+}
+
+func (f *Fs) Readdir(path string, fill func(name string, stat *fuse.Stat_t, ofst int64) bool, ofst int64, fh uint64) int {
+	objs, err := fs.List(f.ctx, f.fullPath(path), &fs.ListArgs{NoLog: true})
+	if err != nil {
+		return errno(err)
+	}
+	fill(".", nil, 0)
+	fill("..", nil, 0)
+	listed := make(map[string]struct{}, len(objs))
+	for _, obj := range objs {
+		listed[obj.GetName()] = struct{}{}
+		stat := &fuse.Stat_t{}
+		f.fillStat(stat, obj.IsDir(), obj.GetSize(), obj.ModTime())
+		if !fill(obj.GetName(), stat, 0) {
+			return 0
+		}
+	}
+	// files which are still staged don't exist in the storage yet
+	f.mu.Lock()
+	var staged []*writeHandle
+	for p, h := range f.staged {
+		if _, ok := listed[stdpath.Base(p)]; !ok && stdpath.Dir(p) == stdpath.Clean(path) {
+			staged = append(staged, h)
+		}
+	}
+	f.mu.Unlock()
+	for _, h := range staged {
+		size, err := h.size()
+		if err != nil {
+			continue
+		}
+		stat := &fuse.Stat_t{}
+		f.fillStat(stat, false, size, h.modTime)
+		if !fill(stdpath.Base(h.p), stat, 0) {
+			return 0
+		}
+	}
+	return 0
+}
+
+func (f *Fs) Releasedir(path string, fh uint64) int {
+	return 0
+}
+
+func (f *Fs) fillStat(stat *fuse.Stat_t, isDir bool, size int64, modTime time.Time) {
+	if isDir {
+		stat.Mode = fuse.S_IFDIR | 0o755
+		stat.Nlink = 2
+	} else {
+		stat.Mode = fuse.S_IFREG | 0o644
+		stat.Nlink = 1
+		stat.Size = size
+		stat.Blocks = (size + 511) / 512
+	}
+	stat.Uid = f.uid
+	stat.Gid = f.gid
+	t := fuse.NewTimespec(modTime)
+	stat.Mtim = t
+	stat.Ctim = t
+	stat.Atim = t
+	stat.Birthtim = t
+}
+
+func errno(err error) int {
+	if err == nil {
+		return 0
+	}
+	switch {
+	case errs.IsNotFoundError(err):
+		return -fuse.ENOENT
+	case errors.Is(err, errs.PermissionDenied):
+		return -fuse.EACCES
+	case errs.IsNotImplementError(err), errs.IsNotSupportError(err), errors.Is(err, errs.UploadNotSupported):
+		return -fuse.ENOSYS
+	case errors.Is(err, errs.NotFolder):
+		return -fuse.ENOTDIR
+	case errors.Is(err, errs.NotFile):
+		return -fuse.EISDIR
+	case errors.Is(err, errBusy):
+		return -fuse.EBUSY
+	case errors.Is(err, errs.ObjectAlreadyExists):
+		return -fuse.EEXIST
+	}
+	log.Errorf("[fuse] %+v", err)
+	return -fuse.EIO
 }
 
 var _ fuse.FileSystemInterface = (*Fs)(nil)
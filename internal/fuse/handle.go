@@ -0,0 +1,277 @@
+//go:build fuse
+
+package fuse
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	stdpath "path"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var errBusy = errors.New("resource busy")
+
+type handle interface {
+	path() string
+	readAt(ctx context.Context, p []byte, off int64) (int, error)
+	release(ctx context.Context) error
+}
+
+// readHandle reads a remote file with ranged requests, the link is resolved lazily
+// on the first read so that stat-only opens don't cost a link request
+type readHandle struct {
+	fs  *Fs
+	p   string
+	obj model.Obj
+
+	mu     sync.Mutex
+	reader model.File
+	closer io.Closer
+}
+
+func (h *readHandle) path() string {
+	return h.p
+}
+
+func (h *readHandle) open(ctx context.Context) error {
+	link, obj, err := fs.Link(ctx, h.fs.fullPath(h.p), model.LinkArgs{})
+	if err != nil {
+		return err
+	}
+	ss, err := stream.NewSeekableStream(&stream.FileStream{
+		Obj: obj,
+		Ctx: ctx,
+	}, link)
+	if err != nil {
+		_ = link.Close()
+		return err
+	}
+	reader, err := stream.NewReadAtSeeker(ss, 0)
+	if err != nil {
+		_ = ss.Close()
+		return err
+	}
+	h.reader, h.closer = reader, ss
+	return nil
+}
+
+func (h *readHandle) readAt(ctx context.Context, p []byte, off int64) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if off >= h.obj.GetSize() {
+		return 0, nil
+	}
+	if h.reader == nil {
+		if err := h.open(ctx); err != nil {
+			return 0, err
+		}
+	}
+	n, err := h.reader.ReadAt(p, off)
+	if errors.Is(err, io.EOF) {
+		err = nil
+	}
+	return n, err
+}
+
+func (h *readHandle) release(context.Context) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closer == nil {
+		return nil
+	}
+	err := h.closer.Close()
+	h.reader, h.closer = nil, nil
+	return err
+}
+
+// stagedReadHandle reads a file which is being written by another handle
+type stagedReadHandle struct {
+	*writeHandle
+}
+
+func (h stagedReadHandle) release(context.Context) error {
+	return nil
+}
+
+// writeHandle buffers the whole file in the staging dir,
+// the content is uploaded when the handle is flushed, synced or released
+type writeHandle struct {
+	fs      *Fs
+	p       string
+	modTime time.Time
+
+	mu    sync.Mutex
+	file  *os.File
+	dirty bool
+	// orphan is set when the handle was released but the upload failed,
+	// the staging file is kept so the content can be written again
+	orphan bool
+}
+
+func newWriteHandle(f *Fs, path string, origin model.Obj) (*writeHandle, error) {
+	if f.getStaged(path) != nil {
+		return nil, errors.Wrapf(errBusy, "[%s] is being written", path)
+	}
+	file, err := os.CreateTemp(f.StagingDir, "fuse-*")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	h := &writeHandle{fs: f, p: path, modTime: time.Now(), file: file}
+	if origin == nil {
+		return h, nil
+	}
+	rh := &readHandle{fs: f, p: path, obj: origin}
+	defer rh.release(f.ctx)
+	if err = rh.open(f.ctx); err == nil {
+		_, err = utils.CopyWithBuffer(file, io.NewSectionReader(rh.reader, 0, origin.GetSize()))
+	}
+	if err != nil {
+		h.discard()
+		return nil, errors.WithMessagef(err, "failed to stage [%s]", path)
+	}
+	return h, nil
+}
+
+func (h *writeHandle) path() string {
+	return h.p
+}
+
+func (h *writeHandle) size() (int64, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.file == nil {
+		return 0, os.ErrClosed
+	}
+	info, err := h.file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+func (h *writeHandle) readAt(_ context.Context, p []byte, off int64) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.file == nil {
+		return 0, os.ErrClosed
+	}
+	n, err := h.file.ReadAt(p, off)
+	if errors.Is(err, io.EOF) {
+		err = nil
+	}
+	return n, err
+}
+
+func (h *writeHandle) writeAt(p []byte, off int64) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.file == nil {
+		return 0, os.ErrClosed
+	}
+	h.dirty = true
+	h.modTime = time.Now()
+	return h.file.WriteAt(p, off)
+}
+
+func (h *writeHandle) truncate(size int64) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.file == nil {
+		return os.ErrClosed
+	}
+	h.dirty = true
+	h.modTime = time.Now()
+	return h.file.Truncate(size)
+}
+
+// upload puts the staged content to the storage if it was changed
+func (h *writeHandle) upload(ctx context.Context) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.file == nil || !h.dirty {
+		return nil
+	}
+	info, err := h.file.Stat()
+	if err != nil {
+		return err
+	}
+	// the stream is closed after put, so give it its own file descriptor
+	reader, err := os.Open(h.file.Name())
+	if err != nil {
+		return err
+	}
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(reader, head)
+	if _, err = reader.Seek(0, io.SeekStart); err != nil {
+		_ = reader.Close()
+		return err
+	}
+	dir, name := stdpath.Split(h.fs.fullPath(h.p))
+	s := &stream.FileStream{
+		Obj: &model.Object{
+			Name:     name,
+			Size:     info.Size(),
+			Modified: h.modTime,
+			Ctime:    h.modTime,
+		},
+		Mimetype: http.DetectContentType(head[:n]),
+		Reader:   reader,
+		Closers:  utils.Closers{reader},
+	}
+	if err = fs.PutDirectly(ctx, dir, s); err != nil {
+		return err
+	}
+	h.dirty = false
+	return nil
+}
+
+// discard drops the staged content without uploading it
+func (h *writeHandle) discard() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.file == nil {
+		return
+	}
+	name := h.file.Name()
+	_ = h.file.Close()
+	h.file = nil
+	if err := os.Remove(name); err != nil {
+		log.Warnf("[fuse] failed to remove staging file [%s]: %+v", name, err)
+	}
+}
+
+func (h *writeHandle) release(ctx context.Context) error {
+	if err := h.upload(ctx); err != nil {
+		// keep the staging file, it's the only copy of the content
+		h.mu.Lock()
+		h.orphan = true
+		h.mu.Unlock()
+		return err
+	}
+	h.discard()
+	if h.fs.getStaged(h.p) == h {
+		h.fs.setStaged(h.p, nil)
+	}
+	return nil
+}
+
+// reopen takes over a staged file whose upload failed on release
+func (h *writeHandle) reopen() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.orphan || h.file == nil {
+		return false
+	}
+	h.orphan = false
+	return true
+}
@@ -1,9 +1,33 @@
+//go:build fuse
+
 package fuse
 
-import "github.com/winfsp/cgofuse/fuse"
+import (
+	"context"
+	"errors"
+
+	"github.com/winfsp/cgofuse/fuse"
+)
 
-func Mount(mountSrc, mountDst string, opts []string) {
-	fs := &Fs{RootFolder: mountSrc}
+// Mount serves mountSrc of the OpenList file system at mountDst.
+// It blocks until the file system is unmounted or ctx is done.
+func Mount(ctx context.Context, mountSrc, mountDst, stagingDir string, opts []string) error {
+	fs := NewFs(ctx, mountSrc, stagingDir)
 	host := fuse.NewFileSystemHost(fs)
-	go host.Mount(mountDst, opts)
+	host.SetCapReaddirPlus(true)
+	done := make(chan bool, 1)
+	go func() {
+		done <- host.Mount(mountDst, opts)
+	}()
+	select {
+	case ok := <-done:
+		if !ok {
+			return errors.New("failed to mount, check the mount point and fuse options")
+		}
+		return nil
+	case <-ctx.Done():
+		host.Unmount()
+		<-done
+		return nil
+	}
 }
@@ -0,0 +1,14 @@
+//go:build !fuse
+
+package fuse
+
+import (
+	"context"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+)
+
+// Mount is not available unless built with the fuse tag, which requires cgo and libfuse headers
+func Mount(ctx context.Context, mountSrc, mountDst, stagingDir string, opts []string) error {
+	return errs.NewErr(errs.NotSupport, "this binary is built without fuse support, rebuild it with `-tags fuse`")
+}
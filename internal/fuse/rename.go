@@ -0,0 +1,104 @@
+package fuse
+
+import (
+	"context"
+	stdpath "path"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	log "github.com/sirupsen/logrus"
+)
+
+// renamer does the operations a rename is made of, against the file system in production
+type renamer interface {
+	exists(path string) bool
+	rename(path, name string) error
+	move(path, dstDir string) error
+	remove(path string) error
+}
+
+type fsRenamer struct {
+	ctx context.Context
+}
+
+func (r fsRenamer) exists(path string) bool {
+	_, err := fs.Get(r.ctx, path, &fs.GetArgs{NoLog: true})
+	return err == nil
+}
+
+func (r fsRenamer) rename(path, name string) error {
+	return fs.Rename(r.ctx, path, name)
+}
+
+func (r fsRenamer) move(path, dstDir string) error {
+	_, err := fs.Move(r.ctx, path, dstDir)
+	return err
+}
+
+func (r fsRenamer) remove(path string) error {
+	return fs.Remove(r.ctx, path)
+}
+
+func tempName(name string) string {
+	return "." + name + "." + random.String(8) + ".tmp"
+}
+
+// replace moves src to dst as rename(2) does, replacing dst if it exists. The storages can't
+// do it at once, so src reaches the dir of dst under a temporary name, where it can't clobber
+// a file of the same name, and the existing dst is set aside until src has taken its place.
+// When a step fails the files are put back where they were.
+func replace(r renamer, src, dst string) error {
+	if src == dst {
+		return nil
+	}
+	srcDir, srcName := stdpath.Split(src)
+	dstDir, dstName := stdpath.Split(dst)
+	srcDir, dstDir = stdpath.Clean(srcDir), stdpath.Clean(dstDir)
+	// undo puts src back after it has been moved to dstDir
+	undo := func() {}
+	if srcDir != dstDir {
+		tmp := tempName(srcName)
+		if err := r.rename(src, tmp); err != nil {
+			return err
+		}
+		if err := r.move(stdpath.Join(srcDir, tmp), dstDir); err != nil {
+			restore(r, stdpath.Join(srcDir, tmp), srcName)
+			return err
+		}
+		src = stdpath.Join(dstDir, tmp)
+		undo = func() {
+			if err := r.move(src, srcDir); err != nil {
+				log.Errorf("[fuse] failed move %s back to %s: %+v", src, srcDir, err)
+				return
+			}
+			restore(r, stdpath.Join(srcDir, tmp), srcName)
+		}
+	}
+	var backup string
+	if r.exists(dst) {
+		backup = tempName(dstName)
+		if err := r.rename(dst, backup); err != nil {
+			undo()
+			return err
+		}
+	}
+	if err := r.rename(src, dstName); err != nil {
+		if backup != "" {
+			restore(r, stdpath.Join(dstDir, backup), dstName)
+		}
+		undo()
+		return err
+	}
+	if backup != "" {
+		if err := r.remove(stdpath.Join(dstDir, backup)); err != nil {
+			log.Warnf("[fuse] failed remove replaced %s: %+v", stdpath.Join(dstDir, backup), err)
+		}
+	}
+	return nil
+}
+
+func restore(r renamer, path, name string) {
+	if err := r.rename(path, name); err != nil {
+		log.Errorf("[fuse] failed restore %s to %s: %+v", path, name, err)
+	}
+}
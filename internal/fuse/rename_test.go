@@ -0,0 +1,106 @@
+package fuse
+
+import (
+	"errors"
+	stdpath "path"
+	"strings"
+	"testing"
+)
+
+// memRenamer is a flat file system of paths to contents, it fails the first rename to failName
+type memRenamer struct {
+	files    map[string]string
+	failName string
+}
+
+func (m *memRenamer) exists(path string) bool {
+	_, ok := m.files[path]
+	return ok
+}
+
+func (m *memRenamer) rename(path, name string) error {
+	if name == m.failName {
+		m.failName = ""
+		return errors.New("rename failed")
+	}
+	return m.moveTo(path, stdpath.Join(stdpath.Dir(path), name))
+}
+
+func (m *memRenamer) move(path, dstDir string) error {
+	return m.moveTo(path, stdpath.Join(dstDir, stdpath.Base(path)))
+}
+
+func (m *memRenamer) moveTo(path, dst string) error {
+	content, ok := m.files[path]
+	if !ok {
+		return errors.New("not found")
+	}
+	if _, ok := m.files[dst]; ok {
+		return errors.New("exists")
+	}
+	delete(m.files, path)
+	m.files[dst] = content
+	return nil
+}
+
+func (m *memRenamer) remove(path string) error {
+	delete(m.files, path)
+	return nil
+}
+
+func (m *memRenamer) check(t *testing.T, want map[string]string) {
+	t.Helper()
+	for p := range m.files {
+		if strings.HasSuffix(p, ".tmp") {
+			t.Errorf("temporary file %s is left", p)
+		}
+	}
+	for p, content := range want {
+		if m.files[p] != content {
+			t.Errorf("%s has %q, want %q", p, m.files[p], content)
+		}
+	}
+	if len(m.files) != len(want) {
+		t.Errorf("got files %v, want %v", m.files, want)
+	}
+}
+
+func TestReplace(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		src, dst string
+		failName string
+		want     map[string]string
+		wantErr  bool
+	}{
+		{
+			name:  "same dir over existing",
+			files: map[string]string{"/a/x": "src", "/a/y": "old"},
+			src:   "/a/x", dst: "/a/y",
+			want: map[string]string{"/a/y": "src"},
+		},
+		{
+			name:  "other dir keeps file named like src",
+			files: map[string]string{"/a/x": "src", "/b/x": "other", "/b/y": "old"},
+			src:   "/a/x", dst: "/b/y",
+			want: map[string]string{"/b/x": "other", "/b/y": "src"},
+		},
+		{
+			name:  "failed rename keeps target",
+			files: map[string]string{"/a/x": "src", "/b/y": "old"},
+			src:   "/a/x", dst: "/b/y", failName: "y",
+			want:    map[string]string{"/a/x": "src", "/b/y": "old"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &memRenamer{files: tt.files, failName: tt.failName}
+			if err := replace(m, tt.src, tt.dst); (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			m.check(t, tt.want)
+		})
+	}
+}
@@ -0,0 +1,109 @@
+// Package mail sends plain text emails through the smtp server configured in the settings.
+package mail
+
+import (
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/pkg/errors"
+)
+
+const timeout = 30 * time.Second
+
+// Configured reports whether an smtp server is set
+func Configured() bool {
+	return setting.GetStr(conf.SMTPHost) != ""
+}
+
+// Send sends the mail to a single address
+func Send(to, subject, body string) error {
+	host := setting.GetStr(conf.SMTPHost)
+	if host == "" {
+		return errors.New("smtp is not configured")
+	}
+	if strings.ContainsAny(to, "\r\n") {
+		return errors.Errorf("invalid address: %s", to)
+	}
+	addr := net.JoinHostPort(host, strconv.Itoa(setting.GetInt(conf.SMTPPort, 587)))
+	username := setting.GetStr(conf.SMTPUsername)
+	from := setting.GetStr(conf.SMTPFrom)
+	if from == "" {
+		from = username
+	}
+	c, err := dial(addr, host, setting.GetStr(conf.SMTPEncryption))
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if username != "" {
+		if err := c.Auth(smtp.PlainAuth("", username, setting.GetStr(conf.SMTPPassword), host)); err != nil {
+			return errors.Wrap(err, "failed smtp auth")
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := c.Rcpt(to); err != nil {
+		return errors.WithStack(err)
+	}
+	w, err := c.Data()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if _, err := w.Write(message(from, to, subject, body)); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := w.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+	return c.Quit()
+}
+
+// dial connects with implicit tls for ssl, or upgrades the connection for starttls
+func dial(addr, host, encryption string) (*smtp.Client, error) {
+	tlsConfig := &tls.Config{ServerName: host}
+	var conn net.Conn
+	var err error
+	if encryption == "ssl" {
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: timeout}, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = net.DialTimeout("tcp", addr, timeout)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed connect smtp server")
+	}
+	_ = conn.SetDeadline(time.Now().Add(2 * timeout))
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		_ = conn.Close()
+		return nil, errors.WithStack(err)
+	}
+	if encryption == "starttls" {
+		if err := c.StartTLS(tlsConfig); err != nil {
+			_ = c.Close()
+			return nil, errors.Wrap(err, "failed starttls")
+		}
+	}
+	return c, nil
+}
+
+func message(from, to, subject, body string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+	return []byte(b.String())
+}
@@ -0,0 +1,156 @@
+package model
+
+import (
+	stdpath "path"
+	"slices"
+	"strings"
+)
+
+// the operations an acl rule can allow or deny
+const (
+	ACLList       = "list"
+	ACLRead       = "read"
+	ACLUpload     = "upload"
+	ACLRename     = "rename"
+	ACLMove       = "move"
+	ACLCopy       = "copy"
+	ACLDelete     = "delete"
+	ACLShare      = "share"
+	ACLDecompress = "decompress"
+)
+
+var ACLOperations = []string{ACLList, ACLRead, ACLUpload, ACLRename, ACLMove, ACLCopy, ACLDelete, ACLShare, ACLDecompress}
+
+const (
+	ACLSubjectUser     = "user"
+	ACLSubjectGroup    = "group"
+	ACLSubjectEveryone = "everyone"
+)
+
+const (
+	ACLAllow = "allow"
+	ACLDeny  = "deny"
+)
+
+// ACLRule allows or denies operations under the paths matching Path. A rule applies to the
+// matching paths and everything below them. When several rules match, the one with the most
+// specific path wins, then a user rule beats a group rule which beats an everyone rule, and
+// deny beats allow. Rules only narrow what the user permissions allow, an allow rule lifts a
+// less specific deny rule.
+type ACLRule struct {
+	ID uint `json:"id" gorm:"primaryKey"`
+	// Path is a mount path, its segments may use the wildcards of path.Match and ** for any number of segments
+	Path        string   `json:"path" binding:"required"`
+	SubjectType string   `json:"subject_type"`
+	SubjectId   uint     `json:"subject_id"`
+	Operations  []string `json:"operations" gorm:"serializer:json"`
+	Effect      string   `json:"effect"`
+	Disabled    bool     `json:"disabled"`
+	Comment     string   `json:"comment"`
+}
+
+func ValidACLOperation(operation string) bool {
+	return slices.Contains(ACLOperations, operation)
+}
+
+func splitPath(p string) []string {
+	p = strings.Trim(p, "/")
+	if p == "" {
+		return nil
+	}
+	return strings.Split(p, "/")
+}
+
+// Match reports whether the rule applies to the mount path p
+func (r *ACLRule) Match(p string) bool {
+	return matchSegments(splitPath(r.Path), splitPath(p))
+}
+
+// matchSegments matches the pattern against a prefix of the segments, the rest is inherited
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return true
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if ok, _ := stdpath.Match(pattern[0], segments[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], segments[1:])
+}
+
+// specificity is the number of path segments that are not **
+func (r *ACLRule) specificity() int {
+	n := 0
+	for _, s := range splitPath(r.Path) {
+		if s != "**" {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *ACLRule) subjectRank() int {
+	switch r.SubjectType {
+	case ACLSubjectUser:
+		return 2
+	case ACLSubjectGroup:
+		return 1
+	}
+	return 0
+}
+
+// Applies reports whether the rule is about the user and the operation. A nil user is an
+// anonymous client, only the rules for everyone apply to it.
+func (r *ACLRule) Applies(u *User, operation string) bool {
+	if r.Disabled || !slices.Contains(r.Operations, operation) {
+		return false
+	}
+	if u == nil {
+		return r.SubjectType == ACLSubjectEveryone
+	}
+	switch r.SubjectType {
+	case ACLSubjectUser:
+		return u.ID == r.SubjectId
+	case ACLSubjectGroup:
+		return slices.Contains(u.Groups, r.SubjectId)
+	case ACLSubjectEveryone:
+		return true
+	}
+	return false
+}
+
+// precedes reports whether r wins over o when both match
+func (r *ACLRule) precedes(o *ACLRule) bool {
+	if a, b := r.specificity(), o.specificity(); a != b {
+		return a > b
+	}
+	if a, b := r.subjectRank(), o.subjectRank(); a != b {
+		return a > b
+	}
+	return r.Effect == ACLDeny && o.Effect != ACLDeny
+}
+
+// DecideACL returns the rule deciding the operation of the user on the mount path p, nil if no rule matches
+func DecideACL(rules []ACLRule, u *User, operation, p string) *ACLRule {
+	var decided *ACLRule
+	for i := range rules {
+		r := &rules[i]
+		if !r.Applies(u, operation) || !r.Match(p) {
+			continue
+		}
+		if decided == nil || r.precedes(decided) {
+			decided = r
+		}
+	}
+	return decided
+}
@@ -0,0 +1,68 @@
+package model
+
+import "testing"
+
+func TestDecideACL(t *testing.T) {
+	user := &User{ID: 2, Groups: []uint{5}}
+	rules := []ACLRule{
+		{ID: 1, Path: "/team", SubjectType: ACLSubjectGroup, SubjectId: 5, Operations: []string{ACLDelete, ACLUpload}, Effect: ACLDeny},
+		{ID: 2, Path: "/team/shared", SubjectType: ACLSubjectEveryone, Operations: []string{ACLUpload}, Effect: ACLAllow},
+		{ID: 3, Path: "/team/shared", SubjectType: ACLSubjectUser, SubjectId: 2, Operations: []string{ACLUpload}, Effect: ACLDeny},
+		{ID: 4, Path: "/**/*.key", SubjectType: ACLSubjectEveryone, Operations: []string{ACLRead}, Effect: ACLDeny},
+		{ID: 5, Path: "/public", SubjectType: ACLSubjectUser, SubjectId: 2, Operations: []string{ACLList}, Effect: ACLAllow},
+		{ID: 6, Path: "/public", SubjectType: ACLSubjectUser, SubjectId: 2, Operations: []string{ACLList}, Effect: ACLDeny},
+		{ID: 7, Path: "/other", SubjectType: ACLSubjectUser, SubjectId: 3, Operations: []string{ACLList}, Effect: ACLDeny},
+	}
+	tests := []struct {
+		operation string
+		path      string
+		want      uint
+	}{
+		{ACLDelete, "/team/a/b.txt", 1},
+		{ACLDelete, "/teams", 0},
+		{ACLUpload, "/team/shared/x", 3},
+		{ACLRead, "/a/b/id.key", 4},
+		{ACLRead, "/id.key/inner", 4},
+		{ACLRead, "/a/b/id.keys", 0},
+		{ACLList, "/public", 6},
+		{ACLList, "/other", 0},
+	}
+	for _, tt := range tests {
+		got := DecideACL(rules, user, tt.operation, tt.path)
+		var id uint
+		if got != nil {
+			id = got.ID
+		}
+		if id != tt.want {
+			t.Errorf("DecideACL(%s, %s) = rule %d, want %d", tt.operation, tt.path, id, tt.want)
+		}
+	}
+}
+
+func TestDecideACLAnonymous(t *testing.T) {
+	rules := []ACLRule{
+		{ID: 1, Path: "/private", SubjectType: ACLSubjectUser, SubjectId: 2, Operations: []string{ACLRead}, Effect: ACLDeny},
+		{ID: 2, Path: "/team", SubjectType: ACLSubjectEveryone, Operations: []string{ACLRead}, Effect: ACLDeny},
+		{ID: 3, Path: "/team/open", SubjectType: ACLSubjectGroup, SubjectId: 5, Operations: []string{ACLRead}, Effect: ACLAllow},
+		{ID: 4, Path: "/public", SubjectType: ACLSubjectEveryone, Operations: []string{ACLRead}, Effect: ACLAllow},
+	}
+	tests := []struct {
+		path string
+		want uint
+	}{
+		{"/private/a.txt", 0},
+		{"/team/open/a.txt", 2},
+		{"/public/a.txt", 4},
+		{"/other", 0},
+	}
+	for _, tt := range tests {
+		got := DecideACL(rules, nil, ACLRead, tt.path)
+		var id uint
+		if got != nil {
+			id = got.ID
+		}
+		if id != tt.want {
+			t.Errorf("DecideACL(nil, %s) = rule %d, want %d", tt.path, id, tt.want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package model
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+// APITokenPrefix starts every raw api token, it tells them apart from login tokens
+const APITokenPrefix = "olt_"
+
+const (
+	TokenAccessReadWrite  = "read_write"
+	TokenAccessReadOnly   = "read_only"
+	TokenAccessUploadOnly = "upload_only"
+)
+
+// tokenAccessPermissions are the user permission bits kept by each access level
+var tokenAccessPermissions = map[string]int32{
+	TokenAccessReadWrite: -1,
+	// see hides, access without password, webdav read, ftp read and read archives
+	TokenAccessReadOnly: 1<<0 | 1<<1 | 1<<8 | 1<<10 | 1<<12,
+	// mkdir and upload, webdav read (required to log in) and webdav write
+	TokenAccessUploadOnly: 1<<3 | 1<<8 | 1<<9,
+}
+
+// APIToken is a named token a user creates for automation. It acts as the user,
+// limited to the scope path and to the permissions allowed by the token.
+type APIToken struct {
+	ID     uint   `json:"id" gorm:"primaryKey"`
+	UserId uint   `json:"user_id" gorm:"index"`
+	Name   string `json:"name"`
+	// KeyId identifies the token, it is also the access key id on the S3 endpoint
+	KeyId string `json:"key_id" gorm:"uniqueIndex;size:32"`
+	// SecretHash checks the raw token, SecretEnc is the secret encrypted with the api token key
+	// setting, S3 needs the secret itself to check the signatures
+	SecretHash string `json:"-"`
+	SecretEnc  string `json:"-"`
+	// Scope is the path the token is limited to, relative to the base path of the user
+	Scope string `json:"scope"`
+	// Access is read_write, read_only or upload_only
+	Access string `json:"access"`
+	// Permission masks the permissions of the user, using the same bits
+	Permission int32      `json:"permission"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsed   *time.Time `json:"last_used"`
+	Created    time.Time  `json:"created"`
+	Revoked    bool       `json:"revoked"`
+}
+
+func ValidTokenAccess(access string) bool {
+	_, ok := tokenAccessPermissions[access]
+	return ok
+}
+
+func (t *APIToken) Expired() bool {
+	return t.ExpiresAt != nil && !t.ExpiresAt.After(time.Now())
+}
+
+// ScopeUser returns a copy of u narrowed to the token. The base path becomes the scope and
+// the permissions are masked by the token, admins lose their role so tokens never reach admin APIs.
+func (t *APIToken) ScopeUser(u *User) (*User, error) {
+	scoped := *u
+	basePath, err := utils.JoinBasePath(u.GetBasePath(), t.Scope)
+	if err != nil {
+		return nil, err
+	}
+	scoped.BasePath = basePath
+	scoped.Permission = u.EffectivePermission() & t.Permission & tokenAccessPermissions[t.Access]
+	scoped.GroupPermission = 0
+	scoped.GroupBasePath = ""
+	if scoped.Role == ADMIN {
+		scoped.Role = GENERAL
+	}
+	scoped.APITokenId = t.ID
+	scoped.TokenAccess = t.Access
+	return &scoped, nil
+}
@@ -81,19 +81,24 @@ type ArchiveDecompressArgs struct {
 	Overwrite     bool
 }
 
+// the Token of the sharing args is the one of a recipient, required by a restricted sharing
+
 type SharingListArgs struct {
 	Refresh bool
 	Pwd     string
+	Token   string
 }
 
 type SharingArchiveMetaArgs struct {
 	ArchiveMetaArgs
-	Pwd string
+	Pwd   string
+	Token string
 }
 
 type SharingArchiveListArgs struct {
 	ArchiveListArgs
-	Pwd string
+	Pwd   string
+	Token string
 }
 
 type SharingLinkArgs struct {
@@ -0,0 +1,67 @@
+package model
+
+import "time"
+
+// the protocols an operation can come from
+const (
+	ProtocolWeb    = "web"
+	ProtocolWebDAV = "webdav"
+	ProtocolFTP    = "ftp"
+	ProtocolSFTP   = "sftp"
+	ProtocolS3     = "s3"
+)
+
+// the audited operations, admin changes are recorded as admin. followed by the api route
+const (
+	AuditMakeDir        = "mkdir"
+	AuditUpload         = "upload"
+	AuditPutURL         = "put_url"
+	AuditRename         = "rename"
+	AuditMove           = "move"
+	AuditCopy           = "copy"
+	AuditMerge          = "merge"
+	AuditRemove         = "remove"
+	AuditHardLink       = "hard_link"
+	AuditDecompress     = "decompress"
+	AuditRestoreTrash   = "restore_trash"
+	AuditPurgeTrash     = "purge_trash"
+	AuditRestoreVersion = "restore_version"
+	AuditOther          = "other"
+	AuditDownload       = "download"
+	AuditShareCreate    = "share_create"
+	AuditShareUpdate    = "share_update"
+	AuditShareDelete    = "share_delete"
+	AuditShareAccess    = "share_access"
+	AuditLogin          = "login"
+	AuditAdminPrefix    = "admin."
+)
+
+// AuditLog records who did what, from where and whether it worked
+type AuditLog struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Time      time.Time `json:"time" gorm:"index"`
+	UserId    uint      `json:"user_id" gorm:"index"`
+	Username  string    `json:"username"`
+	Protocol  string    `json:"protocol"`
+	IP        string    `json:"ip"`
+	Operation string    `json:"operation" gorm:"index"`
+	Path      string    `json:"path" gorm:"type:text"`
+	DstPath   string    `json:"dst_path" gorm:"type:text"`
+	Size      int64     `json:"size"`
+	Success   bool      `json:"success"`
+	// Detail is the error of a failed operation or what else is worth knowing about it
+	Detail string `json:"detail" gorm:"type:text"`
+}
+
+type AuditLogQuery struct {
+	PageReq
+	Username  string `json:"username" form:"username"`
+	Operation string `json:"operation" form:"operation"`
+	Protocol  string `json:"protocol" form:"protocol"`
+	IP        string `json:"ip" form:"ip"`
+	// Path matches the paths and destination paths starting with it
+	Path    string     `json:"path" form:"path"`
+	Success *bool      `json:"success" form:"success"`
+	From    *time.Time `json:"from" form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To      *time.Time `json:"to" form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+}
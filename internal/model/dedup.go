@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+// DuplicateScan is a run of the duplicate file finder
+type DuplicateScan struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	Paths     []string   `json:"paths" gorm:"serializer:json"`
+	MinSize   int64      `json:"min_size"`
+	Creator   string     `json:"creator"`
+	StartTime time.Time  `json:"start_time"`
+	EndTime   *time.Time `json:"end_time"`
+	Status    string     `json:"status"`
+	Error     string     `json:"error" gorm:"type:text"`
+	Files     int64      `json:"files"`
+	Sets      int64      `json:"sets"`
+	// WastedBytes is the size taken by all but one file of every set
+	WastedBytes int64 `json:"wasted_bytes"`
+}
+
+// DuplicateSet is a group of files with the same size and content
+type DuplicateSet struct {
+	ID     uint  `json:"id" gorm:"primaryKey"`
+	ScanId uint  `json:"scan_id" gorm:"index"`
+	Size   int64 `json:"size"`
+	// Hash is <hash type>:<hash>, the hash type is partial if only the head and the tail were compared
+	Hash     string   `json:"hash"`
+	Partial  bool     `json:"partial"`
+	Paths    []string `json:"paths" gorm:"serializer:json"`
+	Resolved bool     `json:"resolved"`
+	Result   string   `json:"result" gorm:"type:text"`
+}
+
+const (
+	DuplicateActionRemove   = "remove"
+	DuplicateActionHardLink = "hard_link"
+)
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+// Group gives its members a base path and permissions, a user can belong to several groups
+type Group struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	Name        string `json:"name" gorm:"unique" binding:"required"`
+	Description string `json:"description"`
+	BasePath    string `json:"base_path"`
+	// Permission uses the same bits as User.Permission
+	Permission int32 `json:"permission"`
+	// QuotaBytes and QuotaFiles apply to members without a quota of their own, 0 sets none
+	QuotaBytes int64 `json:"quota_bytes"`
+	QuotaFiles int64 `json:"quota_files"`
+	// ExternalGroups are the LDAP groups or OIDC claim values whose users join this group at login
+	ExternalGroups []string `json:"external_groups" gorm:"serializer:json"`
+	Disabled       bool     `json:"disabled"`
+}
+
+// MatchExternal reports whether any of the directory groups of a user maps onto the group,
+// LDAP DNs are compared case insensitively.
+func (g *Group) MatchExternal(external []string) bool {
+	for _, want := range g.ExternalGroups {
+		for _, have := range external {
+			if strings.EqualFold(want, have) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ApplyGroups merges the groups into the user. The permissions of the groups are added to the
+// ones of the user. A user whose own base path is the root gets the nearest common parent of
+// the base paths of the groups instead, a user with a narrower base path keeps it. The user
+// gets the largest quota set by its groups.
+func (u *User) ApplyGroups(groups []Group) {
+	u.GroupPermission = 0
+	u.GroupBasePath = ""
+	u.GroupQuotaBytes, u.GroupQuotaFiles = 0, 0
+	var paths []string
+	for _, g := range groups {
+		if g.Disabled {
+			continue
+		}
+		u.GroupPermission |= g.Permission
+		u.GroupQuotaBytes = max(u.GroupQuotaBytes, g.QuotaBytes)
+		u.GroupQuotaFiles = max(u.GroupQuotaFiles, g.QuotaFiles)
+		paths = append(paths, utils.FixAndCleanPath(g.BasePath))
+	}
+	if len(paths) > 0 {
+		u.GroupBasePath = commonParent(paths)
+	}
+}
+
+func commonParent(paths []string) string {
+	common := strings.Split(paths[0], "/")
+	for _, p := range paths[1:] {
+		parts := strings.Split(p, "/")
+		n := 0
+		for n < len(common) && n < len(parts) && common[n] == parts[n] {
+			n++
+		}
+		common = common[:n]
+	}
+	return utils.FixAndCleanPath(strings.Join(common, "/"))
+}
@@ -4,8 +4,10 @@ type Meta struct {
 	ID            uint   `json:"id" gorm:"primaryKey"`
 	Path          string `json:"path" gorm:"unique" binding:"required"`
 	ReadUsers     []uint `json:"read_users" gorm:"serializer:json"`
+	ReadGroups    []uint `json:"read_groups" gorm:"serializer:json"` // groups whose members are also read users
 	ReadUsersSub  bool   `json:"read_users_sub"`
 	WriteUsers    []uint `json:"write_users" gorm:"serializer:json"`
+	WriteGroups   []uint `json:"write_groups" gorm:"serializer:json"` // groups whose members are also write users
 	WriteUsersSub bool   `json:"write_users_sub"`
 	Password      string `json:"password"`
 	PSub          bool   `json:"p_sub"`
@@ -17,4 +19,6 @@ type Meta struct {
 	RSub          bool   `json:"r_sub"`
 	Header        string `json:"header"`
 	HeaderSub     bool   `json:"header_sub"`
+	Versions      int    `json:"versions"` // number of old revisions to keep on overwrite, 0 to disable
+	VSub          bool   `json:"v_sub"`
 }
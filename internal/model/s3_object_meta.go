@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// S3ObjectMeta keeps what S3 clients store with an object and the storages can't, like the
+// user metadata and the etag. It belongs to the object as long as its size and modified
+// time are the same, so changes made outside S3 make it stale.
+type S3ObjectMeta struct {
+	ID     uint   `json:"id" gorm:"primaryKey"`
+	Path   string `json:"path" gorm:"unique"`
+	Parent string `json:"parent" gorm:"index"`
+	// Metadata are the stored headers, the x-amz-meta-* ones and the content headers
+	Metadata map[string]string `json:"metadata" gorm:"serializer:json"`
+	ETag     string            `json:"etag"`
+	Size     int64             `json:"size"`
+	Modified time.Time         `json:"modified"`
+}
+
+// Fresh reports whether the meta still belongs to the object with size and modified
+func (m *S3ObjectMeta) Fresh(size int64, modified time.Time) bool {
+	return m.Size == size && m.Modified.Equal(modified)
+}
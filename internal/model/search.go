@@ -1,8 +1,13 @@
 package model
 
 import (
+	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 )
 
 type IndexProgress struct {
@@ -12,19 +17,54 @@ type IndexProgress struct {
 	Error        string     `json:"error"`
 }
 
+// StorageIndex is the state of the last incremental index update of a storage
+type StorageIndex struct {
+	StorageId uint       `json:"storage_id" gorm:"primaryKey;autoIncrement:false"`
+	LastStart *time.Time `json:"last_start"`
+	LastDone  *time.Time `json:"last_done"`
+	Dirs      uint64     `json:"dirs"`
+	Added     uint64     `json:"added"`
+	Updated   uint64     `json:"updated"`
+	Deleted   uint64     `json:"deleted"`
+	Error     string     `json:"error"`
+}
+
 type SearchReq struct {
 	Parent   string `json:"parent"`
 	Keywords string `json:"keywords"`
 	// 0 for all, 1 for dir, 2 for file
 	Scope int `json:"scope"`
+	// name, size or modified, sorted by name if empty
+	OrderBy string `json:"order_by"`
+	// asc or desc
+	OrderDirection string `json:"order_direction"`
+	// Filters are parsed from the keywords, e.g. size>1G modified<2024-01-01 type:video hash:md5=...
+	Filters SearchFilters `json:"-"`
 	PageReq
 }
 
+// SearchFilters narrow down the search results, zero values mean no limit.
+type SearchFilters struct {
+	MinSize *int64
+	MaxSize *int64
+	// ModifiedFrom is inclusive and ModifiedTo is exclusive
+	ModifiedFrom *time.Time
+	ModifiedTo   *time.Time
+	// ObjTypes are the types returned by utils.GetObjType
+	ObjTypes []int
+	// Hashes maps hash type names to lower case hash values
+	Hashes map[string]string
+}
+
 type SearchNode struct {
-	Parent string `json:"parent" gorm:"index"`
-	Name   string `json:"name"`
-	IsDir  bool   `json:"is_dir"`
-	Size   int64  `json:"size"`
+	Parent   string    `json:"parent" gorm:"index"`
+	Name     string    `json:"name"`
+	IsDir    bool      `json:"is_dir"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+	ObjType  int       `json:"obj_type"`
+	// Hashes are the lower case hashes exposed by the storage, in the json format of utils.HashInfo
+	Hashes string `json:"hashes"`
 }
 
 func (p *SearchReq) Validate() error {
@@ -40,3 +80,57 @@ func (p *SearchReq) Validate() error {
 func (s *SearchNode) Type() string {
 	return "SearchNode"
 }
+
+// NewSearchNode builds the index node of obj
+func NewSearchNode(parent string, obj Obj) SearchNode {
+	return SearchNode{
+		Parent:   parent,
+		Name:     obj.GetName(),
+		IsDir:    obj.IsDir(),
+		Size:     obj.GetSize(),
+		Modified: obj.ModTime(),
+		ObjType:  utils.GetObjType(obj.GetName(), obj.IsDir()),
+		Hashes:   searchHashes(obj.GetHash()),
+	}
+}
+
+// Outdated reports whether the indexed file no longer matches obj and should be indexed again
+func (s *SearchNode) Outdated(obj Obj) bool {
+	if s.IsDir || obj.IsDir() {
+		return false
+	}
+	return s.Size != obj.GetSize() ||
+		!s.Modified.Truncate(time.Second).Equal(obj.ModTime().Truncate(time.Second)) ||
+		s.Hashes != searchHashes(obj.GetHash())
+}
+
+func searchHashes(hi utils.HashInfo) string {
+	m := make(map[string]string)
+	for ht, h := range hi.All() {
+		if h != "" {
+			m[ht.Name] = strings.ToLower(h)
+		}
+	}
+	if len(m) == 0 {
+		return ""
+	}
+	b, _ := json.Marshal(m)
+	return string(b)
+}
+
+// HashList returns the hashes of the node as <hash type>:<hash>, sorted
+func (s *SearchNode) HashList() []string {
+	if s.Hashes == "" {
+		return nil
+	}
+	var m map[string]string
+	if err := json.Unmarshal([]byte(s.Hashes), &m); err != nil {
+		return nil
+	}
+	res := make([]string, 0, len(m))
+	for name, h := range m {
+		res = append(res, name+":"+h)
+	}
+	sort.Strings(res)
+	return res
+}
@@ -1,20 +1,50 @@
 package model
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+)
+
+const (
+	// SharingTypeUpload shares a folder for anonymous uploads only, nothing in it can be read
+	SharingTypeUpload = "upload"
+)
 
 type SharingDB struct {
-	ID          string     `json:"id" gorm:"type:char(12);primaryKey"`
-	FilesRaw    string     `json:"-" gorm:"type:text"`
-	Expires     *time.Time `json:"expires"`
-	Pwd         string     `json:"pwd"`
-	Accessed    int        `json:"accessed"`
-	MaxAccessed int        `json:"max_accessed"`
-	CreatorId   uint       `json:"-"`
-	Disabled    bool       `json:"disabled"`
-	Remark      string     `json:"remark"`
-	Readme      string     `json:"readme" gorm:"type:text"`
-	Header      string     `json:"header" gorm:"type:text"`
+	ID       string     `json:"id" gorm:"type:char(12);primaryKey"`
+	FilesRaw string     `json:"-" gorm:"type:text"`
+	Expires  *time.Time `json:"expires"`
+	// Pwd is the password in plain text of a sharing created by an old version, it is hashed on upgrade
+	Pwd         string `json:"-"`
+	PwdHash     string `json:"-"`
+	PwdSalt     string `json:"-"`
+	Accessed    int    `json:"accessed"`
+	MaxAccessed int    `json:"max_accessed"`
+	CreatorId   uint   `json:"-"`
+	Disabled    bool   `json:"disabled"`
+	Remark      string `json:"remark"`
+	Readme      string `json:"readme" gorm:"type:text"`
+	Header      string `json:"header" gorm:"type:text"`
 	Sort
+	// Type is empty for a read only sharing, or SharingTypeUpload
+	Type string `json:"type"`
+	// the limits of an upload sharing, 0 or empty for none
+	MaxFileSize int64 `json:"max_file_size"`
+	// AllowedExts is a comma separated list of the extensions an uploaded file may have
+	AllowedExts   string `json:"allowed_exts"`
+	MaxTotalBytes int64  `json:"max_total_bytes"`
+	UploadedBytes int64  `json:"uploaded_bytes"`
+	// MaxBandwidth limits the bytes served by the downloads of the sharing, 0 for no limit
+	MaxBandwidth        int64 `json:"max_bandwidth"`
+	BytesServed         int64 `json:"bytes_served"`
+	MaxDownloadsPerFile int   `json:"max_downloads_per_file"`
+	// Restricted sharings are only accessible by their recipients, with a token got by email
+	Restricted bool `json:"restricted"`
 }
 
 type Sharing struct {
@@ -42,6 +72,53 @@ func (s *Sharing) Valid() bool {
 	return true
 }
 
+// HasPwd reports whether the sharing asks for a password
+func (s *Sharing) HasPwd() bool {
+	return s.PwdHash != "" || s.Pwd != ""
+}
+
+// SetPwd hashes the password like the one of a user, an empty one removes it
+func (s *Sharing) SetPwd(pwd string) {
+	s.Pwd = ""
+	if pwd == "" {
+		s.PwdHash, s.PwdSalt = "", ""
+		return
+	}
+	s.PwdSalt = random.String(16)
+	s.PwdHash = TwoHashPwd(pwd, s.PwdSalt)
+}
+
 func (s *Sharing) Verify(pwd string) bool {
-	return s.Pwd == "" || s.Pwd == pwd
+	if s.PwdHash == "" {
+		return s.Pwd == "" || s.Pwd == pwd
+	}
+	return s.PwdHash == TwoHashPwd(pwd, s.PwdSalt)
+}
+
+func (s *Sharing) IsUpload() bool {
+	return s.Type == SharingTypeUpload
+}
+
+// CheckUpload returns why the file named name of size bytes can't be uploaded into the sharing, nil if it can
+func (s *Sharing) CheckUpload(name string, size int64) error {
+	if s.MaxFileSize > 0 && size > s.MaxFileSize {
+		return fmt.Errorf("the file is larger than %d bytes", s.MaxFileSize)
+	}
+	if s.AllowedExts != "" {
+		ext := utils.Ext(name)
+		allowed := false
+		for _, e := range strings.Split(s.AllowedExts, ",") {
+			if strings.ToLower(strings.TrimPrefix(strings.TrimSpace(e), ".")) == ext {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("only files with the extensions %s are accepted", s.AllowedExts)
+		}
+	}
+	if s.MaxTotalBytes > 0 && s.UploadedBytes+size > s.MaxTotalBytes {
+		return errors.New("the sharing has no space left")
+	}
+	return nil
 }
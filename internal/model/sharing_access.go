@@ -0,0 +1,43 @@
+package model
+
+import "time"
+
+// the kinds of access to a sharing
+const (
+	SharingAccessView     = "view"
+	SharingAccessDownload = "download"
+	// SharingAccessArchive is the download of a file inside an archive
+	SharingAccessArchive = "archive"
+)
+
+// SharingAccess is an entry of the access log of a sharing
+type SharingAccess struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	SharingId string    `json:"sharing_id" gorm:"type:char(12);index"`
+	Time      time.Time `json:"time" gorm:"index"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent" gorm:"type:text"`
+	// Recipient is the email of the recipient of a restricted sharing
+	Recipient string `json:"recipient" gorm:"index"`
+	Kind      string `json:"kind"`
+	// Path is the path in the sharing, InnerPath the one in the archive at Path
+	Path      string `json:"path" gorm:"type:text"`
+	InnerPath string `json:"inner_path" gorm:"type:text"`
+	// Bytes is what was served, the size of the file when the client was redirected to the storage
+	Bytes int64 `json:"bytes"`
+}
+
+type SharingFileStats struct {
+	Path      string `json:"path"`
+	InnerPath string `json:"inner_path"`
+	Downloads int64  `json:"downloads"`
+	Bytes     int64  `json:"bytes"`
+}
+
+type SharingStats struct {
+	Accesses       int64              `json:"accesses"`
+	UniqueVisitors int64              `json:"unique_visitors"`
+	Downloads      int64              `json:"downloads"`
+	BytesServed    int64              `json:"bytes_served"`
+	Files          []SharingFileStats `json:"files" gorm:"-"`
+}
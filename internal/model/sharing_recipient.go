@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// SharingRecipient is an email a restricted sharing is shared with, its owner gets a one time
+// code by email and exchanges it for a token to access the sharing
+type SharingRecipient struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	SharingId string `json:"sharing_id" gorm:"type:char(12);index"`
+	Email     string `json:"email"`
+	// Revoked recipients can't get codes anymore and their tokens are refused
+	Revoked    bool       `json:"revoked"`
+	LastAccess *time.Time `json:"last_access"`
+	CreatedAt  time.Time  `json:"created_at"`
+	// the code sent last, hashed like a password
+	CodeHash    string    `json:"-"`
+	CodeSalt    string    `json:"-"`
+	CodeSentAt  time.Time `json:"-"`
+	CodeExpires time.Time `json:"-"`
+	// CodeAttempts counts the codes tried since the code was sent
+	CodeAttempts int `json:"-"`
+}
@@ -0,0 +1,49 @@
+package model
+
+import "testing"
+
+func TestSharingCheckUpload(t *testing.T) {
+	s := &Sharing{SharingDB: &SharingDB{
+		Type:          SharingTypeUpload,
+		MaxFileSize:   100,
+		AllowedExts:   "pdf, .DOCX",
+		MaxTotalBytes: 150,
+		UploadedBytes: 60,
+	}}
+	tests := []struct {
+		name string
+		size int64
+		ok   bool
+	}{
+		{"a.pdf", 50, true},
+		{"b.docx", 90, true},
+		{"c.PDF", 10, true},
+		{"d.exe", 10, false},
+		{"noext", 10, false},
+		{"e.pdf", 101, false},
+		{"f.pdf", 91, false},
+	}
+	for _, tt := range tests {
+		if err := s.CheckUpload(tt.name, tt.size); (err == nil) != tt.ok {
+			t.Errorf("CheckUpload(%s, %d) = %v, want ok %v", tt.name, tt.size, err, tt.ok)
+		}
+	}
+}
+
+func TestSharingVerify(t *testing.T) {
+	s := &Sharing{SharingDB: &SharingDB{Pwd: "legacy"}}
+	if !s.Verify("legacy") || s.Verify("wrong") {
+		t.Errorf("the plain text password of an old sharing is not verified")
+	}
+	s.SetPwd(s.Pwd)
+	if s.Pwd != "" || s.PwdHash == "" {
+		t.Fatalf("the password is not hashed")
+	}
+	if !s.Verify("legacy") || s.Verify("wrong") || s.Verify("") {
+		t.Errorf("the hashed password is not verified")
+	}
+	s.SetPwd("")
+	if s.HasPwd() || !s.Verify("") {
+		t.Errorf("the password is not removed")
+	}
+}
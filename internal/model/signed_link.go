@@ -0,0 +1,54 @@
+package model
+
+import (
+	"net"
+	"strings"
+	"time"
+)
+
+// SignedLink is a download link to a file minted by a user, it expires and may be limited to
+// some addresses, a number of downloads, a byte range of the file and a speed
+type SignedLink struct {
+	ID     uint `json:"id" gorm:"primaryKey"`
+	UserId uint `json:"user_id" gorm:"index"`
+	// Path is the full path of the file, with the base path of the user
+	Path    string    `json:"path" gorm:"type:text"`
+	Expires time.Time `json:"expires"`
+	// IPs is a comma separated list of the addresses or CIDRs allowed to download, any if empty
+	IPs          string `json:"ips"`
+	MaxDownloads int    `json:"max_downloads"`
+	Downloads    int    `json:"downloads"`
+	// RangeLength bytes from RangeStart can be downloaded, the whole file if it is 0
+	RangeStart  int64 `json:"range_start"`
+	RangeLength int64 `json:"range_length"`
+	// RateLimit is the download speed in KB/s, 0 for no limit
+	RateLimit int       `json:"rate_limit"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AllowsIP reports whether the link can be used from ip
+func (l *SignedLink) AllowsIP(ip string) bool {
+	if l.IPs == "" {
+		return true
+	}
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, s := range strings.Split(l.IPs, ",") {
+		s = strings.TrimSpace(s)
+		if _, ipNet, err := net.ParseCIDR(s); err == nil {
+			if ipNet.Contains(addr) {
+				return true
+			}
+		} else if allowed := net.ParseIP(s); allowed != nil && allowed.Equal(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// MustProxy reports whether the download has to go through the server to enforce the link
+func (l *SignedLink) MustProxy() bool {
+	return l.RangeLength > 0 || l.RateLimit > 0
+}
@@ -0,0 +1,26 @@
+package model
+
+import "testing"
+
+func TestSignedLinkAllowsIP(t *testing.T) {
+	l := &SignedLink{IPs: "10.0.0.0/8, 192.168.1.2,2001:db8::/32"}
+	tests := []struct {
+		ip string
+		ok bool
+	}{
+		{"10.1.2.3", true},
+		{"192.168.1.2", true},
+		{"192.168.1.3", false},
+		{"2001:db8::1", true},
+		{"2001:db9::1", false},
+		{"invalid", false},
+	}
+	for _, tt := range tests {
+		if got := l.AllowsIP(tt.ip); got != tt.ok {
+			t.Errorf("AllowsIP(%s) = %v, want %v", tt.ip, got, tt.ok)
+		}
+	}
+	if !(&SignedLink{}).AllowsIP("1.1.1.1") {
+		t.Errorf("a link without ips refuses an address")
+	}
+}
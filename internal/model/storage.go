@@ -19,10 +19,18 @@ type Storage struct {
 	Disabled            bool      `json:"disabled"` // if disabled
 	DisableIndex        bool      `json:"disable_index"`
 	EnableSign          bool      `json:"enable_sign"`
+	IndexSchedule
 	Sort
 	Proxy
 }
 
+// IndexSchedule updates the search index of the storage incrementally on a cron schedule
+type IndexSchedule struct {
+	IndexCron string `json:"index_cron"`
+	// IndexRateLimit limits the dirs listed per second while walking, 0 for no limit
+	IndexRateLimit float64 `json:"index_rate_limit"`
+}
+
 type Sort struct {
 	OrderBy        string `json:"order_by"`
 	OrderDirection string `json:"order_direction"`
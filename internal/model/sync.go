@@ -0,0 +1,78 @@
+package model
+
+import "time"
+
+const (
+	SyncModeUpdate = "update" // copy new and changed files from src to dst
+	SyncModeMirror = "mirror" // make dst identical to src, files only in dst are removed
+	SyncModeTwoWay = "two_way"
+)
+
+const (
+	SyncConflictNewer = "newer" // keep the file modified last
+	SyncConflictSrc   = "src"
+	SyncConflictDst   = "dst"
+	SyncConflictSkip  = "skip"
+)
+
+type SyncJob struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	Name           string     `json:"name"`
+	SrcPath        string     `json:"src_path" binding:"required"`
+	DstPath        string     `json:"dst_path" binding:"required"`
+	Mode           string     `json:"mode"`
+	ConflictPolicy string     `json:"conflict_policy"`
+	CompareHash    bool       `json:"compare_hash"`
+	Cron           string     `json:"cron"`
+	Disabled       bool       `json:"disabled"`
+	CreatorId      uint       `json:"creator_id"`
+	LastRun        *time.Time `json:"last_run"`
+	LastStatus     string     `json:"last_status"`
+}
+
+type SyncRun struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	JobId     uint       `json:"job_id" gorm:"index"`
+	DryRun    bool       `json:"dry_run"`
+	StartTime time.Time  `json:"start_time"`
+	EndTime   *time.Time `json:"end_time"`
+	Status    string     `json:"status"`
+	Error     string     `json:"error" gorm:"type:text"`
+	Report    string     `json:"report" gorm:"type:text"` // json encoded SyncReport
+}
+
+type SyncAction struct {
+	Op     string `json:"op"`
+	Path   string `json:"path"` // relative to the job's src and dst path
+	IsDir  bool   `json:"is_dir,omitempty"`
+	Size   int64  `json:"size,omitempty"`
+	Reason string `json:"reason,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+const (
+	SyncOpCopyToDst = "copy_to_dst"
+	SyncOpCopyToSrc = "copy_to_src"
+	SyncOpDeleteDst = "delete_dst"
+	SyncOpDeleteSrc = "delete_src"
+	SyncOpConflict  = "conflict"
+)
+
+type SyncReport struct {
+	Identical int          `json:"identical"`
+	Actions   []SyncAction `json:"actions"`
+	Failed    int          `json:"failed"`
+}
+
+// SyncSnapshotEntry is a path both sides of a two way sync job had in common after its last
+// run, the next run tells from it which side changed or deleted the path.
+type SyncSnapshotEntry struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	JobId       uint      `json:"job_id" gorm:"index"`
+	Path        string    `json:"path" gorm:"type:text"` // relative to the job's src and dst path
+	IsDir       bool      `json:"is_dir"`
+	SrcSize     int64     `json:"src_size"`
+	SrcModified time.Time `json:"src_modified"`
+	DstSize     int64     `json:"dst_size"`
+	DstModified time.Time `json:"dst_modified"`
+}
@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+const (
+	TransferEntryNew       = "new"
+	TransferEntryIdentical = "identical"
+	TransferEntryConflict  = "conflict"
+	TransferEntryDstOnly   = "dst_only"
+)
+
+const (
+	TransferActionCopy = "copy" // the entry will be copied, overwriting the existing one if any
+	TransferActionSkip = "skip" // the entry exists in dst and will be skipped by merge
+	TransferActionNone = "none" // nothing to do, e.g. an existing dir or a dst only entry
+)
+
+// TransferPlanEntry is a single object of a copy, move or merge, Path is relative
+// to both the src dir and the dst dir.
+type TransferPlanEntry struct {
+	Path        string     `json:"path"`
+	IsDir       bool       `json:"is_dir"`
+	Status      string     `json:"status"`
+	Action      string     `json:"action"`
+	SrcSize     int64      `json:"src_size"`
+	DstSize     int64      `json:"dst_size"`
+	SrcModified *time.Time `json:"src_modified,omitempty"`
+	DstModified *time.Time `json:"dst_modified,omitempty"`
+}
+
+type TransferPlan struct {
+	Entries   []TransferPlanEntry `json:"entries"`
+	New       int                 `json:"new"`
+	Identical int                 `json:"identical"`
+	Conflict  int                 `json:"conflict"`
+	DstOnly   int                 `json:"dst_only"`
+	// CopyBytes is the total size of the files that will be copied
+	CopyBytes int64 `json:"copy_bytes"`
+}
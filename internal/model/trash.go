@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+type TrashItem struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	StorageId  uint      `json:"storage_id" gorm:"index"`
+	Path       string    `json:"path" gorm:"type:text"` // original mount path of the removed object
+	TrashPath  string    `json:"-" gorm:"type:text"`    // actual path of the holder dir inside the storage
+	Name       string    `json:"name"`
+	IsDir      bool      `json:"is_dir"`
+	Size       int64     `json:"size"`
+	DeleterId  uint      `json:"deleter_id" gorm:"index"`
+	Deleter    string    `json:"deleter"`
+	DeleteTime time.Time `json:"delete_time" gorm:"index"`
+}
@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// UserUsage is what a user stores under its base path. Uploads and removals update it as they
+// happen, a rescan replaces it with the real totals.
+type UserUsage struct {
+	UserId    uint       `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	Bytes     int64      `json:"bytes"`
+	Files     int64      `json:"files"`
+	ScannedAt *time.Time `json:"scanned_at"`
+}
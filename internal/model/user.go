@@ -68,6 +68,20 @@ type User struct {
 	SsoID      string `json:"sso_id"` // unique by sso platform
 	Authn      string `gorm:"type:text" json:"-"`
 	AllowLdap  bool   `json:"allow_ldap" gorm:"default:true"`
+	// Groups are the ids of the groups the user belongs to
+	Groups []uint `json:"groups" gorm:"serializer:json"`
+	// GroupPermission and GroupBasePath are merged from the groups when the user is loaded
+	GroupPermission int32  `json:"-" gorm:"-"`
+	GroupBasePath   string `json:"-" gorm:"-"`
+	// QuotaBytes and QuotaFiles limit what the user stores under its base path, 0 is unlimited
+	QuotaBytes int64 `json:"quota_bytes"`
+	QuotaFiles int64 `json:"quota_files"`
+	// GroupQuotaBytes and GroupQuotaFiles are merged from the groups when the user is loaded
+	GroupQuotaBytes int64 `json:"-" gorm:"-"`
+	GroupQuotaFiles int64 `json:"-" gorm:"-"`
+	// APITokenId and TokenAccess are set when the user is authenticated by an api token
+	APITokenId  uint   `json:"-" gorm:"-"`
+	TokenAccess string `json:"-" gorm:"-"`
 }
 
 func (u *User) IsGuest() bool {
@@ -104,7 +118,7 @@ func CanSeeHides(permission int32) bool {
 }
 
 func (u *User) CanSeeHides() bool {
-	return CanSeeHides(u.Permission)
+	return CanSeeHides(u.EffectivePermission())
 }
 
 func CanAccessWithoutPassword(permission int32) bool {
@@ -112,7 +126,7 @@ func CanAccessWithoutPassword(permission int32) bool {
 }
 
 func (u *User) CanAccessWithoutPassword() bool {
-	return CanAccessWithoutPassword(u.Permission)
+	return CanAccessWithoutPassword(u.EffectivePermission())
 }
 
 func CanAddOfflineDownloadTasks(permission int32) bool {
@@ -120,7 +134,7 @@ func CanAddOfflineDownloadTasks(permission int32) bool {
 }
 
 func (u *User) CanAddOfflineDownloadTasks() bool {
-	return CanAddOfflineDownloadTasks(u.Permission)
+	return CanAddOfflineDownloadTasks(u.EffectivePermission())
 }
 
 func CanWriteContent(permission int32) bool {
@@ -128,7 +142,7 @@ func CanWriteContent(permission int32) bool {
 }
 
 func (u *User) CanWriteContent() bool {
-	return CanWriteContent(u.Permission)
+	return CanWriteContent(u.EffectivePermission())
 }
 
 func CanRename(permission int32) bool {
@@ -136,7 +150,7 @@ func CanRename(permission int32) bool {
 }
 
 func (u *User) CanRename() bool {
-	return CanRename(u.Permission)
+	return CanRename(u.EffectivePermission())
 }
 
 func CanMove(permission int32) bool {
@@ -144,7 +158,7 @@ func CanMove(permission int32) bool {
 }
 
 func (u *User) CanMove() bool {
-	return CanMove(u.Permission)
+	return CanMove(u.EffectivePermission())
 }
 
 func CanCopy(permission int32) bool {
@@ -152,7 +166,7 @@ func CanCopy(permission int32) bool {
 }
 
 func (u *User) CanCopy() bool {
-	return CanCopy(u.Permission)
+	return CanCopy(u.EffectivePermission())
 }
 
 func CanRemove(permission int32) bool {
@@ -160,7 +174,7 @@ func CanRemove(permission int32) bool {
 }
 
 func (u *User) CanRemove() bool {
-	return CanRemove(u.Permission)
+	return CanRemove(u.EffectivePermission())
 }
 
 func CanWebdavRead(permission int32) bool {
@@ -168,7 +182,7 @@ func CanWebdavRead(permission int32) bool {
 }
 
 func (u *User) CanWebdavRead() bool {
-	return CanWebdavRead(u.Permission)
+	return CanWebdavRead(u.EffectivePermission())
 }
 
 func CanWebdavManage(permission int32) bool {
@@ -176,7 +190,7 @@ func CanWebdavManage(permission int32) bool {
 }
 
 func (u *User) CanWebdavManage() bool {
-	return CanWebdavManage(u.Permission)
+	return CanWebdavManage(u.EffectivePermission())
 }
 
 func CanFTPAccess(permission int32) bool {
@@ -184,7 +198,7 @@ func CanFTPAccess(permission int32) bool {
 }
 
 func (u *User) CanFTPAccess() bool {
-	return CanFTPAccess(u.Permission)
+	return CanFTPAccess(u.EffectivePermission())
 }
 
 func CanFTPManage(permission int32) bool {
@@ -192,7 +206,7 @@ func CanFTPManage(permission int32) bool {
 }
 
 func (u *User) CanFTPManage() bool {
-	return CanFTPManage(u.Permission)
+	return CanFTPManage(u.EffectivePermission())
 }
 
 func CanReadArchives(permission int32) bool {
@@ -200,7 +214,7 @@ func CanReadArchives(permission int32) bool {
 }
 
 func (u *User) CanReadArchives() bool {
-	return CanReadArchives(u.Permission)
+	return CanReadArchives(u.EffectivePermission())
 }
 
 func CanDecompress(permission int32) bool {
@@ -208,7 +222,7 @@ func CanDecompress(permission int32) bool {
 }
 
 func (u *User) CanDecompress() bool {
-	return CanDecompress(u.Permission)
+	return CanDecompress(u.EffectivePermission())
 }
 
 func CanShare(permission int32) bool {
@@ -216,11 +230,48 @@ func CanShare(permission int32) bool {
 }
 
 func (u *User) CanShare() bool {
-	return CanShare(u.Permission)
+	return CanShare(u.EffectivePermission())
+}
+
+// CanReadContent is false for upload only api tokens
+func (u *User) CanReadContent() bool {
+	return u.TokenAccess != TokenAccessUploadOnly
+}
+
+// IsAPIToken reports whether the user is authenticated by an api token
+func (u *User) IsAPIToken() bool {
+	return u.APITokenId != 0
+}
+
+// EffectivePermission is the permission of the user with the ones of its groups added
+func (u *User) EffectivePermission() int32 {
+	return u.Permission | u.GroupPermission
+}
+
+// GetBasePath returns the base path of the user, or the one merged from its groups
+// when the user itself is not limited
+func (u *User) GetBasePath() string {
+	if u.GroupBasePath == "" || utils.FixAndCleanPath(u.BasePath) != "/" {
+		return u.BasePath
+	}
+	return u.GroupBasePath
+}
+
+// EffectiveQuota returns the byte and file quota of the user, its own quota wins over the
+// ones of its groups. 0 is unlimited.
+func (u *User) EffectiveQuota() (bytes, files int64) {
+	bytes, files = u.QuotaBytes, u.QuotaFiles
+	if bytes == 0 {
+		bytes = u.GroupQuotaBytes
+	}
+	if files == 0 {
+		files = u.GroupQuotaFiles
+	}
+	return bytes, files
 }
 
 func (u *User) JoinPath(reqPath string) (string, error) {
-	return utils.JoinBasePath(u.BasePath, reqPath)
+	return utils.JoinBasePath(u.GetBasePath(), reqPath)
 }
 
 func StaticHash(password string) string {
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+type FileVersion struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	StorageId   uint      `json:"-" gorm:"index"`
+	Path        string    `json:"path" gorm:"index"`  // mount path of the versioned file
+	VersionPath string    `json:"-" gorm:"type:text"` // actual path of the holder dir inside the storage
+	Size        int64     `json:"size"`
+	Modified    time.Time `json:"modified"`
+	CreatorId   uint      `json:"creator_id"`
+	Creator     string    `json:"creator"`
+	Created     time.Time `json:"created"`
+}
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// WebDAVLock is a lock taken by a WebDAV client on a path. It is kept in the database so that
+// it survives restarts and is seen by the other protocols and the other instances.
+type WebDAVLock struct {
+	Token string `json:"token" gorm:"primaryKey"`
+	// Root is the full path of the locked resource
+	Root      string `json:"root" gorm:"index"`
+	ZeroDepth bool   `json:"zero_depth"`
+	OwnerXML  string `json:"owner_xml" gorm:"type:text"`
+	// Duration is the timeout of the lock, negative for a lock that doesn't expire
+	Duration time.Duration `json:"duration"`
+	// Expiry is nil for a lock that doesn't expire
+	Expiry    *time.Time `json:"expiry" gorm:"index"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Expired reports whether the lock is no longer valid at now
+func (l *WebDAVLock) Expired(now time.Time) bool {
+	return l.Expiry != nil && !now.Before(*l.Expiry)
+}
+
+// SetDuration sets the timeout of the lock starting at now
+func (l *WebDAVLock) SetDuration(now time.Time, d time.Duration) {
+	l.Duration = d
+	l.Expiry = nil
+	if d >= 0 {
+		expiry := now.Add(d)
+		l.Expiry = &expiry
+	}
+}
+
+// Covers reports whether the lock applies to path, the root itself or a path below an
+// infinite depth lock
+func (l *WebDAVLock) Covers(path string) bool {
+	if path == l.Root {
+		return true
+	}
+	return !l.ZeroDepth && (l.Root == "/" || strings.HasPrefix(path, l.Root+"/"))
+}
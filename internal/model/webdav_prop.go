@@ -0,0 +1,13 @@
+package model
+
+// WebDAVProp is a dead property set on a path by a WebDAV PROPPATCH, like the Win32
+// timestamps and attributes of the Windows and macOS clients.
+type WebDAVProp struct {
+	ID    uint   `json:"id" gorm:"primaryKey"`
+	Path  string `json:"path" gorm:"index"`
+	Space string `json:"space"`
+	Local string `json:"local"`
+	Lang  string `json:"lang"`
+	// InnerXML is the raw content of the property element
+	InnerXML string `json:"inner_xml" gorm:"type:text"`
+}
@@ -0,0 +1,68 @@
+package model
+
+import (
+	"slices"
+	"time"
+)
+
+// the events a webhook can subscribe to
+const (
+	EventUpload      = "fs.upload"
+	EventRemove      = "fs.remove"
+	EventRename      = "fs.rename"
+	EventMove        = "fs.move"
+	EventShareCreate = "share.create"
+	EventShareAccess = "share.access"
+	// the offline download succeeds once the file is downloaded and handed to the transfer into the
+	// destination, it fails when either the download or the transfer fails
+	EventOfflineDownloadSucceeded = "offline_download.succeeded"
+	EventOfflineDownloadFailed    = "offline_download.failed"
+	EventCopySucceeded            = "copy.succeeded"
+	EventCopyFailed               = "copy.failed"
+	// EventPing is only sent by the test of a webhook
+	EventPing = "ping"
+)
+
+var WebhookEvents = []string{
+	EventUpload, EventRemove, EventRename, EventMove, EventShareCreate, EventShareAccess,
+	EventOfflineDownloadSucceeded, EventOfflineDownloadFailed, EventCopySucceeded, EventCopyFailed,
+}
+
+// Webhook posts the events it subscribes to as json to URL
+type Webhook struct {
+	ID   uint   `json:"id" gorm:"primaryKey"`
+	Name string `json:"name" binding:"required"`
+	URL  string `json:"url" binding:"required"`
+	// Secret signs the payloads, no signature is sent when it is empty
+	Secret string   `json:"secret"`
+	Events []string `json:"events" gorm:"serializer:json"`
+	// Paths limits the events to those about these mount paths and below, empty for all
+	Paths    []string `json:"paths" gorm:"serializer:json"`
+	Disabled bool     `json:"disabled"`
+	Remark   string   `json:"remark"`
+}
+
+func (w *Webhook) Subscribes(event string) bool {
+	return !w.Disabled && slices.Contains(w.Events, event)
+}
+
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// WebhookDelivery is an event sent or to be sent to a webhook
+type WebhookDelivery struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	WebhookId uint   `json:"webhook_id" gorm:"index"`
+	Event     string `json:"event"`
+	Payload   string `json:"payload" gorm:"type:text"`
+	Status    string `json:"status" gorm:"index"`
+	Attempts  int    `json:"attempts"`
+	// StatusCode is the http status of the last attempt, 0 if no response was received
+	StatusCode int       `json:"status_code"`
+	Error      string    `json:"error" gorm:"type:text"`
+	CreatedAt  time.Time `json:"created_at" gorm:"index"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/setting"
 	"github.com/OpenListTeam/OpenList/v4/internal/task"
 	"github.com/OpenListTeam/OpenList/v4/internal/task_group"
+	"github.com/OpenListTeam/OpenList/v4/internal/webhook"
 	"github.com/OpenListTeam/tache"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
@@ -211,6 +212,14 @@ func (t *DownloadTask) Transfer() error {
 	return transferStd(t.Ctx(), t.TempDir, t.DstDirPath, t.DeletePolicy)
 }
 
+func (t *DownloadTask) OnSucceeded() {
+	webhook.Emit(t.Ctx(), &webhook.Event{Event: model.EventOfflineDownloadSucceeded, Path: t.DstDirPath, URL: t.Url, Size: t.GetTotalBytes()})
+}
+
+func (t *DownloadTask) OnFailed() {
+	webhook.Emit(t.Ctx(), &webhook.Event{Event: model.EventOfflineDownloadFailed, Path: t.DstDirPath, URL: t.Url, Error: t.GetErr().Error()})
+}
+
 func (t *DownloadTask) GetName() string {
 	return fmt.Sprintf("download %s to (%s)", t.Url, t.DstDirPath)
 }
@@ -16,6 +16,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/stream"
 	"github.com/OpenListTeam/OpenList/v4/internal/task"
 	"github.com/OpenListTeam/OpenList/v4/internal/task_group"
+	"github.com/OpenListTeam/OpenList/v4/internal/webhook"
 	"github.com/OpenListTeam/OpenList/v4/pkg/http_range"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
@@ -107,6 +108,12 @@ func (t *TransferTask) OnFailed() {
 		}
 	}
 	task_group.TransferCoordinator.Done(context.WithoutCancel(t.Ctx()), t.groupID, false)
+	webhook.Emit(t.Ctx(), &webhook.Event{
+		Event: model.EventOfflineDownloadFailed,
+		Path:  stdpath.Join(t.DstStorageMp, t.DstActualPath),
+		URL:   t.Url,
+		Error: t.GetErr().Error(),
+	})
 }
 
 func (t *TransferTask) SetRetry(retry int, maxRetry int) {
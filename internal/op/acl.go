@@ -0,0 +1,203 @@
+package op
+
+import (
+	stdpath "path"
+	"sync"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	aclRules   []model.ACLRule
+	aclLoaded  bool
+	aclRulesMu sync.RWMutex
+)
+
+// getACLRules returns the cached rules, they are loaded on first use
+func getACLRules() ([]model.ACLRule, error) {
+	aclRulesMu.RLock()
+	if aclLoaded {
+		defer aclRulesMu.RUnlock()
+		return aclRules, nil
+	}
+	aclRulesMu.RUnlock()
+	aclRulesMu.Lock()
+	defer aclRulesMu.Unlock()
+	if !aclLoaded {
+		rules, err := db.GetACLRules()
+		if err != nil {
+			return nil, err
+		}
+		aclRules, aclLoaded = rules, true
+	}
+	return aclRules, nil
+}
+
+func clearACLRules() {
+	aclRulesMu.Lock()
+	defer aclRulesMu.Unlock()
+	aclRules, aclLoaded = nil, false
+}
+
+func GetACLRules() ([]model.ACLRule, error) {
+	return db.GetACLRules()
+}
+
+func GetACLRuleById(id uint) (*model.ACLRule, error) {
+	return db.GetACLRuleById(id)
+}
+
+func checkACLRule(r *model.ACLRule) error {
+	r.Path = utils.FixAndCleanPath(r.Path)
+	if _, err := stdpath.Match(r.Path, ""); err != nil {
+		return errors.Errorf("invalid path pattern: %s", r.Path)
+	}
+	switch r.SubjectType {
+	case model.ACLSubjectUser:
+		if _, err := db.GetUserById(r.SubjectId); err != nil {
+			return errors.WithMessagef(err, "user %d of the rule", r.SubjectId)
+		}
+	case model.ACLSubjectGroup:
+		if _, err := db.GetGroupById(r.SubjectId); err != nil {
+			return errors.WithMessagef(err, "group %d of the rule", r.SubjectId)
+		}
+	case model.ACLSubjectEveryone:
+		r.SubjectId = 0
+	default:
+		return errors.Errorf("unknown subject type: %s", r.SubjectType)
+	}
+	if len(r.Operations) == 0 {
+		return errors.New("the rule has no operations")
+	}
+	for _, o := range r.Operations {
+		if !model.ValidACLOperation(o) {
+			return errors.Errorf("unknown operation: %s", o)
+		}
+	}
+	if r.Effect != model.ACLAllow && r.Effect != model.ACLDeny {
+		return errors.Errorf("unknown effect: %s", r.Effect)
+	}
+	return nil
+}
+
+func CreateACLRule(r *model.ACLRule) error {
+	if err := checkACLRule(r); err != nil {
+		return err
+	}
+	defer clearACLRules()
+	return db.CreateACLRule(r)
+}
+
+func UpdateACLRule(r *model.ACLRule) error {
+	if _, err := db.GetACLRuleById(r.ID); err != nil {
+		return err
+	}
+	if err := checkACLRule(r); err != nil {
+		return err
+	}
+	defer clearACLRules()
+	return db.UpdateACLRule(r)
+}
+
+func DeleteACLRuleById(id uint) error {
+	defer clearACLRules()
+	return db.DeleteACLRuleById(id)
+}
+
+// deleteACLRulesBySubject drops the rules of a deleted user or group, its id may be reused
+func deleteACLRulesBySubject(subjectType string, subjectId uint) error {
+	defer clearACLRules()
+	return db.DeleteACLRulesBySubject(subjectType, subjectId)
+}
+
+// DecideACL returns the rule deciding the operation of the user on the mount path p, nil if no rule matches
+func DecideACL(user *model.User, operation, p string) (*model.ACLRule, error) {
+	rules, err := getACLRules()
+	if err != nil {
+		return nil, err
+	}
+	return model.DecideACL(rules, user, operation, p), nil
+}
+
+// ACLAllows reports whether the acl rules let the user do the operation on the mount path p.
+// Admins are not subject to the rules, rules that can't be loaded deny everything.
+func ACLAllows(user *model.User, operation, p string) bool {
+	if user == nil || user.IsAdmin() {
+		return true
+	}
+	rule, err := DecideACL(user, operation, p)
+	if err != nil {
+		log.Errorf("failed load acl rules: %+v", err)
+		return false
+	}
+	return rule == nil || rule.Effect == model.ACLAllow
+}
+
+// ACLAllowsAnonymous reports whether the acl rules for everyone let a client that isn't known
+// do the operation on the mount path p
+func ACLAllowsAnonymous(operation, p string) bool {
+	rule, err := DecideACL(nil, operation, p)
+	if err != nil {
+		log.Errorf("failed load acl rules: %+v", err)
+		return false
+	}
+	return rule == nil || rule.Effect == model.ACLAllow
+}
+
+// EffectivePermission is how an operation of a user on a path is decided
+type EffectivePermission struct {
+	Operation string `json:"operation"`
+	Allowed   bool   `json:"allowed"`
+	// ByPermission tells whether the user permissions allow the operation at all
+	ByPermission bool `json:"by_permission"`
+	// Rule is the acl rule deciding the operation, nil if none matches
+	Rule *model.ACLRule `json:"rule"`
+}
+
+func permitted(user *model.User, operation string) bool {
+	if user.IsAdmin() {
+		return true
+	}
+	switch operation {
+	case model.ACLRead:
+		return user.CanReadContent()
+	case model.ACLUpload:
+		return user.CanWriteContent()
+	case model.ACLRename:
+		return user.CanRename()
+	case model.ACLMove:
+		return user.CanMove()
+	case model.ACLCopy:
+		return user.CanCopy()
+	case model.ACLDelete:
+		return user.CanRemove()
+	case model.ACLShare:
+		return user.CanShare()
+	case model.ACLDecompress:
+		return user.CanDecompress()
+	}
+	return true
+}
+
+// GetEffectivePermissions explains every operation of the user on the mount path p
+func GetEffectivePermissions(user *model.User, p string) ([]EffectivePermission, error) {
+	p = utils.FixAndCleanPath(p)
+	res := make([]EffectivePermission, 0, len(model.ACLOperations))
+	for _, operation := range model.ACLOperations {
+		e := EffectivePermission{Operation: operation, ByPermission: permitted(user, operation)}
+		if !user.IsAdmin() {
+			rule, err := DecideACL(user, operation, p)
+			if err != nil {
+				return nil, err
+			}
+			e.Rule = rule
+		}
+		e.Allowed = e.ByPermission && (e.Rule == nil || e.Rule.Effect == model.ACLAllow)
+		res = append(res, e)
+	}
+	return res, nil
+}
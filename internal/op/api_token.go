@@ -0,0 +1,195 @@
+package op
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// apiTokenLastUsedInterval limits how often the last used time of a token is written
+const apiTokenLastUsedInterval = time.Minute
+
+// apiTokenCipher encrypts the secrets of the tokens with the api token key setting
+func apiTokenCipher() (cipher.AEAD, error) {
+	key, err := GetSettingItemByKey(conf.APITokenKey)
+	if err != nil || key.Value == "" {
+		return nil, errors.New("the api token key is not set")
+	}
+	sum := sha256.Sum256([]byte(key.Value))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	return gcm, errors.WithStack(err)
+}
+
+func hashAPITokenSecret(secret string) string {
+	sum := sha256.Sum256([]byte("api token:" + secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// APITokenSecret returns the secret of the token, it is the secret access key of the token on the S3 endpoint
+func APITokenSecret(t *model.APIToken) (string, error) {
+	gcm, err := apiTokenCipher()
+	if err != nil {
+		return "", err
+	}
+	data, err := hex.DecodeString(t.SecretEnc)
+	if err != nil || len(data) < gcm.NonceSize() {
+		return "", errors.New("invalid api token secret")
+	}
+	secret, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], []byte(t.KeyId))
+	if err != nil {
+		return "", errors.New("invalid api token secret")
+	}
+	return string(secret), nil
+}
+
+// setAPITokenSecret gives the token a random secret and returns it
+func setAPITokenSecret(t *model.APIToken) (string, error) {
+	gcm, err := apiTokenCipher()
+	if err != nil {
+		return "", err
+	}
+	secret := random.String(40)
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", errors.WithStack(err)
+	}
+	t.SecretHash = hashAPITokenSecret(secret)
+	t.SecretEnc = hex.EncodeToString(gcm.Seal(nonce, nonce, []byte(secret), []byte(t.KeyId)))
+	return secret, nil
+}
+
+// CreateAPIToken creates the token and returns the raw token and its secret, which are never shown again
+func CreateAPIToken(t *model.APIToken) (string, string, error) {
+	if t.Name == "" {
+		return "", "", errors.New("token name is required")
+	}
+	if t.Access == "" {
+		t.Access = model.TokenAccessReadWrite
+	}
+	if !model.ValidTokenAccess(t.Access) {
+		return "", "", errors.Errorf("unknown token access: %s", t.Access)
+	}
+	if t.ExpiresAt != nil && !t.ExpiresAt.After(time.Now()) {
+		return "", "", errors.New("token expiry must be in the future")
+	}
+	if strings.Contains(t.Scope, "..") {
+		return "", "", errors.New("token scope must not be relative")
+	}
+	t.Scope = utils.FixAndCleanPath(t.Scope)
+	// upper case like the access key ids of S3
+	keyId := strings.ToUpper(random.String(20))
+	t.KeyId = keyId
+	secret, err := setAPITokenSecret(t)
+	if err != nil {
+		return "", "", err
+	}
+	t.Created = time.Now()
+	t.LastUsed = nil
+	t.Revoked = false
+	if err := db.CreateAPIToken(t); err != nil {
+		return "", "", err
+	}
+	return model.APITokenPrefix + keyId + "_" + secret, secret, nil
+}
+
+func GetAPITokensByUserId(userId uint, pageIndex, pageSize int) ([]model.APIToken, int64, error) {
+	return db.GetAPITokensByUserId(userId, pageIndex, pageSize)
+}
+
+func GetAPITokenById(id uint) (*model.APIToken, error) {
+	return db.GetAPITokenById(id)
+}
+
+func GetAPITokenByIdAndUserId(id, userId uint) (*model.APIToken, error) {
+	t, err := db.GetAPITokenById(id)
+	if err != nil {
+		return nil, err
+	}
+	if t.UserId != userId {
+		return nil, errors.New("failed get api token")
+	}
+	return t, nil
+}
+
+func RevokeAPIToken(t *model.APIToken) error {
+	t.Revoked = true
+	return db.UpdateAPIToken(t)
+}
+
+func DeleteAPITokenById(id uint) error {
+	return db.DeleteAPITokenById(id)
+}
+
+// GetAPITokenUser checks the raw token and returns its user, narrowed to the token
+func GetAPITokenUser(raw string) (*model.User, error) {
+	keyId, secret, ok := strings.Cut(strings.TrimPrefix(raw, model.APITokenPrefix), "_")
+	if !ok {
+		return nil, errors.New("invalid api token")
+	}
+	t, user, err := GetAPITokenByKeyId(keyId)
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(hashAPITokenSecret(secret)), []byte(t.SecretHash)) != 1 {
+		return nil, errors.New("invalid api token")
+	}
+	TouchAPIToken(t)
+	return user, nil
+}
+
+// GetAPITokenByKeyId returns the usable token with keyId and its user narrowed to it, the caller
+// checks the secret and then calls TouchAPIToken
+func GetAPITokenByKeyId(keyId string) (*model.APIToken, *model.User, error) {
+	t, err := db.GetAPITokenByKeyId(keyId)
+	if err != nil {
+		return nil, nil, errors.New("invalid api token")
+	}
+	if t.Revoked {
+		return nil, nil, errors.New("api token has been revoked")
+	}
+	if t.Expired() {
+		return nil, nil, errors.New("api token has expired")
+	}
+	if t.SecretHash == "" {
+		return nil, nil, errors.New("api token has no secret, create a new one")
+	}
+	user, err := GetUserById(t.UserId)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user.Disabled {
+		return nil, nil, errors.New("the user of the api token is disabled")
+	}
+	scoped, err := t.ScopeUser(user)
+	if err != nil {
+		return nil, nil, err
+	}
+	return t, scoped, nil
+}
+
+// TouchAPIToken records that the token has been used, at most once in apiTokenLastUsedInterval
+func TouchAPIToken(t *model.APIToken) {
+	now := time.Now()
+	if t.LastUsed == nil || now.Sub(*t.LastUsed) > apiTokenLastUsedInterval {
+		if err := db.UpdateAPITokenLastUsed(t.ID, now); err != nil {
+			log.Warnf("failed update last used time of api token %d: %+v", t.ID, err)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package op_test
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+func TestAPITokenSecret(t *testing.T) {
+	if err := op.SaveSettingItem(&model.SettingItem{Key: conf.APITokenKey, Value: "key", Type: conf.TypeString, Group: model.SINGLE}); err != nil {
+		t.Fatalf("failed save api token key: %+v", err)
+	}
+	user := &model.User{Username: "token_user", Role: model.GENERAL, BasePath: "/"}
+	if err := op.CreateUser(user); err != nil {
+		t.Fatalf("failed create user: %+v", err)
+	}
+	token := &model.APIToken{UserId: user.ID, Name: "ci", Permission: -1}
+	raw, secret, err := op.CreateAPIToken(token)
+	if err != nil {
+		t.Fatalf("failed create api token: %+v", err)
+	}
+	if got, err := op.GetAPITokenUser(raw); err != nil || got.APITokenId != token.ID {
+		t.Errorf("the raw token is refused: %v", err)
+	}
+	if _, err := op.GetAPITokenUser(raw + "x"); err == nil {
+		t.Error("a wrong secret is accepted")
+	}
+	stored, _, err := op.GetAPITokenByKeyId(token.KeyId)
+	if err != nil {
+		t.Fatalf("failed get api token: %+v", err)
+	}
+	if stored.LastUsed == nil {
+		t.Error("the use of the token is not recorded")
+	}
+	if got, err := op.APITokenSecret(stored); err != nil || got != secret {
+		t.Errorf("APITokenSecret = %q, %v, want the secret of the token", got, err)
+	}
+}
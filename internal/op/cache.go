@@ -12,8 +12,8 @@ import (
 )
 
 type CacheManager struct {
-	dirCache     *cache.KeyedCache[*directoryCache]       // Cache for directory listings
-	linkCache    *cache.TypedCache[*objWithLink]          // Cache for file links
+	dirCache     *dirCacheStore                           // Cache for directory listings
+	linkCache    *linkCacheStore                          // Cache for file links
 	userCache    *cache.KeyedCache[*model.User]           // Cache for user data
 	settingCache *cache.KeyedCache[any]                   // Cache for settings
 	detailCache  *cache.KeyedCache[*model.StorageDetails] // Cache for storage details
@@ -21,8 +21,8 @@ type CacheManager struct {
 
 func NewCacheManager() *CacheManager {
 	return &CacheManager{
-		dirCache:     cache.NewKeyedCache[*directoryCache](time.Minute * 5),
-		linkCache:    cache.NewTypedCache[*objWithLink](time.Minute * 30),
+		dirCache:     newDirCacheStore(time.Minute * 5),
+		linkCache:    newLinkCacheStore(time.Minute * 30),
 		userCache:    cache.NewKeyedCache[*model.User](time.Hour),
 		settingCache: cache.NewKeyedCache[any](time.Hour),
 		detailCache:  cache.NewKeyedCache[*model.StorageDetails](time.Minute * 30),
@@ -98,6 +98,11 @@ func (cm *CacheManager) DeleteUser(username string) {
 	cm.userCache.Delete(username)
 }
 
+// remove all users from cache
+func (cm *CacheManager) ClearUsers() {
+	cm.userCache.Clear()
+}
+
 // caches setting
 func (cm *CacheManager) SetSetting(key string, setting *model.SettingItem) {
 	cm.settingCache.Set(key, setting)
@@ -159,6 +164,8 @@ type directoryCache struct {
 	mu     sync.RWMutex
 
 	dirtyFlags uint8
+	// onChange is called after the objects are changed in place
+	onChange func()
 }
 
 const (
@@ -175,7 +182,14 @@ func newDirectoryCache(objs []model.Obj) *directoryCache {
 	}
 }
 
+func (dc *directoryCache) changed() {
+	if dc.onChange != nil {
+		dc.onChange()
+	}
+}
+
 func (dc *directoryCache) RemoveObject(name string) {
+	defer dc.changed()
 	dc.mu.Lock()
 	defer dc.mu.Unlock()
 	for i, obj := range dc.objs {
@@ -188,6 +202,7 @@ func (dc *directoryCache) RemoveObject(name string) {
 }
 
 func (dc *directoryCache) UpdateObject(oldName string, newObj model.Obj) {
+	defer dc.changed()
 	dc.mu.Lock()
 	defer dc.mu.Unlock()
 	if oldName != "" {
@@ -0,0 +1,355 @@
+package op
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/cache"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	dirCacheBucket  = "dir"
+	linkCacheBucket = "link"
+)
+
+// SetBackend persists directory listings and links to the backend,
+// the in-memory caches are still used as the first level.
+// Invalidations published by other instances drop the in-memory entries.
+func (cm *CacheManager) SetBackend(backend cache.Backend) {
+	cm.dirCache.backend = backend
+	cm.linkCache.backend = backend
+	if backend == nil {
+		return
+	}
+	backend.Subscribe(func(bucket, key string) {
+		log.Debugf("cache invalidated by other instance: %s %s", bucket, key)
+		switch {
+		case bucket == dirCacheBucket && key != "":
+			cm.dirCache.mem.Delete(key)
+		case bucket == linkCacheBucket && key != "":
+			cm.linkCache.mem.DeleteKey(key)
+		case bucket == dirCacheBucket:
+			cm.dirCache.mem.Clear()
+		case bucket == linkCacheBucket:
+			cm.linkCache.mem.Clear()
+		case bucket == "":
+			cm.dirCache.mem.Clear()
+			cm.linkCache.mem.Clear()
+		}
+	})
+}
+
+// cachedObj is the persisted form of the objects defined in the model package.
+// Objects of driver specific types can't be restored, so listings containing them are never persisted.
+type cachedObj struct {
+	Type      uint8         `json:"t"`
+	WrapName  string        `json:"w,omitempty"`
+	ID        string        `json:"i,omitempty"`
+	Path      string        `json:"p,omitempty"`
+	Name      string        `json:"n"`
+	Size      int64         `json:"s"`
+	Modified  time.Time     `json:"m"`
+	Ctime     time.Time     `json:"c"`
+	IsFolder  bool          `json:"d,omitempty"`
+	Hash      string        `json:"h,omitempty"`
+	Mask      model.ObjMask `json:"k,omitempty"`
+	Thumbnail string        `json:"th,omitempty"`
+	Url       string        `json:"u,omitempty"`
+	Provider  string        `json:"pr,omitempty"`
+}
+
+const (
+	cachedObject uint8 = iota
+	cachedObjThumb
+	cachedObjectURL
+	cachedObjThumbURL
+	cachedObjectProvider
+)
+
+func toCachedObj(obj model.Obj) (cachedObj, bool) {
+	var co cachedObj
+	if w, ok := obj.(*model.ObjWrapName); ok {
+		co.WrapName = w.Name
+		obj = w.Obj
+	}
+	var o *model.Object
+	switch v := obj.(type) {
+	case *model.Object:
+		co.Type, o = cachedObject, v
+	case *model.ObjThumb:
+		co.Type, o, co.Thumbnail = cachedObjThumb, &v.Object, v.Thumbnail.Thumbnail
+	case *model.ObjectURL:
+		co.Type, o, co.Url = cachedObjectURL, &v.Object, v.Url.Url
+	case *model.ObjThumbURL:
+		co.Type, o, co.Thumbnail, co.Url = cachedObjThumbURL, &v.Object, v.Thumbnail.Thumbnail, v.Url.Url
+	case *model.ObjectProvider:
+		co.Type, o, co.Provider = cachedObjectProvider, &v.Object, v.Provider.Provider
+	default:
+		return co, false
+	}
+	co.ID, co.Path, co.Name = o.ID, o.Path, o.Name
+	co.Size, co.Modified, co.Ctime, co.IsFolder = o.Size, o.Modified, o.Ctime, o.IsFolder
+	co.Hash, co.Mask = o.HashInfo.String(), o.Mask
+	return co, true
+}
+
+func (co *cachedObj) toObj() model.Obj {
+	o := model.Object{
+		ID:       co.ID,
+		Path:     co.Path,
+		Name:     co.Name,
+		Size:     co.Size,
+		Modified: co.Modified,
+		Ctime:    co.Ctime,
+		IsFolder: co.IsFolder,
+		HashInfo: utils.FromString(co.Hash),
+		Mask:     co.Mask,
+	}
+	var obj model.Obj
+	switch co.Type {
+	case cachedObjThumb:
+		obj = &model.ObjThumb{Object: o, Thumbnail: model.Thumbnail{Thumbnail: co.Thumbnail}}
+	case cachedObjectURL:
+		obj = &model.ObjectURL{Object: o, Url: model.Url{Url: co.Url}}
+	case cachedObjThumbURL:
+		obj = &model.ObjThumbURL{Object: o, Thumbnail: model.Thumbnail{Thumbnail: co.Thumbnail}, Url: model.Url{Url: co.Url}}
+	case cachedObjectProvider:
+		obj = &model.ObjectProvider{Object: o, Provider: model.Provider{Provider: co.Provider}}
+	default:
+		obj = &o
+	}
+	if co.WrapName != "" {
+		return &model.ObjWrapName{Name: co.WrapName, Obj: obj}
+	}
+	return obj
+}
+
+func logBackendErr(action, key string, err error) {
+	if err != nil && !errors.Is(err, cache.ErrNotFound) {
+		log.Warnf("failed to %s persistent cache of %s: %+v", action, key, err)
+	}
+}
+
+type persistedDir struct {
+	Expire time.Time   `json:"e"`
+	Objs   []cachedObj `json:"o"`
+}
+
+// dirCacheStore is the directory cache, backed by an optional persistent backend
+type dirCacheStore struct {
+	mem     *cache.KeyedCache[*directoryCache]
+	backend cache.Backend
+}
+
+func newDirCacheStore(ttl time.Duration) *dirCacheStore {
+	return &dirCacheStore{mem: cache.NewKeyedCache[*directoryCache](ttl)}
+}
+
+func (s *dirCacheStore) Get(key string) (*directoryCache, bool) {
+	if dc, ok := s.mem.Get(key); ok || s.backend == nil {
+		return dc, ok
+	}
+	data, err := s.backend.Get(dirCacheBucket, key)
+	if err != nil {
+		logBackendErr("get", key, err)
+		return nil, false
+	}
+	var pd persistedDir
+	if err = utils.Json.Unmarshal(data, &pd); err != nil {
+		logBackendErr("decode", key, err)
+		return nil, false
+	}
+	if time.Now().After(pd.Expire) {
+		return nil, false
+	}
+	objs := make([]model.Obj, len(pd.Objs))
+	for i := range pd.Objs {
+		objs[i] = pd.Objs[i].toObj()
+	}
+	dc := newDirectoryCache(objs)
+	dc.onChange = func() { s.invalidate(key) }
+	s.mem.SetWithExpirable(key, dc, cache.ExpirationTime(pd.Expire))
+	return dc, true
+}
+
+func (s *dirCacheStore) SetWithTTL(key string, dc *directoryCache, ttl time.Duration) {
+	dc.onChange = func() { s.invalidate(key) }
+	s.mem.SetWithTTL(key, dc, ttl)
+	if s.backend == nil {
+		return
+	}
+	pd := persistedDir{Expire: time.Now().Add(ttl), Objs: make([]cachedObj, 0, len(dc.objs))}
+	for _, obj := range dc.objs {
+		co, ok := toCachedObj(obj)
+		if !ok {
+			s.invalidate(key)
+			return
+		}
+		pd.Objs = append(pd.Objs, co)
+	}
+	data, err := utils.Json.Marshal(pd)
+	if err == nil {
+		err = s.backend.Set(dirCacheBucket, key, data, ttl)
+	}
+	logBackendErr("set", key, err)
+	// the other instances should load the refreshed listing
+	logBackendErr("publish", key, s.backend.Publish(dirCacheBucket, key))
+}
+
+// invalidate drops the persisted listing, the in-memory one is kept
+func (s *dirCacheStore) invalidate(key string) {
+	if s.backend == nil {
+		return
+	}
+	logBackendErr("delete", key, s.backend.Delete(dirCacheBucket, key))
+	logBackendErr("publish", key, s.backend.Publish(dirCacheBucket, key))
+}
+
+func (s *dirCacheStore) Delete(key string) {
+	s.mem.Delete(key)
+	s.invalidate(key)
+}
+
+func (s *dirCacheStore) Pop(key string) (*directoryCache, bool) {
+	dc, ok := s.Get(key)
+	if ok {
+		s.Delete(key)
+	}
+	return dc, ok
+}
+
+func (s *dirCacheStore) Clear() {
+	s.mem.Clear()
+	if s.backend == nil {
+		return
+	}
+	logBackendErr("clear", dirCacheBucket, s.backend.Clear(dirCacheBucket))
+	logBackendErr("publish", dirCacheBucket, s.backend.Publish(dirCacheBucket, ""))
+}
+
+type persistedLink struct {
+	Expire        time.Time   `json:"e"`
+	URL           string      `json:"u"`
+	Header        http.Header `json:"h,omitempty"`
+	Concurrency   int         `json:"c,omitempty"`
+	PartSize      int         `json:"p,omitempty"`
+	ContentLength int64       `json:"l,omitempty"`
+	Obj           cachedObj   `json:"o"`
+}
+
+// linkCacheStore is the link cache, backed by an optional persistent backend.
+// Only plain url links with an expiration are persisted.
+type linkCacheStore struct {
+	mem     *cache.TypedCache[*objWithLink]
+	backend cache.Backend
+}
+
+func newLinkCacheStore(ttl time.Duration) *linkCacheStore {
+	return &linkCacheStore{mem: cache.NewTypedCache[*objWithLink](ttl)}
+}
+
+func (s *linkCacheStore) load(key string) map[string]persistedLink {
+	data, err := s.backend.Get(linkCacheBucket, key)
+	if err != nil {
+		logBackendErr("get", key, err)
+		return nil
+	}
+	var links map[string]persistedLink
+	if err = utils.Json.Unmarshal(data, &links); err != nil {
+		logBackendErr("decode", key, err)
+		return nil
+	}
+	return links
+}
+
+func (s *linkCacheStore) GetType(key, typeKey string) (*objWithLink, bool) {
+	if ol, ok := s.mem.GetType(key, typeKey); ok || s.backend == nil {
+		return ol, ok
+	}
+	pl, ok := s.load(key)[typeKey]
+	if !ok {
+		return nil, false
+	}
+	expiration := time.Until(pl.Expire)
+	if expiration <= 0 {
+		return nil, false
+	}
+	ol := &objWithLink{
+		link: &model.Link{
+			URL:           pl.URL,
+			Header:        pl.Header,
+			Expiration:    &expiration,
+			Concurrency:   pl.Concurrency,
+			PartSize:      pl.PartSize,
+			ContentLength: pl.ContentLength,
+		},
+		obj: pl.Obj.toObj(),
+	}
+	s.mem.SetTypeWithExpirable(key, typeKey, ol, cache.ExpirationTime(pl.Expire))
+	return ol, true
+}
+
+func (s *linkCacheStore) SetTypeWithTTL(key, typeKey string, ol *objWithLink, ttl time.Duration) {
+	s.mem.SetTypeWithTTL(key, typeKey, ol, ttl)
+	if s.backend == nil || ol.link.URL == "" || ol.link.RangeReader != nil || ol.link.RequireReference {
+		return
+	}
+	co, ok := toCachedObj(ol.obj)
+	if !ok {
+		return
+	}
+	now := time.Now()
+	links := s.load(key)
+	if links == nil {
+		links = make(map[string]persistedLink)
+	}
+	expire := now.Add(ttl)
+	links[typeKey] = persistedLink{
+		Expire:        expire,
+		URL:           ol.link.URL,
+		Header:        ol.link.Header,
+		Concurrency:   ol.link.Concurrency,
+		PartSize:      ol.link.PartSize,
+		ContentLength: ol.link.ContentLength,
+		Obj:           co,
+	}
+	for k, l := range links {
+		if now.After(l.Expire) {
+			delete(links, k)
+		} else if l.Expire.After(expire) {
+			expire = l.Expire
+		}
+	}
+	data, err := utils.Json.Marshal(links)
+	if err == nil {
+		err = s.backend.Set(linkCacheBucket, key, data, expire.Sub(now))
+	}
+	logBackendErr("set", key, err)
+}
+
+// SetTypeWithExpirable caches links which are bound to the process, they are never persisted
+func (s *linkCacheStore) SetTypeWithExpirable(key, typeKey string, ol *objWithLink, exp cache.Expirable) {
+	s.mem.SetTypeWithExpirable(key, typeKey, ol, exp)
+}
+
+func (s *linkCacheStore) DeleteKey(key string) {
+	s.mem.DeleteKey(key)
+	if s.backend == nil {
+		return
+	}
+	logBackendErr("delete", key, s.backend.Delete(linkCacheBucket, key))
+	logBackendErr("publish", key, s.backend.Publish(linkCacheBucket, key))
+}
+
+func (s *linkCacheStore) Clear() {
+	s.mem.Clear()
+	if s.backend == nil {
+		return
+	}
+	logBackendErr("clear", linkCacheBucket, s.backend.Clear(linkCacheBucket))
+	logBackendErr("publish", linkCacheBucket, s.backend.Publish(linkCacheBucket, ""))
+}
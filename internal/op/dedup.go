@@ -0,0 +1,42 @@
+package op
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+func GetDuplicateScanById(id uint) (*model.DuplicateScan, error) {
+	return db.GetDuplicateScanById(id)
+}
+
+func GetDuplicateScans(pageIndex, pageSize int) ([]model.DuplicateScan, int64, error) {
+	return db.GetDuplicateScans(pageIndex, pageSize)
+}
+
+func CreateDuplicateScan(s *model.DuplicateScan) error {
+	return db.CreateDuplicateScan(s)
+}
+
+func UpdateDuplicateScan(s *model.DuplicateScan) error {
+	return db.UpdateDuplicateScan(s)
+}
+
+func DeleteDuplicateScanById(id uint) error {
+	return db.DeleteDuplicateScanById(id)
+}
+
+func GetDuplicateSetById(id uint) (*model.DuplicateSet, error) {
+	return db.GetDuplicateSetById(id)
+}
+
+func GetDuplicateSetsByScanId(scanId uint, pageIndex, pageSize int) ([]model.DuplicateSet, int64, error) {
+	return db.GetDuplicateSetsByScanId(scanId, pageIndex, pageSize)
+}
+
+func CreateDuplicateSets(sets []model.DuplicateSet) error {
+	return db.CreateDuplicateSets(sets)
+}
+
+func UpdateDuplicateSet(s *model.DuplicateSet) error {
+	return db.UpdateDuplicateSet(s)
+}
@@ -545,6 +545,7 @@ func Copy(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 	dstKey := Key(storage, dstDirPath)
 	if !srcRawObj.IsDir() {
 		Cache.linkCache.DeleteKey(stdpath.Join(dstKey, srcRawObj.GetName()))
+		addUsage(ctx, utils.GetFullPath(storage.GetStorage().MountPath, stdpath.Join(dstDirPath, srcRawObj.GetName())), srcRawObj.GetSize(), 1)
 	}
 	if !storage.Config().NoCache {
 		if cache, exist := Cache.dirCache.Get(dstKey); exist {
@@ -595,6 +596,10 @@ func Remove(ctx context.Context, storage driver.Driver, path string) error {
 		err = s.Remove(ctx, model.UnwrapObjName(rawObj))
 		if err == nil {
 			Cache.removeDirectoryObject(storage, dirPath, rawObj)
+			// the content of removed dirs is only released by a rescan
+			if !rawObj.IsDir() {
+				addUsage(ctx, utils.GetFullPath(storage.GetStorage().MountPath, path), -rawObj.GetSize(), -1)
+			}
 		}
 	default:
 		return errs.NotImplement
@@ -602,6 +607,37 @@ func Remove(ctx context.Context, storage driver.Driver, path string) error {
 	return errors.WithStack(err)
 }
 
+// HardLink replaces the file at dstPath with a hard link to the file at srcPath, both on the storage
+func HardLink(ctx context.Context, storage driver.Driver, srcPath, dstPath string) error {
+	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
+		return errors.WithMessagef(errs.StorageNotInit, "storage status: %s", storage.GetStorage().Status)
+	}
+	s, ok := storage.(driver.HardLink)
+	if !ok {
+		return errs.NotImplement
+	}
+	srcPath, dstPath = utils.FixAndCleanPath(srcPath), utils.FixAndCleanPath(dstPath)
+	srcObj, err := Get(ctx, storage, srcPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed to get src object")
+	}
+	dstObj, err := Get(ctx, storage, dstPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed to get dst object")
+	}
+	if srcObj.IsDir() || dstObj.IsDir() {
+		return errors.WithStack(errs.NotFile)
+	}
+	if model.ObjHasMask(dstObj, model.NoRemove) {
+		return errors.WithStack(errs.PermissionDenied)
+	}
+	err = s.HardLink(ctx, model.UnwrapObjName(srcObj), model.UnwrapObjName(dstObj))
+	if err == nil {
+		Cache.DeleteDirectory(storage, stdpath.Dir(dstPath))
+	}
+	return errors.WithStack(err)
+}
+
 func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file model.FileStreamer, up driver.UpdateProgress) error {
 	defer func() {
 		if err := file.Close(); err != nil {
@@ -623,6 +659,17 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 	tempName := file.GetName() + ".openlist_to_delete"
 	tempPath := stdpath.Join(dstDirPath, tempName)
 	fi, err := GetUnwrap(ctx, storage, dstPath)
+	fullPath := utils.GetFullPath(storage.GetStorage().MountPath, dstPath)
+	// streams of unknown size are only checked for the file count
+	wantBytes, wantFiles := max(file.GetSize(), 0), int64(1)
+	if err == nil {
+		wantBytes, wantFiles = wantBytes-fi.GetSize(), 0
+	}
+	if qErr := CheckQuota(ctx, fullPath, wantBytes, wantFiles); qErr != nil {
+		return qErr
+	}
+	// an overwritten file is released here, the others are released when they are removed below
+	overwrite := false
 	if err == nil {
 		if fi.GetSize() == 0 {
 			err = Remove(ctx, storage, dstPath)
@@ -637,6 +684,7 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 			}
 		} else {
 			file.SetExist(fi)
+			overwrite = true
 		}
 	}
 	err = MakeDir(ctx, storage, dstDirPath)
@@ -661,6 +709,10 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 		log.Warnf("file size < 0, try to get full size from cache")
 		file.CacheFullAndWriter(nil, nil)
 	}
+	addBytes, addFiles := file.GetSize(), int64(1)
+	if overwrite {
+		addBytes, addFiles = addBytes-fi.GetSize(), 0
+	}
 
 	var newObj model.Obj
 	switch s := storage.(type) {
@@ -672,6 +724,7 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 		return errs.NotImplement
 	}
 	if err == nil {
+		addUsage(ctx, fullPath, addBytes, addFiles)
 		Cache.linkCache.DeleteKey(Key(storage, dstPath))
 		if !storage.Config().NoCache {
 			if cache, exist := Cache.dirCache.Get(Key(storage, dstDirPath)); exist {
@@ -719,6 +772,11 @@ func PutURL(ctx context.Context, storage driver.Driver, dstDirPath, dstName, url
 	if _, err := Get(ctx, storage, dstPath); err == nil {
 		return errors.WithStack(errs.ObjectAlreadyExists)
 	}
+	// the size is not known before the storage fetched the url, a rescan counts it
+	fullPath := utils.GetFullPath(storage.GetStorage().MountPath, dstPath)
+	if err := CheckQuota(ctx, fullPath, 0, 1); err != nil {
+		return err
+	}
 	err := MakeDir(ctx, storage, dstDirPath)
 	if err != nil {
 		return errors.WithMessagef(err, "failed to make dir [%s]", dstDirPath)
@@ -740,6 +798,7 @@ func PutURL(ctx context.Context, storage driver.Driver, dstDirPath, dstName, url
 		return errors.WithStack(errs.NotImplement)
 	}
 	if err == nil {
+		addUsage(ctx, fullPath, 0, 1)
 		Cache.linkCache.DeleteKey(Key(storage, dstPath))
 		if !storage.Config().NoCache {
 			if cache, exist := Cache.dirCache.Get(Key(storage, dstDirPath)); exist {
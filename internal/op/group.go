@@ -0,0 +1,116 @@
+package op
+
+import (
+	"slices"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+func GetGroups(pageIndex, pageSize int) ([]model.Group, int64, error) {
+	return db.GetGroups(pageIndex, pageSize)
+}
+
+func GetGroupById(id uint) (*model.Group, error) {
+	return db.GetGroupById(id)
+}
+
+func CreateGroup(g *model.Group) error {
+	g.BasePath = utils.FixAndCleanPath(g.BasePath)
+	if err := db.CreateGroup(g); err != nil {
+		return err
+	}
+	clearGroupUsers()
+	return nil
+}
+
+func UpdateGroup(g *model.Group) error {
+	if _, err := db.GetGroupById(g.ID); err != nil {
+		return err
+	}
+	g.BasePath = utils.FixAndCleanPath(g.BasePath)
+	if err := db.UpdateGroup(g); err != nil {
+		return err
+	}
+	clearGroupUsers()
+	return nil
+}
+
+// DeleteGroupById deletes the group and removes it from its members
+func DeleteGroupById(id uint) error {
+	users, err := db.GetUsersWithGroups()
+	if err != nil {
+		return err
+	}
+	for i := range users {
+		u := &users[i]
+		if !slices.Contains(u.Groups, id) {
+			continue
+		}
+		u.Groups = slices.DeleteFunc(u.Groups, func(gid uint) bool { return gid == id })
+		if err := db.UpdateUser(u); err != nil {
+			return errors.WithMessagef(err, "failed remove group from user [%s]", u.Username)
+		}
+	}
+	if err := deleteACLRulesBySubject(model.ACLSubjectGroup, id); err != nil {
+		return errors.WithMessage(err, "failed delete acl rules of the group")
+	}
+	if err := db.DeleteGroupById(id); err != nil {
+		return err
+	}
+	clearGroupUsers()
+	return nil
+}
+
+// clearGroupUsers drops the cached users, their merged permissions may have changed
+func clearGroupUsers() {
+	Cache.ClearUsers()
+	guestUser = nil
+}
+
+// applyGroups merges the groups of u into it, missing groups are ignored
+func applyGroups(u *model.User) error {
+	groups, err := db.GetGroupsByIds(u.Groups)
+	if err != nil {
+		return err
+	}
+	u.ApplyGroups(groups)
+	return nil
+}
+
+// SyncExternalGroups makes the user a member of exactly the groups mapped from its LDAP groups
+// or OIDC claims. Groups without any external group are assigned by hand and left alone.
+func SyncExternalGroups(u *model.User, external []string) error {
+	groups, err := db.GetAllGroups()
+	if err != nil {
+		return err
+	}
+	newGroups := make([]uint, 0, len(u.Groups))
+	for _, id := range u.Groups {
+		idx := slices.IndexFunc(groups, func(g model.Group) bool { return g.ID == id })
+		if idx < 0 || len(groups[idx].ExternalGroups) == 0 {
+			newGroups = append(newGroups, id)
+		}
+	}
+	for _, g := range groups {
+		if g.MatchExternal(external) && !slices.Contains(newGroups, g.ID) {
+			newGroups = append(newGroups, g.ID)
+		}
+	}
+	slices.Sort(newGroups)
+	old := slices.Clone(u.Groups)
+	slices.Sort(old)
+	if slices.Equal(old, newGroups) {
+		return nil
+	}
+	log.Infof("groups of user [%s] mapped from %v: %v", u.Username, external, newGroups)
+	u.Groups = newGroups
+	if err := db.UpdateUser(u); err != nil {
+		return err
+	}
+	Cache.DeleteUser(u.Username)
+	return applyGroups(u)
+}
@@ -0,0 +1,115 @@
+package op
+
+import (
+	"context"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// QuotaInfo is the usage of a user against its quota, 0 quota is unlimited
+type QuotaInfo struct {
+	UsedBytes  int64 `json:"used_bytes"`
+	UsedFiles  int64 `json:"used_files"`
+	QuotaBytes int64 `json:"quota_bytes"`
+	QuotaFiles int64 `json:"quota_files"`
+	// RemainingBytes and RemainingFiles are -1 when unlimited
+	RemainingBytes int64      `json:"remaining_bytes"`
+	RemainingFiles int64      `json:"remaining_files"`
+	ScannedAt      *time.Time `json:"scanned_at"`
+}
+
+func remaining(quota, used int64) int64 {
+	if quota == 0 {
+		return -1
+	}
+	return max(quota-used, 0)
+}
+
+func GetQuotaInfo(user *model.User) (*QuotaInfo, error) {
+	usage, err := db.GetUserUsage(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	quotaBytes, quotaFiles := user.EffectiveQuota()
+	return &QuotaInfo{
+		UsedBytes:      usage.Bytes,
+		UsedFiles:      usage.Files,
+		QuotaBytes:     quotaBytes,
+		QuotaFiles:     quotaFiles,
+		RemainingBytes: remaining(quotaBytes, usage.Bytes),
+		RemainingFiles: remaining(quotaFiles, usage.Files),
+		ScannedAt:      usage.ScannedAt,
+	}, nil
+}
+
+// SetUsage replaces the usage of the user with the totals of a rescan
+func SetUsage(userId uint, bytes, files int64) error {
+	now := time.Now()
+	return db.SaveUserUsage(&model.UserUsage{UserId: userId, Bytes: bytes, Files: files, ScannedAt: &now})
+}
+
+// HasQuota reports whether the user of ctx is limited by a quota
+func HasQuota(ctx context.Context) bool {
+	user, _ := ctx.Value(conf.UserKey).(*model.User)
+	if user == nil {
+		return false
+	}
+	quotaBytes, quotaFiles := user.EffectiveQuota()
+	return quotaBytes > 0 || quotaFiles > 0
+}
+
+// quotaUser returns the user of ctx if the mount path p is under its base path, the objects
+// stored there count against its quota. Everything else, like the work of the system, is free.
+func quotaUser(ctx context.Context, p string) *model.User {
+	user, _ := ctx.Value(conf.UserKey).(*model.User)
+	if user == nil || !utils.IsSubPath(user.GetBasePath(), p) {
+		return nil
+	}
+	return user
+}
+
+// CheckQuota returns errs.QuotaExceeded if storing bytes and files more at the mount path p
+// would exceed the quota of the user of ctx
+func CheckQuota(ctx context.Context, p string, bytes, files int64) error {
+	user := quotaUser(ctx, p)
+	if user == nil {
+		return nil
+	}
+	quotaBytes, quotaFiles := user.EffectiveQuota()
+	if (quotaBytes == 0 || bytes <= 0) && (quotaFiles == 0 || files <= 0) {
+		return nil
+	}
+	usage, err := db.GetUserUsage(user.ID)
+	if err != nil {
+		return errors.WithMessage(err, "failed get usage")
+	}
+	if quotaBytes > 0 && bytes > 0 && usage.Bytes+bytes > quotaBytes {
+		return errors.WithMessagef(errs.QuotaExceeded, "%d of %d bytes used, %d more requested", usage.Bytes, quotaBytes, bytes)
+	}
+	if quotaFiles > 0 && files > 0 && usage.Files+files > quotaFiles {
+		return errors.WithMessagef(errs.QuotaExceeded, "%d of %d files used", usage.Files, quotaFiles)
+	}
+	return nil
+}
+
+// addUsage counts bytes and files stored at the mount path p for the user of ctx, negative
+// values release them. Failures are only logged, a rescan fixes the usage.
+func addUsage(ctx context.Context, p string, bytes, files int64) {
+	if bytes == 0 && files == 0 {
+		return
+	}
+	user := quotaUser(ctx, p)
+	if user == nil {
+		return
+	}
+	if err := db.AddUserUsage(user.ID, bytes, files); err != nil {
+		log.Errorf("failed update usage of user [%s]: %+v", user.Username, err)
+	}
+}
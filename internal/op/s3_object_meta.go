@@ -0,0 +1,52 @@
+package op
+
+import (
+	stdpath "path"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+func GetS3ObjectMeta(path string) (*model.S3ObjectMeta, error) {
+	return db.GetS3ObjectMeta(utils.FixAndCleanPath(path))
+}
+
+// GetS3ObjectMetas returns the metas of the objects in dir by their names
+func GetS3ObjectMetas(dir string) (map[string]*model.S3ObjectMeta, error) {
+	metas, err := db.GetS3ObjectMetasByParent(utils.FixAndCleanPath(dir))
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]*model.S3ObjectMeta, len(metas))
+	for i := range metas {
+		res[stdpath.Base(metas[i].Path)] = &metas[i]
+	}
+	return res, nil
+}
+
+func SaveS3ObjectMeta(m *model.S3ObjectMeta) error {
+	m.Path = utils.FixAndCleanPath(m.Path)
+	m.Parent = stdpath.Dir(m.Path)
+	return db.SaveS3ObjectMeta(m)
+}
+
+func MoveS3ObjectMetas(src, dst string) error {
+	src, dst = utils.FixAndCleanPath(src), utils.FixAndCleanPath(dst)
+	if src == dst {
+		return nil
+	}
+	return db.MoveS3ObjectMetas(src, dst)
+}
+
+func CopyS3ObjectMetas(src, dst string) error {
+	src, dst = utils.FixAndCleanPath(src), utils.FixAndCleanPath(dst)
+	if src == dst {
+		return nil
+	}
+	return db.CopyS3ObjectMetas(src, dst)
+}
+
+func DeleteS3ObjectMetas(path string) error {
+	return db.DeleteS3ObjectMetas(utils.FixAndCleanPath(path))
+}
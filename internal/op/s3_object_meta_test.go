@@ -0,0 +1,56 @@
+package op_test
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+func TestS3ObjectMetasFollowPaths(t *testing.T) {
+	save := func(path, etag string) {
+		t.Helper()
+		if err := op.SaveS3ObjectMeta(&model.S3ObjectMeta{Path: path, ETag: etag}); err != nil {
+			t.Fatalf("failed save meta of %s: %+v", path, err)
+		}
+	}
+	get := func(path string) string {
+		t.Helper()
+		m, err := op.GetS3ObjectMeta(path)
+		if err != nil {
+			return ""
+		}
+		return m.ETag
+	}
+	save("/s3/a_b/x.txt", "1")
+	save("/s3/axb/y.txt", "2")
+	save("/s3/a%b/z.txt", "3")
+
+	if err := op.CopyS3ObjectMetas("/s3/a_b", "/copy"); err != nil {
+		t.Fatalf("failed copy metas: %+v", err)
+	}
+	if v := get("/copy/x.txt"); v != "1" {
+		t.Errorf("copied x.txt has %q, want 1", v)
+	}
+	if err := op.MoveS3ObjectMetas("/s3/a_b", "/moved"); err != nil {
+		t.Fatalf("failed move metas: %+v", err)
+	}
+	if v := get("/moved/x.txt"); v != "1" {
+		t.Errorf("moved x.txt has %q, want 1", v)
+	}
+	if metas, err := op.GetS3ObjectMetas("/moved"); err != nil || metas["x.txt"] == nil {
+		t.Errorf("moved x.txt is not listed in its new parent: %v", err)
+	}
+	if v := get("/s3/axb/y.txt"); v != "2" {
+		t.Errorf("y.txt matched by the underscore has %q, want 2", v)
+	}
+	if err := op.DeleteS3ObjectMetas("/s3/a%b"); err != nil {
+		t.Fatalf("failed delete metas: %+v", err)
+	}
+	if v := get("/s3/a%b/z.txt"); v != "" {
+		t.Errorf("deleted z.txt still has %q", v)
+	}
+	if v := get("/s3/axb/y.txt"); v != "2" {
+		t.Errorf("y.txt matched by the percent sign has %q, want 2", v)
+	}
+}
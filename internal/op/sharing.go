@@ -4,8 +4,10 @@ import (
 	"fmt"
 	stdpath "path"
 	"strings"
+	"time"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/pkg/singleflight"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
@@ -135,9 +137,135 @@ func UpdateSharing(sharing *model.Sharing, skipMarshal ...bool) (err error) {
 
 func DeleteSharing(sid string) error {
 	sharingCache.Del(sid)
+	if err := db.DeleteSharingAccesses(sid); err != nil {
+		return err
+	}
+	if err := db.DeleteSharingRecipients(sid); err != nil {
+		return err
+	}
 	return db.DeleteSharingById(sid)
 }
 
+// CheckSharingDownload returns errs.SharingLimitExceeded if the file at path of the sharing, or at
+// inner in the archive at path, can't be downloaded anymore because of the bandwidth of the
+// sharing or the downloads of the file
+func CheckSharingDownload(sharing *model.Sharing, path, inner string) error {
+	if sharing.MaxBandwidth > 0 && sharing.BytesServed >= sharing.MaxBandwidth {
+		return errors.WithStack(errs.SharingLimitExceeded)
+	}
+	if sharing.MaxDownloadsPerFile > 0 {
+		count, err := db.CountSharingDownloads(sharing.ID, path, inner)
+		if err != nil {
+			return err
+		}
+		if count >= int64(sharing.MaxDownloadsPerFile) {
+			return errors.WithStack(errs.SharingLimitExceeded)
+		}
+	}
+	return nil
+}
+
+// RecordSharingAccess adds the access to the log of the sharing, the bytes of a download count
+// against its bandwidth
+func RecordSharingAccess(sharing *model.Sharing, access *model.SharingAccess) error {
+	access.SharingId = sharing.ID
+	if access.Time.IsZero() {
+		access.Time = time.Now()
+	}
+	if err := db.CreateSharingAccess(access); err != nil {
+		return err
+	}
+	if access.Kind == model.SharingAccessView || access.Bytes <= 0 {
+		return nil
+	}
+	sharingCache.Del(sharing.ID)
+	return db.AddSharingBytesServed(sharing.ID, access.Bytes)
+}
+
+// CountSharingAccess adds one to the accesses of the sharing
+func CountSharingAccess(sharing *model.Sharing) error {
+	sharingCache.Del(sharing.ID)
+	return db.AddSharingAccessed(sharing.ID)
+}
+
+// ReserveSharingUpload counts size bytes against the total bytes of the sharing,
+// false when it has no space left for them
+func ReserveSharingUpload(sharing *model.Sharing, size int64) (bool, error) {
+	sharingCache.Del(sharing.ID)
+	return db.AddSharingUploadedBytes(sharing.ID, size, true)
+}
+
+// ReleaseSharingUpload gives back the size bytes of an upload that failed
+func ReleaseSharingUpload(sharing *model.Sharing, size int64) error {
+	sharingCache.Del(sharing.ID)
+	_, err := db.AddSharingUploadedBytes(sharing.ID, -size, false)
+	return err
+}
+
+func GetSharingAccesses(sid, recipient string, pageIndex, pageSize int) ([]model.SharingAccess, int64, error) {
+	return db.GetSharingAccesses(sid, recipient, pageIndex, pageSize)
+}
+
+func GetSharingRecipients(sid string) ([]model.SharingRecipient, error) {
+	return db.GetSharingRecipients(sid)
+}
+
+func GetSharingRecipientById(id uint) (*model.SharingRecipient, error) {
+	return db.GetSharingRecipientById(id)
+}
+
+// GetSharingRecipientByEmail finds the recipient case-insensitively
+func GetSharingRecipientByEmail(sid, email string) (*model.SharingRecipient, error) {
+	return db.GetSharingRecipientByEmail(sid, strings.ToLower(strings.TrimSpace(email)))
+}
+
+func UpdateSharingRecipient(r *model.SharingRecipient) error {
+	return db.UpdateSharingRecipient(r)
+}
+
+func CountSharingRecipientCodeAttempt(id uint, maxAttempts int) (bool, error) {
+	return db.CountSharingRecipientCodeAttempt(id, maxAttempts)
+}
+
+func UseSharingRecipientCode(id uint, codeHash string) (bool, error) {
+	return db.UseSharingRecipientCode(id, codeHash)
+}
+
+// SetSharingRecipients makes the emails the recipients of the sharing, the recipients that stay
+// keep their revocation and those not in emails are deleted
+func SetSharingRecipients(sid string, emails []string) error {
+	recipients, err := db.GetSharingRecipients(sid)
+	if err != nil {
+		return err
+	}
+	keep := make(map[string]struct{}, len(emails))
+	for _, e := range emails {
+		keep[strings.ToLower(strings.TrimSpace(e))] = struct{}{}
+	}
+	for _, r := range recipients {
+		if _, ok := keep[r.Email]; ok {
+			delete(keep, r.Email)
+		} else if err := db.DeleteSharingRecipientById(r.ID); err != nil {
+			return err
+		}
+	}
+	for _, e := range emails {
+		e = strings.ToLower(strings.TrimSpace(e))
+		if _, ok := keep[e]; !ok {
+			continue
+		}
+		delete(keep, e)
+		if err := db.CreateSharingRecipient(&model.SharingRecipient{SharingId: sid, Email: e}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func GetSharingStats(sid string) (*model.SharingStats, error) {
+	return db.GetSharingStats(sid)
+}
+
 func DeleteSharingsByCreatorId(creatorId uint) error {
 	return db.DeleteSharingsByCreatorId(creatorId)
 }
@@ -0,0 +1,145 @@
+package op_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+func TestSharingAccessLimits(t *testing.T) {
+	s := &model.Sharing{SharingDB: &model.SharingDB{MaxBandwidth: 150, MaxDownloadsPerFile: 2}}
+	id, err := db.CreateSharing(s.SharingDB)
+	if err != nil {
+		t.Fatalf("failed create sharing: %+v", err)
+	}
+	accesses := []model.SharingAccess{
+		{IP: "1.1.1.1", Kind: model.SharingAccessView, Path: "/"},
+		{IP: "1.1.1.1", Kind: model.SharingAccessDownload, Path: "/a.zip", Bytes: 50},
+		{IP: "2.2.2.2", Kind: model.SharingAccessArchive, Path: "/a.zip", InnerPath: "/b.txt", Bytes: 10},
+		{IP: "2.2.2.2", Kind: model.SharingAccessDownload, Path: "/a.zip", Bytes: 50},
+	}
+	for i := range accesses {
+		if err := op.CheckSharingDownload(s, accesses[i].Path, accesses[i].InnerPath); err != nil {
+			t.Fatalf("access %d is refused: %+v", i, err)
+		}
+		if err := op.RecordSharingAccess(s, &accesses[i]); err != nil {
+			t.Fatalf("failed record access %d: %+v", i, err)
+		}
+	}
+	if err := op.CheckSharingDownload(s, "/a.zip", ""); !errors.Is(err, errs.SharingLimitExceeded) {
+		t.Errorf("the third download of a file is not refused: %v", err)
+	}
+	s.SharingDB, err = db.GetSharingById(id)
+	if err != nil {
+		t.Fatalf("failed get sharing: %+v", err)
+	}
+	if s.BytesServed != 110 {
+		t.Errorf("expected 110 bytes served, got %d", s.BytesServed)
+	}
+	if err := op.CheckSharingDownload(s, "/a.zip", "/b.txt"); err != nil {
+		t.Errorf("the second download of a file in an archive is refused: %+v", err)
+	}
+	s.BytesServed = 150
+	if err := op.CheckSharingDownload(s, "/c.txt", ""); !errors.Is(err, errs.SharingLimitExceeded) {
+		t.Errorf("a download beyond the bandwidth is not refused: %v", err)
+	}
+	stats, err := op.GetSharingStats(id)
+	if err != nil {
+		t.Fatalf("failed get stats: %+v", err)
+	}
+	if stats.Accesses != 4 || stats.UniqueVisitors != 2 || stats.Downloads != 3 || stats.BytesServed != 110 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if len(stats.Files) != 2 || stats.Files[0].Path != "/a.zip" || stats.Files[0].Downloads != 2 {
+		t.Errorf("unexpected file stats: %+v", stats.Files)
+	}
+}
+
+func TestReserveSharingUpload(t *testing.T) {
+	s := &model.Sharing{SharingDB: &model.SharingDB{MaxTotalBytes: 100}}
+	id, err := db.CreateSharing(s.SharingDB)
+	if err != nil {
+		t.Fatalf("failed create sharing: %+v", err)
+	}
+	for i, tt := range []struct {
+		size int64
+		want bool
+	}{{60, true}, {50, false}, {40, true}, {1, false}} {
+		ok, err := op.ReserveSharingUpload(s, tt.size)
+		if err != nil {
+			t.Fatalf("failed reserve %d: %+v", i, err)
+		}
+		if ok != tt.want {
+			t.Errorf("reserve %d of %d bytes = %v, want %v", i, tt.size, ok, tt.want)
+		}
+	}
+	if err := op.ReleaseSharingUpload(s, 60); err != nil {
+		t.Fatalf("failed release: %+v", err)
+	}
+	s.SharingDB, err = db.GetSharingById(id)
+	if err != nil {
+		t.Fatalf("failed get sharing: %+v", err)
+	}
+	if s.UploadedBytes != 40 {
+		t.Errorf("uploaded bytes = %d, want 40", s.UploadedBytes)
+	}
+}
+
+func TestUpdateSharingKeepsBytesServed(t *testing.T) {
+	s := &model.Sharing{SharingDB: &model.SharingDB{}, Creator: &model.User{}}
+	id, err := db.CreateSharing(s.SharingDB)
+	if err != nil {
+		t.Fatalf("failed create sharing: %+v", err)
+	}
+	stale := *s.SharingDB
+	if err := op.RecordSharingAccess(s, &model.SharingAccess{Kind: model.SharingAccessDownload, Path: "/a", Bytes: 30}); err != nil {
+		t.Fatalf("failed record access: %+v", err)
+	}
+	stale.Remark = "edited"
+	if err := db.UpdateSharing(&stale); err != nil {
+		t.Fatalf("failed update sharing: %+v", err)
+	}
+	got, err := db.GetSharingById(id)
+	if err != nil {
+		t.Fatalf("failed get sharing: %+v", err)
+	}
+	if got.BytesServed != 30 || got.Remark != "edited" {
+		t.Errorf("got bytes served %d and remark %q, want 30 and edited", got.BytesServed, got.Remark)
+	}
+}
+
+func TestSharingRecipientCodeAttempts(t *testing.T) {
+	r := &model.SharingRecipient{SharingId: "recipient", Email: "a@example.com", CodeHash: "hash", CodeSalt: "salt"}
+	if err := db.CreateSharingRecipient(r); err != nil {
+		t.Fatalf("failed create recipient: %+v", err)
+	}
+	t.Cleanup(func() { _ = db.DeleteSharingRecipientById(r.ID) })
+	for i := 0; i < 3; i++ {
+		if ok, err := op.CountSharingRecipientCodeAttempt(r.ID, 3); err != nil || !ok {
+			t.Fatalf("attempt %d is refused: %v, %+v", i, ok, err)
+		}
+	}
+	if ok, err := op.CountSharingRecipientCodeAttempt(r.ID, 3); err != nil || ok {
+		t.Errorf("an attempt beyond the limit is not refused: %v, %+v", ok, err)
+	}
+	if ok, err := op.UseSharingRecipientCode(r.ID, "hash"); err != nil || !ok {
+		t.Fatalf("failed use the code: %v, %+v", ok, err)
+	}
+	if ok, err := op.UseSharingRecipientCode(r.ID, "hash"); err != nil || ok {
+		t.Errorf("the code is used twice: %v, %+v", ok, err)
+	}
+	got, err := op.GetSharingRecipientById(r.ID)
+	if err != nil {
+		t.Fatalf("failed get recipient: %+v", err)
+	}
+	if got.CodeHash != "" || got.CodeAttempts != 0 {
+		t.Errorf("expected the code to be cleared, got %+v", got)
+	}
+	if ok, err := op.CountSharingRecipientCodeAttempt(r.ID, 3); err != nil || ok {
+		t.Errorf("an attempt without code is not refused: %v, %+v", ok, err)
+	}
+}
@@ -0,0 +1,87 @@
+package op
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/go-cache"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// signedLinkResumeWindow is how long a client may resume a counted download of a signed link
+const signedLinkResumeWindow = time.Hour
+
+// signedLinkDownloads records the clients a download of a signed link was counted for
+var signedLinkDownloads = cache.NewMemCache[struct{}]()
+
+// CreateSignedLink checks the constraints of the link and creates it, the expired links are deleted
+func CreateSignedLink(l *model.SignedLink) error {
+	if !l.Expires.After(time.Now()) {
+		return errors.New("link expiry must be in the future")
+	}
+	if l.MaxDownloads < 0 || l.RangeStart < 0 || l.RangeLength < 0 || l.RateLimit < 0 {
+		return errors.New("link limits must not be negative")
+	}
+	var ips []string
+	for _, s := range strings.Split(l.IPs, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(s); err != nil && net.ParseIP(s) == nil {
+			return errors.Errorf("invalid ip or cidr: %s", s)
+		}
+		ips = append(ips, s)
+	}
+	l.IPs = strings.Join(ips, ",")
+	l.Path = utils.FixAndCleanPath(l.Path)
+	l.Downloads = 0
+	if err := db.DeleteSignedLinksExpiredBefore(time.Now()); err != nil {
+		log.Warnf("failed delete expired signed links: %+v", err)
+	}
+	return db.CreateSignedLink(l)
+}
+
+func GetSignedLinksByUserId(userId uint, pageIndex, pageSize int) ([]model.SignedLink, int64, error) {
+	return db.GetSignedLinksByUserId(userId, pageIndex, pageSize)
+}
+
+func GetSignedLinkById(id uint) (*model.SignedLink, error) {
+	return db.GetSignedLinkById(id)
+}
+
+func GetSignedLinkByIdAndUserId(id, userId uint) (*model.SignedLink, error) {
+	l, err := db.GetSignedLinkById(id)
+	if err != nil {
+		return nil, err
+	}
+	if l.UserId != userId {
+		return nil, errors.New("failed get signed link")
+	}
+	return l, nil
+}
+
+// CountSignedLinkDownload counts a download of the link by the client at ip, false if it has no
+// download left. A resume is let through without counting when a download was counted for the
+// client in the resume window, else it counts as a new download.
+func CountSignedLinkDownload(l *model.SignedLink, ip string, resume bool) (bool, error) {
+	key := fmt.Sprintf("%d/%s", l.ID, ip)
+	if _, ok := signedLinkDownloads.Get(key); ok && resume {
+		return true, nil
+	}
+	ok, err := db.CountSignedLinkDownload(l.ID)
+	if err != nil || !ok {
+		return ok, err
+	}
+	signedLinkDownloads.Set(key, struct{}{}, cache.WithEx[struct{}](signedLinkResumeWindow))
+	return true, nil
+}
+
+func DeleteSignedLinkById(id uint) error {
+	return db.DeleteSignedLinkById(id)
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/driver"
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
 	"github.com/OpenListTeam/OpenList/v4/pkg/generic_sync"
 	"github.com/OpenListTeam/OpenList/v4/pkg/singleflight"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
@@ -49,6 +50,9 @@ func CreateStorage(ctx context.Context, storage model.Storage) (uint, error) {
 	storage.Modified = time.Now()
 	storage.MountPath = utils.FixAndCleanPath(storage.MountPath)
 	var err error
+	if err = checkIndexSchedule(storage.IndexSchedule); err != nil {
+		return 0, err
+	}
 	// check driver first
 	driverName := storage.Driver
 	driverNew, err := GetDriver(driverName)
@@ -227,6 +231,9 @@ func UpdateStorage(ctx context.Context, storage model.Storage) error {
 	if oldStorage.Driver != storage.Driver {
 		return errors.Errorf("driver cannot be changed")
 	}
+	if err = checkIndexSchedule(storage.IndexSchedule); err != nil {
+		return err
+	}
 	storage.Modified = time.Now()
 	storage.MountPath = utils.FixAndCleanPath(storage.MountPath)
 	err = db.UpdateStorage(&storage)
@@ -257,6 +264,19 @@ func UpdateStorage(ctx context.Context, storage model.Storage) error {
 	return err
 }
 
+// checkIndexSchedule validates the rate limit and the cron of the index schedule of a storage
+func checkIndexSchedule(s model.IndexSchedule) error {
+	if s.IndexRateLimit < 0 {
+		return errors.New("index rate limit must not be negative")
+	}
+	if s.IndexCron != "" {
+		if _, err := cron.ParseSchedule(s.IndexCron); err != nil {
+			return errors.WithMessage(err, "invalid index cron")
+		}
+	}
+	return nil
+}
+
 func DeleteStorageById(ctx context.Context, id uint) error {
 	storage, err := db.GetStorageById(id)
 	if err != nil {
@@ -282,6 +302,9 @@ func DeleteStorageById(ctx context.Context, id uint) error {
 	if err := db.DeleteStorageById(id); err != nil {
 		return errors.WithMessage(err, "failed delete storage in database")
 	}
+	if err := db.DeleteStorageIndex(id); err != nil {
+		log.Warnf("failed delete index state of storage %d: %+v", id, err)
+	}
 	return dropErr
 }
 
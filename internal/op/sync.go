@@ -0,0 +1,106 @@
+package op
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+func validateSyncJob(j *model.SyncJob) error {
+	j.SrcPath = utils.FixAndCleanPath(j.SrcPath)
+	j.DstPath = utils.FixAndCleanPath(j.DstPath)
+	if utils.IsSubPath(j.SrcPath, j.DstPath) || utils.IsSubPath(j.DstPath, j.SrcPath) {
+		return errors.New("src path and dst path must not contain each other")
+	}
+	switch j.Mode {
+	case "":
+		j.Mode = model.SyncModeUpdate
+	case model.SyncModeUpdate, model.SyncModeMirror, model.SyncModeTwoWay:
+	default:
+		return errors.Errorf("unknown sync mode: %s", j.Mode)
+	}
+	switch j.ConflictPolicy {
+	case "":
+		j.ConflictPolicy = model.SyncConflictNewer
+	case model.SyncConflictNewer, model.SyncConflictSrc, model.SyncConflictDst, model.SyncConflictSkip:
+	default:
+		return errors.Errorf("unknown conflict policy: %s", j.ConflictPolicy)
+	}
+	if j.Cron != "" {
+		if _, err := cron.ParseSchedule(j.Cron); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func GetSyncJobById(id uint) (*model.SyncJob, error) {
+	return db.GetSyncJobById(id)
+}
+
+func GetSyncJobs(pageIndex, pageSize int) ([]model.SyncJob, int64, error) {
+	return db.GetSyncJobs(pageIndex, pageSize)
+}
+
+func GetEnabledSyncJobs() ([]model.SyncJob, error) {
+	return db.GetEnabledSyncJobs()
+}
+
+func CreateSyncJob(j *model.SyncJob) error {
+	if err := validateSyncJob(j); err != nil {
+		return err
+	}
+	return db.CreateSyncJob(j)
+}
+
+func UpdateSyncJob(j *model.SyncJob) error {
+	if err := validateSyncJob(j); err != nil {
+		return err
+	}
+	old, err := db.GetSyncJobById(j.ID)
+	if err != nil {
+		return err
+	}
+	// the snapshot describes the old paths, the next run starts over
+	if old.SrcPath != j.SrcPath || old.DstPath != j.DstPath || old.Mode != j.Mode {
+		if err := db.DeleteSyncSnapshot(j.ID); err != nil {
+			return err
+		}
+	}
+	return db.UpdateSyncJob(j)
+}
+
+func DeleteSyncJobById(id uint) error {
+	return db.DeleteSyncJobById(id)
+}
+
+func GetSyncRunsByJobId(jobId uint, pageIndex, pageSize int) ([]model.SyncRun, int64, error) {
+	return db.GetSyncRunsByJobId(jobId, pageIndex, pageSize)
+}
+
+func CreateSyncRun(r *model.SyncRun) error {
+	return db.CreateSyncRun(r)
+}
+
+func UpdateSyncRun(r *model.SyncRun) error {
+	return db.UpdateSyncRun(r)
+}
+
+// GetSyncSnapshot returns the snapshot of the job by path
+func GetSyncSnapshot(jobId uint) (map[string]model.SyncSnapshotEntry, error) {
+	entries, err := db.GetSyncSnapshot(jobId)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]model.SyncSnapshotEntry, len(entries))
+	for _, e := range entries {
+		res[e.Path] = e
+	}
+	return res, nil
+}
+
+func SaveSyncSnapshot(jobId uint, entries []model.SyncSnapshotEntry) error {
+	return db.SaveSyncSnapshot(jobId, entries)
+}
@@ -0,0 +1,32 @@
+package op
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+func GetTrashItemById(id uint) (*model.TrashItem, error) {
+	return db.GetTrashItemById(id)
+}
+
+func GetTrashItems(pageIndex, pageSize int) ([]model.TrashItem, int64, error) {
+	return db.GetTrashItems(pageIndex, pageSize)
+}
+
+func GetTrashItemsByDeleterId(deleterId uint, pageIndex, pageSize int) ([]model.TrashItem, int64, error) {
+	return db.GetTrashItemsByDeleterId(deleterId, pageIndex, pageSize)
+}
+
+func GetTrashItemsBefore(t time.Time) ([]model.TrashItem, error) {
+	return db.GetTrashItemsBefore(t)
+}
+
+func CreateTrashItem(t *model.TrashItem) error {
+	return db.CreateTrashItem(t)
+}
+
+func DeleteTrashItemById(id uint) error {
+	return db.DeleteTrashItemById(id)
+}
@@ -30,6 +30,9 @@ func GetGuest() (*model.User, error) {
 		if err != nil {
 			return nil, err
 		}
+		if err = applyGroups(user); err != nil {
+			return nil, err
+		}
 		guestUser = user
 	}
 	return guestUser, nil
@@ -51,6 +54,9 @@ func GetUserByName(username string) (*model.User, error) {
 		if err != nil {
 			return nil, err
 		}
+		if err = applyGroups(_user); err != nil {
+			return nil, err
+		}
 		Cache.SetUser(username, _user)
 		return _user, nil
 	})
@@ -58,7 +64,14 @@ func GetUserByName(username string) (*model.User, error) {
 }
 
 func GetUserById(id uint) (*model.User, error) {
-	return db.GetUserById(id)
+	user, err := db.GetUserById(id)
+	if err != nil {
+		return nil, err
+	}
+	if err = applyGroups(user); err != nil {
+		return nil, err
+	}
+	return user, nil
 }
 
 func GetUsers(pageIndex, pageSize int) (users []model.User, count int64, err error) {
@@ -82,6 +95,18 @@ func DeleteUserById(id uint) error {
 	if err := DeleteSharingsByCreatorId(id); err != nil {
 		return errors.WithMessage(err, "failed to delete user's sharings")
 	}
+	if err := db.DeleteAPITokensByUserId(id); err != nil {
+		return errors.WithMessage(err, "failed to delete user's api tokens")
+	}
+	if err := db.DeleteSignedLinksByUserId(id); err != nil {
+		return errors.WithMessage(err, "failed to delete user's signed links")
+	}
+	if err := deleteACLRulesBySubject(model.ACLSubjectUser, id); err != nil {
+		return errors.WithMessage(err, "failed to delete user's acl rules")
+	}
+	if err := db.DeleteUserUsage(id); err != nil {
+		return errors.WithMessage(err, "failed to delete user's usage")
+	}
 	return db.DeleteUserById(id)
 }
 
@@ -0,0 +1,22 @@
+package op
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+func GetFileVersionById(id uint) (*model.FileVersion, error) {
+	return db.GetFileVersionById(id)
+}
+
+func GetFileVersionsByPath(path string) ([]model.FileVersion, error) {
+	return db.GetFileVersionsByPath(path)
+}
+
+func CreateFileVersion(v *model.FileVersion) error {
+	return db.CreateFileVersion(v)
+}
+
+func DeleteFileVersionById(id uint) error {
+	return db.DeleteFileVersionById(id)
+}
@@ -0,0 +1,107 @@
+package op
+
+import (
+	stdpath "path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// webdavLockMu makes checking for conflicts and creating a lock atomic in this instance
+var webdavLockMu sync.Mutex
+
+// GetWebDAVLocks returns the locks at now that apply to path or to a path below it
+func GetWebDAVLocks(now time.Time, path string) ([]model.WebDAVLock, error) {
+	path = utils.FixAndCleanPath(path)
+	roots := []string{path}
+	for p := path; p != "/"; {
+		p = stdpath.Dir(p)
+		roots = append(roots, p)
+	}
+	prefix := path
+	if prefix != "/" {
+		prefix += "/"
+	}
+	locks, err := db.GetWebDAVLocksOn(roots, path)
+	if err != nil {
+		return nil, err
+	}
+	res := locks[:0]
+	for _, l := range locks {
+		if !l.Expired(now) && (l.Covers(path) || strings.HasPrefix(l.Root, prefix)) {
+			res = append(res, l)
+		}
+	}
+	return res, nil
+}
+
+// CreateWebDAVLock creates l with a new token unless it conflicts with another lock, the
+// expired locks are deleted
+func CreateWebDAVLock(now time.Time, l *model.WebDAVLock) error {
+	l.Root = utils.FixAndCleanPath(l.Root)
+	webdavLockMu.Lock()
+	defer webdavLockMu.Unlock()
+	if err := db.DeleteExpiredWebDAVLocks(now); err != nil {
+		log.Warnf("failed delete expired webdav locks: %+v", err)
+	}
+	locks, err := GetWebDAVLocks(now, l.Root)
+	if err != nil {
+		return err
+	}
+	for _, x := range locks {
+		// a lock below the root only conflicts with an infinite depth lock
+		if x.Covers(l.Root) || !l.ZeroDepth {
+			return errors.WithMessagef(errs.Locked, "[%s] is locked", x.Root)
+		}
+	}
+	l.Token = "opaquelocktoken:" + uuid.NewString()
+	l.SetDuration(now, l.Duration)
+	return db.CreateWebDAVLock(l)
+}
+
+// GetWebDAVLock returns the lock of token, gorm.ErrRecordNotFound if it doesn't exist or
+// has expired at now
+func GetWebDAVLock(now time.Time, token string) (*model.WebDAVLock, error) {
+	l, err := db.GetWebDAVLock(token)
+	if err != nil {
+		return nil, err
+	}
+	if l.Expired(now) {
+		return nil, errors.WithStack(gorm.ErrRecordNotFound)
+	}
+	return l, nil
+}
+
+func RefreshWebDAVLock(now time.Time, token string, duration time.Duration) (*model.WebDAVLock, error) {
+	webdavLockMu.Lock()
+	defer webdavLockMu.Unlock()
+	l, err := GetWebDAVLock(now, token)
+	if err != nil {
+		return nil, err
+	}
+	l.SetDuration(now, duration)
+	return l, db.UpdateWebDAVLock(l)
+}
+
+func DeleteWebDAVLock(token string) error {
+	webdavLockMu.Lock()
+	defer webdavLockMu.Unlock()
+	return db.DeleteWebDAVLock(token)
+}
+
+// DeleteWebDAVLocksUnder deletes the locks rooted at path or below it, once the resources are
+// removed or moved away the locks have nothing left to lock
+func DeleteWebDAVLocksUnder(path string) error {
+	webdavLockMu.Lock()
+	defer webdavLockMu.Unlock()
+	return db.DeleteWebDAVLocksUnder(utils.FixAndCleanPath(path))
+}
@@ -0,0 +1,77 @@
+package op_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+func TestWebDAVLocks(t *testing.T) {
+	now := time.Now()
+	create := func(root string, zeroDepth bool, d time.Duration) (*model.WebDAVLock, error) {
+		l := &model.WebDAVLock{Root: root, ZeroDepth: zeroDepth, Duration: d}
+		return l, op.CreateWebDAVLock(now, l)
+	}
+	dir, err := create("/locks/dir", false, time.Minute)
+	if err != nil {
+		t.Fatalf("failed create lock: %+v", err)
+	}
+	if _, err := create("/locks/dir/a.docx", true, time.Minute); !errors.Is(err, errs.Locked) {
+		t.Errorf("lock below an infinite lock: got %v, want locked", err)
+	}
+	if _, err := create("/locks", false, time.Minute); !errors.Is(err, errs.Locked) {
+		t.Errorf("infinite lock above a lock: got %v, want locked", err)
+	}
+	if _, err := create("/locks", true, time.Minute); err != nil {
+		t.Errorf("zero depth lock above a lock: %+v", err)
+	}
+
+	locks, err := op.GetWebDAVLocks(now, "/locks/dir/a.docx")
+	if err != nil || len(locks) != 1 || locks[0].Token != dir.Token {
+		t.Errorf("locks of a.docx: got %v %v, want the dir lock", locks, err)
+	}
+	if locks, _ := op.GetWebDAVLocks(now, "/locks/dirx"); len(locks) != 0 {
+		t.Errorf("locks of a sibling: got %v, want none", locks)
+	}
+	if locks, _ := op.GetWebDAVLocks(now.Add(time.Hour), "/locks/dir/a.docx"); len(locks) != 0 {
+		t.Errorf("locks after expiry: got %v, want none", locks)
+	}
+
+	if _, err := op.RefreshWebDAVLock(now, dir.Token, -1); err != nil {
+		t.Fatalf("failed refresh lock: %+v", err)
+	}
+	if locks, _ := op.GetWebDAVLocks(now.Add(time.Hour), "/locks/dir/a.docx"); len(locks) != 1 {
+		t.Errorf("locks of an infinite lock: got %v, want one", locks)
+	}
+	if err := op.DeleteWebDAVLock(dir.Token); err != nil {
+		t.Fatalf("failed delete lock: %+v", err)
+	}
+	if _, err := create("/locks/dir/a.docx", true, time.Minute); err != nil {
+		t.Errorf("lock after unlock: %+v", err)
+	}
+}
+
+func TestDeleteWebDAVLocksUnder(t *testing.T) {
+	now := time.Now()
+	var locks []*model.WebDAVLock
+	for _, root := range []string{"/gone/a_b", "/gone/a_b/c.txt", "/gone/axb"} {
+		l := &model.WebDAVLock{Root: root, ZeroDepth: true, Duration: time.Minute}
+		if err := op.CreateWebDAVLock(now, l); err != nil {
+			t.Fatalf("failed create lock on %s: %+v", root, err)
+		}
+		locks = append(locks, l)
+	}
+	if err := op.DeleteWebDAVLocksUnder("/gone/a_b"); err != nil {
+		t.Fatalf("failed delete locks: %+v", err)
+	}
+	for i, want := range []bool{false, false, true} {
+		_, err := op.GetWebDAVLock(now, locks[i].Token)
+		if got := err == nil; got != want {
+			t.Errorf("lock on %s exists = %v, want %v", locks[i].Root, got, want)
+		}
+	}
+}
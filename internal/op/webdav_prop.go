@@ -0,0 +1,35 @@
+package op
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+func GetWebDAVProps(path string) ([]model.WebDAVProp, error) {
+	return db.GetWebDAVProps(utils.FixAndCleanPath(path))
+}
+
+func SetWebDAVProps(path string, props []model.WebDAVProp) error {
+	return db.SetWebDAVProps(utils.FixAndCleanPath(path), props)
+}
+
+func MoveWebDAVProps(src, dst string) error {
+	src, dst = utils.FixAndCleanPath(src), utils.FixAndCleanPath(dst)
+	if src == dst {
+		return nil
+	}
+	return db.MoveWebDAVProps(src, dst)
+}
+
+func CopyWebDAVProps(src, dst string) error {
+	src, dst = utils.FixAndCleanPath(src), utils.FixAndCleanPath(dst)
+	if src == dst {
+		return nil
+	}
+	return db.CopyWebDAVProps(src, dst)
+}
+
+func DeleteWebDAVProps(path string) error {
+	return db.DeleteWebDAVProps(utils.FixAndCleanPath(path))
+}
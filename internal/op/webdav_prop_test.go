@@ -0,0 +1,76 @@
+package op_test
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+func TestWebDAVPropsFollowPaths(t *testing.T) {
+	set := func(path, local, value string) {
+		t.Helper()
+		err := op.SetWebDAVProps(path, []model.WebDAVProp{{Space: "urn:test", Local: local, InnerXML: value}})
+		if err != nil {
+			t.Fatalf("failed set props of %s: %+v", path, err)
+		}
+	}
+	get := func(path string) string {
+		t.Helper()
+		props, err := op.GetWebDAVProps(path)
+		if err != nil {
+			t.Fatalf("failed get props of %s: %+v", path, err)
+		}
+		if len(props) == 0 {
+			return ""
+		}
+		return props[0].InnerXML
+	}
+	set("/dav/a", "attr", "1")
+	set("/dav/a/b.txt", "attr", "2")
+	set("/dav/ab.txt", "attr", "3")
+	set("/dav/a_b/d.txt", "attr", "6")
+	set("/dav/axb/d.txt", "attr", "7")
+	set("/dst/a/c.txt", "attr", "4")
+	set("/dst/a/b.txt", "attr", "5")
+
+	if err := op.CopyWebDAVProps("/dav/a", "/dst/a"); err != nil {
+		t.Fatalf("failed copy props: %+v", err)
+	}
+	if v := get("/dst/a/b.txt"); v != "2" {
+		t.Errorf("copied b.txt has %q, want 2", v)
+	}
+	if v := get("/dst/a/c.txt"); v != "4" {
+		t.Errorf("merged c.txt has %q, want 4", v)
+	}
+
+	if err := op.MoveWebDAVProps("/dav/a", "/moved"); err != nil {
+		t.Fatalf("failed move props: %+v", err)
+	}
+	if v := get("/moved/b.txt"); v != "2" {
+		t.Errorf("moved b.txt has %q, want 2", v)
+	}
+	if v := get("/dav/a"); v != "" {
+		t.Errorf("moved source still has %q", v)
+	}
+	if v := get("/dav/ab.txt"); v != "3" {
+		t.Errorf("sibling ab.txt has %q, want 3", v)
+	}
+
+	if err := op.MoveWebDAVProps("/dav/a_b", "/moved_b"); err != nil {
+		t.Fatalf("failed move props: %+v", err)
+	}
+	if v := get("/moved_b/d.txt"); v != "6" {
+		t.Errorf("moved a_b/d.txt has %q, want 6", v)
+	}
+	if v := get("/dav/axb/d.txt"); v != "7" {
+		t.Errorf("axb/d.txt matched by the underscore has %q, want 7", v)
+	}
+
+	if err := op.DeleteWebDAVProps("/dst/a"); err != nil {
+		t.Fatalf("failed delete props: %+v", err)
+	}
+	if v := get("/dst/a/c.txt"); v != "" {
+		t.Errorf("deleted c.txt still has %q", v)
+	}
+}
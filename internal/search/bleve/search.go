@@ -3,6 +3,7 @@ package bleve
 import (
 	"context"
 	"os"
+	"time"
 
 	query2 "github.com/blevesearch/bleve/v2/search/query"
 
@@ -25,19 +26,90 @@ func (b *Bleve) Config() searcher.Config {
 	return config
 }
 
+// searchDocument adds the fields only needed for filtering to the indexed node
+type searchDocument struct {
+	model.SearchNode
+	HashList []string `json:"hash_list"`
+}
+
+func newSearchDocument(node model.SearchNode) *searchDocument {
+	return &searchDocument{SearchNode: node, HashList: node.HashList()}
+}
+
+func filterQueries(f model.SearchFilters) []query2.Query {
+	var queries []query2.Query
+	inclusive := true
+	if f.MinSize != nil || f.MaxSize != nil {
+		var minSize, maxSize *float64
+		if f.MinSize != nil {
+			v := float64(*f.MinSize)
+			minSize = &v
+		}
+		if f.MaxSize != nil {
+			v := float64(*f.MaxSize)
+			maxSize = &v
+		}
+		q := bleve.NewNumericRangeInclusiveQuery(minSize, maxSize, &inclusive, &inclusive)
+		q.SetField("size")
+		queries = append(queries, q)
+	}
+	if f.ModifiedFrom != nil || f.ModifiedTo != nil {
+		var from, to time.Time
+		if f.ModifiedFrom != nil {
+			from = *f.ModifiedFrom
+		}
+		if f.ModifiedTo != nil {
+			to = *f.ModifiedTo
+		}
+		q := bleve.NewDateRangeQuery(from, to)
+		q.SetField("modified")
+		queries = append(queries, q)
+	}
+	if len(f.ObjTypes) > 0 {
+		var types []query2.Query
+		for _, t := range f.ObjTypes {
+			v := float64(t)
+			q := bleve.NewNumericRangeInclusiveQuery(&v, &v, &inclusive, &inclusive)
+			q.SetField("obj_type")
+			types = append(types, q)
+		}
+		queries = append(queries, bleve.NewDisjunctionQuery(types...))
+	}
+	for name, h := range f.Hashes {
+		q := bleve.NewTermQuery(name + ":" + h)
+		q.SetField("hash_list")
+		queries = append(queries, q)
+	}
+	return queries
+}
+
 func (b *Bleve) Search(ctx context.Context, req model.SearchReq) ([]model.SearchNode, int64, error) {
 	var queries []query2.Query
-	query := bleve.NewMatchQuery(req.Keywords)
-	query.SetField("name")
-	queries = append(queries, query)
+	if req.Keywords != "" {
+		query := bleve.NewMatchQuery(req.Keywords)
+		query.SetField("name")
+		queries = append(queries, query)
+	} else {
+		queries = append(queries, bleve.NewMatchAllQuery())
+	}
 	if req.Scope != 0 {
 		isDir := req.Scope == 1
 		isDirQuery := bleve.NewBoolFieldQuery(isDir)
+		isDirQuery.SetField("is_dir")
 		queries = append(queries, isDirQuery)
 	}
+	queries = append(queries, filterQueries(req.Filters)...)
 	reqQuery := bleve.NewConjunctionQuery(queries...)
 	search := bleve.NewSearchRequest(reqQuery)
-	search.SortBy([]string{"name"})
+	order := "name"
+	switch req.OrderBy {
+	case "size", "modified":
+		order = req.OrderBy
+	}
+	if req.OrderDirection == "desc" {
+		order = "-" + order
+	}
+	search.SortBy([]string{order})
 	search.From = (req.Page - 1) * req.PerPage
 	search.Size = req.PerPage
 	search.Fields = []string{"*"}
@@ -47,24 +119,33 @@ func (b *Bleve) Search(ctx context.Context, req model.SearchReq) ([]model.Search
 		return nil, 0, err
 	}
 	res, err := utils.SliceConvert(searchResults.Hits, func(src *search2.DocumentMatch) (model.SearchNode, error) {
-		return model.SearchNode{
+		node := model.SearchNode{
 			Parent: src.Fields["parent"].(string),
 			Name:   src.Fields["name"].(string),
 			IsDir:  src.Fields["is_dir"].(bool),
 			Size:   int64(src.Fields["size"].(float64)),
-		}, nil
+		}
+		// indexes built before these fields were added do not have them
+		if modified, ok := src.Fields["modified"].(string); ok {
+			node.Modified, _ = time.Parse(time.RFC3339, modified)
+		}
+		if objType, ok := src.Fields["obj_type"].(float64); ok {
+			node.ObjType = int(objType)
+		}
+		node.Hashes, _ = src.Fields["hashes"].(string)
+		return node, nil
 	})
 	return res, int64(searchResults.Total), nil
 }
 
 func (b *Bleve) Index(ctx context.Context, node model.SearchNode) error {
-	return b.BIndex.Index(uuid.NewString(), node)
+	return b.BIndex.Index(uuid.NewString(), newSearchDocument(node))
 }
 
 func (b *Bleve) BatchIndex(ctx context.Context, nodes []model.SearchNode) error {
 	batch := b.BIndex.NewBatch()
 	for _, node := range nodes {
-		batch.Index(uuid.NewString(), node)
+		batch.Index(uuid.NewString(), newSearchDocument(node))
 	}
 	return b.BIndex.Batch(batch)
 }
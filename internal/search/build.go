@@ -19,6 +19,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/pkg/mq"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -216,38 +217,62 @@ func Update(ctx context.Context, parent string, objs []model.Obj) {
 		return
 	}
 
+	if _, err = updateDir(ctx, parent, objs); err != nil {
+		log.Errorf("update search index error: %+v", err)
+	}
+}
+
+// dirDiff counts the nodes changed by updateDir
+type dirDiff struct {
+	Added, Updated, Deleted int
+}
+
+// updateDir diffs objs against the indexed nodes of parent, only the new,
+// changed and removed ones are written to the index.
+func updateDir(ctx context.Context, parent string, objs []model.Obj) (dirDiff, error) {
+	var diff dirDiff
 	// Use task queue for Meilisearch to avoid race conditions with async indexing
 	if msInstance, ok := instance.(interface {
 		EnqueueUpdate(parent string, objs []model.Obj)
 	}); ok {
 		// Enqueue task for async processing (diff calculation happens at consumption time)
 		msInstance.EnqueueUpdate(parent, objs)
-		return
+		return diff, nil
 	}
 
 	nodes, err := instance.Get(ctx, parent)
 	if err != nil {
-		log.Errorf("update search index error while get nodes: %+v", err)
-		return
+		return diff, errors.WithMessage(err, "failed get nodes")
 	}
 	now := mapset.NewSet[string]()
 	for i := range objs {
 		now.Add(objs[i].GetName())
 	}
 	old := mapset.NewSet[string]()
+	oldNodes := make(map[string]*model.SearchNode, len(nodes))
 	for i := range nodes {
 		old.Add(nodes[i].Name)
+		oldNodes[nodes[i].Name] = &nodes[i]
+	}
+	// files changed since indexed are deleted and added again
+	outdated := mapset.NewSet[string]()
+	for i := range objs {
+		if node, ok := oldNodes[objs[i].GetName()]; ok && node.Outdated(objs[i]) {
+			outdated.Add(objs[i].GetName())
+		}
 	}
 	// delete data that no longer exists
-	toDelete := old.Difference(now)
-	toAdd := now.Difference(old)
+	toDelete := old.Difference(now).Union(outdated)
+	toAdd := now.Difference(old).Union(outdated)
 	for i := range nodes {
 		if toDelete.Contains(nodes[i].Name) && !op.HasStorage(path.Join(parent, nodes[i].Name)) {
 			log.Debugf("delete index: %s", path.Join(parent, nodes[i].Name))
 			err = instance.Del(ctx, path.Join(parent, nodes[i].Name))
 			if err != nil {
-				log.Errorf("update search index error while del old node: %+v", err)
-				return
+				return diff, errors.WithMessage(err, "failed del old node")
+			}
+			if !outdated.Contains(nodes[i].Name) {
+				diff.Deleted++
 			}
 		}
 	}
@@ -266,10 +291,12 @@ func Update(ctx context.Context, parent string, objs []model.Obj) {
 	if len(toAddObjs) > 0 {
 		err = BatchIndex(ctx, toAddObjs)
 		if err != nil {
-			log.Errorf("update search index error while batch index new nodes: %+v", err)
-			return
+			return diff, errors.WithMessage(err, "failed batch index new nodes")
 		}
 	}
+	diff.Updated = outdated.Cardinality()
+	diff.Added = len(toAddObjs) - diff.Updated
+	return diff, nil
 }
 
 func init() {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 
@@ -25,6 +26,10 @@ type searchDocument struct {
 	// Can be used for filtering all descendants exactly.
 	// Storing path hashes instead of plaintext paths benefits disk usage and case-sensitive filter.
 	ParentPathHashes []string `json:"parent_path_hashes"`
+	// Unix time of the modified time, meilisearch can only filter and sort by numbers
+	ModifiedUnix int64 `json:"modified_unix"`
+	// Hashes of the node as <hash type>:<hash>
+	HashList []string `json:"hash_list"`
 	model.SearchNode
 }
 
@@ -33,6 +38,7 @@ type Meilisearch struct {
 	IndexUid             string
 	FilterableAttributes []string
 	SearchableAttributes []string
+	SortableAttributes   []string
 	taskQueue            *TaskQueueManager
 }
 
@@ -55,22 +61,31 @@ func (m *Meilisearch) Search(ctx context.Context, req model.SearchReq) ([]model.
 		parentHash := hashPath(req.Parent)
 		filters = append(filters, fmt.Sprintf("parent_path_hashes = '%s'", parentHash))
 	}
+	filters = append(filters, searchFilters(req.Filters)...)
 	if len(filters) > 0 {
 		mReq.Filter = strings.Join(filters, " AND ")
 	}
+	if req.OrderBy != "" {
+		order := "name"
+		switch req.OrderBy {
+		case "size":
+			order = "size"
+		case "modified":
+			order = "modified_unix"
+		}
+		direction := "asc"
+		if req.OrderDirection == "desc" {
+			direction = "desc"
+		}
+		mReq.Sort = []string{order + ":" + direction}
+	}
 
 	search, err := m.Client.Index(m.IndexUid).SearchWithContext(ctx, req.Keywords, mReq)
 	if err != nil {
 		return nil, 0, err
 	}
 	nodes, err := utils.SliceConvert(search.Hits, func(src any) (model.SearchNode, error) {
-		srcMap := src.(map[string]any)
-		return model.SearchNode{
-			Parent: srcMap["parent"].(string),
-			Name:   srcMap["name"].(string),
-			IsDir:  srcMap["is_dir"].(bool),
-			Size:   int64(srcMap["size"].(float64)),
-		}, nil
+		return buildSearchDocumentFromResults(src.(map[string]any)).SearchNode, nil
 	})
 	if err != nil {
 		return nil, 0, err
@@ -99,6 +114,8 @@ func (m *Meilisearch) BatchIndex(ctx context.Context, nodes []model.SearchNode)
 			ID:               nodePathHash,
 			ParentHash:       parentHash,
 			ParentPathHashes: parentPathHashes,
+			ModifiedUnix:     src.Modified.Unix(),
+			HashList:         src.HashList(),
 			SearchNode:       src,
 		}, nil
 	})
@@ -261,6 +278,8 @@ func (m *Meilisearch) batchIndexWithTaskUID(ctx context.Context, nodes []model.S
 			ID:               nodePathHash,
 			ParentHash:       parentHash,
 			ParentPathHashes: parentPathHashes,
+			ModifiedUnix:     src.Modified.Unix(),
+			HashList:         src.HashList(),
 			SearchNode:       src,
 		}, nil
 	})
@@ -341,3 +360,30 @@ func (m *Meilisearch) batchDeleteWithTaskUID(ctx context.Context, paths []string
 	}
 	return taskUIDs, nil
 }
+
+func searchFilters(f model.SearchFilters) []string {
+	var filters []string
+	if f.MinSize != nil {
+		filters = append(filters, fmt.Sprintf("size >= %d", *f.MinSize))
+	}
+	if f.MaxSize != nil {
+		filters = append(filters, fmt.Sprintf("size <= %d", *f.MaxSize))
+	}
+	if f.ModifiedFrom != nil {
+		filters = append(filters, fmt.Sprintf("modified_unix >= %d", f.ModifiedFrom.Unix()))
+	}
+	if f.ModifiedTo != nil {
+		filters = append(filters, fmt.Sprintf("modified_unix < %d", f.ModifiedTo.Unix()))
+	}
+	if len(f.ObjTypes) > 0 {
+		types := make([]string, 0, len(f.ObjTypes))
+		for _, t := range f.ObjTypes {
+			types = append(types, strconv.Itoa(t))
+		}
+		filters = append(filters, fmt.Sprintf("obj_type IN [%s]", strings.Join(types, ", ")))
+	}
+	for name, h := range f.Hashes {
+		filters = append(filters, fmt.Sprintf("hash_list = '%s:%s'", name, h))
+	}
+	return filters
+}
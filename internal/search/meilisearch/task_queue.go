@@ -201,12 +201,21 @@ func (tqm *TaskQueueManager) executeTask(ctx context.Context, task *QueuedTask)
 		now.Add(currentObjs[i].GetName())
 	}
 	old := mapset.NewSet[string]()
+	oldNodes := make(map[string]*model.SearchNode, len(nodes))
 	for i := range nodes {
 		old.Add(nodes[i].Name)
+		oldNodes[nodes[i].Name] = &nodes[i]
+	}
+	// documents of files changed since indexed are replaced as their ids do not change
+	outdated := mapset.NewSet[string]()
+	for i := range currentObjs {
+		if node, ok := oldNodes[currentObjs[i].GetName()]; ok && node.Outdated(currentObjs[i]) {
+			outdated.Add(currentObjs[i].GetName())
+		}
 	}
 
 	toDelete := old.Difference(now)
-	toAdd := now.Difference(old)
+	toAdd := now.Difference(old).Union(outdated)
 
 	// Collect paths to delete
 	var pathsToDelete []string
@@ -235,12 +244,7 @@ func (tqm *TaskQueueManager) executeTask(ctx context.Context, task *QueuedTask)
 	for i := range currentObjs {
 		if toAdd.Contains(currentObjs[i].GetName()) {
 			log.Debugf("will add index: %s", path.Join(parent, currentObjs[i].GetName()))
-			nodesToAdd = append(nodesToAdd, model.SearchNode{
-				Parent: parent,
-				Name:   currentObjs[i].GetName(),
-				IsDir:  currentObjs[i].IsDir(),
-				Size:   currentObjs[i].GetSize(),
-			})
+			nodesToAdd = append(nodesToAdd, model.NewSearchNode(parent, currentObjs[i]))
 		}
 	}
 
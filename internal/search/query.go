@@ -0,0 +1,165 @@
+package search
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+var objTypes = map[string]int{
+	"folder": conf.FOLDER,
+	"dir":    conf.FOLDER,
+	"video":  conf.VIDEO,
+	"audio":  conf.AUDIO,
+	"text":   conf.TEXT,
+	"image":  conf.IMAGE,
+	"other":  conf.UNKNOWN,
+}
+
+var sizeUnits = map[string]int64{
+	"":  1,
+	"k": 1 << 10,
+	"m": 1 << 20,
+	"g": 1 << 30,
+	"t": 1 << 40,
+	"p": 1 << 50,
+}
+
+// ParseQuery splits the filters out of the search keywords, the filters are:
+//
+//	size>1G size<=100M      sizes in bytes with an optional K/M/G/T/P unit (1024 based)
+//	modified<2024-01-01     dates or RFC3339 times, with <, <=, > or >=
+//	type:video              one of folder, video, audio, text, image and other, comma separated
+//	hash:md5=<hash>         any hash type exposed by the storages
+//
+// and the rest are returned as the keywords.
+func ParseQuery(query string) (string, model.SearchFilters, error) {
+	var filters model.SearchFilters
+	var keywords []string
+	for _, token := range strings.Fields(query) {
+		lower := strings.ToLower(token)
+		var err error
+		switch {
+		case strings.HasPrefix(lower, "size") && isCompareOp(lower[4:]):
+			err = parseSizeFilter(&filters, lower[4:])
+		case strings.HasPrefix(lower, "modified") && isCompareOp(lower[8:]):
+			err = parseModifiedFilter(&filters, token[8:])
+		case strings.HasPrefix(lower, "type:"):
+			for _, name := range strings.Split(lower[5:], ",") {
+				t, ok := objTypes[name]
+				if !ok {
+					return "", filters, fmt.Errorf("unknown type [%s] in %s", name, token)
+				}
+				filters.ObjTypes = append(filters.ObjTypes, t)
+			}
+		case strings.HasPrefix(lower, "hash:"):
+			name, h, ok := strings.Cut(lower[5:], "=")
+			if !ok || name == "" || h == "" || strings.ContainsAny(h, `%_"\`) {
+				return "", filters, fmt.Errorf("invalid hash filter %s, expected hash:<type>=<hash>", token)
+			}
+			if _, ok = utils.GetHashByName(name); !ok {
+				return "", filters, fmt.Errorf("unknown hash type [%s] in %s", name, token)
+			}
+			if filters.Hashes == nil {
+				filters.Hashes = make(map[string]string)
+			}
+			filters.Hashes[name] = h
+		default:
+			keywords = append(keywords, token)
+		}
+		if err != nil {
+			return "", filters, fmt.Errorf("invalid filter %s: %w", token, err)
+		}
+	}
+	return strings.Join(keywords, " "), filters, nil
+}
+
+func isCompareOp(s string) bool {
+	return strings.HasPrefix(s, "<") || strings.HasPrefix(s, ">")
+}
+
+func cutCompareOp(s string) (string, string) {
+	if len(s) > 1 && s[1] == '=' {
+		return s[:2], s[2:]
+	}
+	return s[:1], s[1:]
+}
+
+func parseSize(s string) (int64, error) {
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "b"), "i")
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	num, unit := s, ""
+	if i >= 0 {
+		num, unit = s[:i], s[i:]
+	}
+	mul, ok := sizeUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("unknown size unit [%s]", unit)
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("invalid size [%s]", num)
+	}
+	return int64(f * float64(mul)), nil
+}
+
+func parseSizeFilter(filters *model.SearchFilters, s string) error {
+	op, value := cutCompareOp(s)
+	size, err := parseSize(value)
+	if err != nil {
+		return err
+	}
+	switch op {
+	case ">":
+		size++
+		fallthrough
+	case ">=":
+		filters.MinSize = &size
+	case "<":
+		size--
+		fallthrough
+	case "<=":
+		filters.MaxSize = &size
+	}
+	return nil
+}
+
+// parseTime returns the time and its precision, a day for dates and a second for times
+func parseTime(s string) (time.Time, time.Duration, error) {
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, 24 * time.Hour, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return t, 0, fmt.Errorf("invalid time [%s], expected 2006-01-02 or RFC3339", s)
+	}
+	return t, time.Second, nil
+}
+
+func parseModifiedFilter(filters *model.SearchFilters, s string) error {
+	op, value := cutCompareOp(s)
+	t, precision, err := parseTime(value)
+	if err != nil {
+		return err
+	}
+	switch op {
+	case ">":
+		t = t.Add(precision)
+		fallthrough
+	case ">=":
+		filters.ModifiedFrom = &t
+	case "<=":
+		t = t.Add(precision)
+		fallthrough
+	case "<":
+		filters.ModifiedTo = &t
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package search
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+)
+
+func TestParseQuery(t *testing.T) {
+	keywords, f, err := ParseQuery("holiday size>1G size<=1.5GiB modified<2024-01-01 type:video,image hash:MD5=ABC movie")
+	if err != nil {
+		t.Fatalf("failed to parse: %+v", err)
+	}
+	if keywords != "holiday movie" {
+		t.Errorf("expected keywords [holiday movie], got [%s]", keywords)
+	}
+	if f.MinSize == nil || *f.MinSize != 1<<30+1 {
+		t.Errorf("unexpected min size %v", f.MinSize)
+	}
+	if f.MaxSize == nil || *f.MaxSize != 3<<29 {
+		t.Errorf("unexpected max size %v", f.MaxSize)
+	}
+	if f.ModifiedFrom != nil || f.ModifiedTo == nil || !f.ModifiedTo.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("unexpected modified range %v - %v", f.ModifiedFrom, f.ModifiedTo)
+	}
+	if len(f.ObjTypes) != 2 || f.ObjTypes[0] != conf.VIDEO || f.ObjTypes[1] != conf.IMAGE {
+		t.Errorf("unexpected types %v", f.ObjTypes)
+	}
+	if f.Hashes["md5"] != "abc" {
+		t.Errorf("unexpected hashes %v", f.Hashes)
+	}
+
+	for _, q := range []string{"size>1X", "modified>yesterday", "type:book", "hash:md5", "hash:foo=1"} {
+		if _, _, err := ParseQuery(q); err == nil {
+			t.Errorf("expected %s to be invalid", q)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package search
+
+import (
+	"context"
+	stdpath "path"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+)
+
+// StorageIndexInfo is the index schedule and freshness of a storage
+type StorageIndexInfo struct {
+	MountPath string `json:"mount_path"`
+	model.IndexSchedule
+	DisableIndex bool       `json:"disable_index"`
+	Running      bool       `json:"running"`
+	NextRun      *time.Time `json:"next_run"`
+	model.StorageIndex
+}
+
+type indexSchedule struct {
+	spec     string
+	schedule *cron.Schedule
+	next     time.Time
+}
+
+var (
+	indexSchedules   = make(map[uint]*indexSchedule)
+	indexSchedulesMu sync.Mutex
+	// storageIndexing holds the ids of the storages being walked
+	storageIndexing sync.Map
+)
+
+func StorageIndexRunning(storageId uint) bool {
+	_, ok := storageIndexing.Load(storageId)
+	return ok
+}
+
+// UpdateStorageIndex walks the storage and updates the index of every dir against its current
+// listing, only the nodes that changed are written. Dirs are listed at most IndexRateLimit per second.
+func UpdateStorageIndex(ctx context.Context, storage driver.Driver) error {
+	if instance == nil {
+		return errs.SearchNotAvailable
+	}
+	if !instance.Config().AutoUpdate {
+		return errors.New("update is not supported for current index")
+	}
+	if Running() {
+		return errs.BuildIndexIsRunning
+	}
+	s := storage.GetStorage()
+	if s.DisableIndex {
+		return errors.Errorf("index of storage [%s] is disabled", s.MountPath)
+	}
+	if _, loaded := storageIndexing.LoadOrStore(s.ID, struct{}{}); loaded {
+		return errors.Errorf("index of storage [%s] is updating", s.MountPath)
+	}
+	defer storageIndexing.Delete(s.ID)
+
+	start := time.Now()
+	state := model.StorageIndex{StorageId: s.ID, LastStart: &start}
+	if err := db.SaveStorageIndex(&state); err != nil {
+		log.Warnf("failed save index state of storage [%s]: %+v", s.MountPath, err)
+	}
+	err := walkStorageIndex(ctx, s, &state)
+	if err != nil {
+		state.Error = err.Error()
+	} else {
+		done := time.Now()
+		state.LastDone = &done
+	}
+	if err := db.SaveStorageIndex(&state); err != nil {
+		log.Warnf("failed save index state of storage [%s]: %+v", s.MountPath, err)
+	}
+	log.Infof("update index of storage [%s]: %d dirs, %d added, %d updated, %d deleted",
+		s.MountPath, state.Dirs, state.Added, state.Updated, state.Deleted)
+	return err
+}
+
+func walkStorageIndex(ctx context.Context, s *model.Storage, state *model.StorageIndex) error {
+	var limiter *rate.Limiter
+	if s.IndexRateLimit > 0 {
+		limiter = rate.NewLimiter(rate.Limit(s.IndexRateLimit), 1)
+	}
+	// other storages mounted below are indexed by their own schedule
+	return walkIndex(ctx, s.MountPath, setting.GetInt(conf.MaxIndexDepth, 20), limiter, false, state)
+}
+
+// walkIndex lists the dirs under root and updates their index incrementally, the counts are added to state
+func walkIndex(ctx context.Context, root string, maxDepth int, limiter *rate.Limiter, crossMounts bool, state *model.StorageIndex) error {
+	admin, err := op.GetAdmin()
+	if err != nil {
+		return err
+	}
+	ctx = context.WithValue(ctx, conf.UserKey, admin)
+	var walk func(dirPath string, depth int) error
+	walk = func(dirPath string, depth int) error {
+		// the same depth as WalkFS, dirs at maxDepth are indexed but not listed
+		if maxDepth >= 0 && depth >= maxDepth || isIgnorePath(dirPath) {
+			return nil
+		}
+		if storage, _, err := op.GetStorageAndActualPath(dirPath); err == nil && storage.GetStorage().DisableIndex {
+			return nil
+		}
+		if limiter != nil {
+			if err := limiter.Wait(ctx); err != nil {
+				return err
+			}
+		}
+		// the hook is skipped, the listing is diffed here whether auto update is on or not
+		objs, err := fs.List(ctx, dirPath, &fs.ListArgs{Refresh: true, NoLog: true, SkipHook: true})
+		if err != nil {
+			return errors.WithMessagef(err, "failed list [%s]", dirPath)
+		}
+		diff, err := updateDir(ctx, dirPath, objs)
+		if err != nil {
+			return errors.WithMessagef(err, "failed update index of [%s]", dirPath)
+		}
+		state.Dirs++
+		state.Added += uint64(diff.Added)
+		state.Updated += uint64(diff.Updated)
+		state.Deleted += uint64(diff.Deleted)
+		for _, obj := range objs {
+			if utils.IsCanceled(ctx) {
+				return ctx.Err()
+			}
+			childPath := stdpath.Join(dirPath, obj.GetName())
+			if !obj.IsDir() || !crossMounts && op.HasStorage(childPath) {
+				continue
+			}
+			if err := walk(childPath, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(root, 0)
+}
+
+// UpdateIndex updates the index under paths incrementally, it holds the same
+// lock as BuildIndex and can be stopped by StopIndex.
+func UpdateIndex(ctx context.Context, paths []string, maxDepth int) error {
+	if instance == nil {
+		return errs.SearchNotAvailable
+	}
+	quit := make(chan struct{}, 1)
+	if !Quit.CompareAndSwap(nil, &quit) {
+		return errs.BuildIndexIsRunning
+	}
+	defer Quit.Store(nil)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-quit:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	var state model.StorageIndex
+	for _, p := range paths {
+		if err := walkIndex(ctx, utils.FixAndCleanPath(p), maxDepth, nil, true, &state); err != nil {
+			return err
+		}
+	}
+	log.Infof("update index of %+v: %d dirs, %d added, %d updated, %d deleted",
+		paths, state.Dirs, state.Added, state.Updated, state.Deleted)
+	return nil
+}
+
+// ScheduleStorageIndexes starts the index updates of the storages whose index cron is due at now,
+// it should be called about once a minute.
+func ScheduleStorageIndexes(now time.Time) {
+	indexSchedulesMu.Lock()
+	defer indexSchedulesMu.Unlock()
+	seen := make(map[uint]struct{})
+	for _, storage := range op.GetAllStorages() {
+		s := storage.GetStorage()
+		if s.IndexCron == "" || s.DisableIndex || s.Disabled {
+			continue
+		}
+		seen[s.ID] = struct{}{}
+		sch, ok := indexSchedules[s.ID]
+		if !ok || sch.spec != s.IndexCron {
+			schedule, err := cron.ParseSchedule(s.IndexCron)
+			if err != nil {
+				log.Errorf("invalid index cron of storage [%s]: %+v", s.MountPath, err)
+				continue
+			}
+			sch = &indexSchedule{spec: s.IndexCron, schedule: schedule, next: schedule.Next(now)}
+			indexSchedules[s.ID] = sch
+		}
+		if sch.next.IsZero() || now.Before(sch.next) {
+			continue
+		}
+		sch.next = sch.schedule.Next(now)
+		if StorageIndexRunning(s.ID) {
+			continue
+		}
+		go func(storage driver.Driver) {
+			if err := UpdateStorageIndex(context.Background(), storage); err != nil {
+				log.Warnf("failed scheduled index update of storage [%s]: %+v", storage.GetStorage().MountPath, err)
+			}
+		}(storage)
+	}
+	for id := range indexSchedules {
+		if _, ok := seen[id]; !ok {
+			delete(indexSchedules, id)
+		}
+	}
+}
+
+// GetStorageIndexInfos returns the index schedule and the last index update of every storage
+func GetStorageIndexInfos() ([]StorageIndexInfo, error) {
+	states, err := db.GetStorageIndexes()
+	if err != nil {
+		return nil, err
+	}
+	stateMap := make(map[uint]model.StorageIndex, len(states))
+	for _, state := range states {
+		stateMap[state.StorageId] = state
+	}
+	indexSchedulesMu.Lock()
+	defer indexSchedulesMu.Unlock()
+	storages := op.GetAllStorages()
+	res := make([]StorageIndexInfo, 0, len(storages))
+	for _, storage := range storages {
+		s := storage.GetStorage()
+		info := StorageIndexInfo{
+			MountPath:     s.MountPath,
+			IndexSchedule: s.IndexSchedule,
+			DisableIndex:  s.DisableIndex,
+			Running:       StorageIndexRunning(s.ID),
+			StorageIndex:  stateMap[s.ID],
+		}
+		info.StorageIndex.StorageId = s.ID
+		if sch, ok := indexSchedules[s.ID]; ok && sch.spec == s.IndexCron && !sch.next.IsZero() {
+			next := sch.next
+			info.NextRun = &next
+		}
+		res = append(res, info)
+	}
+	return res, nil
+}
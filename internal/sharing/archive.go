@@ -18,8 +18,11 @@ func archiveMeta(ctx context.Context, sid, path string, args model.SharingArchiv
 	if !sharing.Valid() {
 		return sharing, nil, errors.WithStack(errs.InvalidSharing)
 	}
-	if !sharing.Verify(args.Pwd) {
-		return sharing, nil, errors.WithStack(errs.WrongShareCode)
+	if sharing.IsUpload() {
+		return sharing, nil, errors.WithStack(errs.UploadOnlySharing)
+	}
+	if err := Verify(sharing, args.Pwd, args.Token); err != nil {
+		return sharing, nil, err
 	}
 	path = utils.FixAndCleanPath(path)
 	if len(sharing.Files) == 1 || path != "/" {
@@ -45,8 +48,11 @@ func archiveList(ctx context.Context, sid, path string, args model.SharingArchiv
 	if !sharing.Valid() {
 		return sharing, nil, errors.WithStack(errs.InvalidSharing)
 	}
-	if !sharing.Verify(args.Pwd) {
-		return sharing, nil, errors.WithStack(errs.WrongShareCode)
+	if sharing.IsUpload() {
+		return sharing, nil, errors.WithStack(errs.UploadOnlySharing)
+	}
+	if err := Verify(sharing, args.Pwd, args.Token); err != nil {
+		return sharing, nil, err
 	}
 	path = utils.FixAndCleanPath(path)
 	if len(sharing.Files) == 1 || path != "/" {
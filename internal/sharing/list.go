@@ -19,8 +19,11 @@ func list(ctx context.Context, sid, path string, args model.SharingListArgs) (*m
 	if !sharing.Valid() {
 		return sharing, nil, errors.WithStack(errs.InvalidSharing)
 	}
-	if !sharing.Verify(args.Pwd) {
-		return sharing, nil, errors.WithStack(errs.WrongShareCode)
+	if sharing.IsUpload() {
+		return sharing, nil, errors.WithStack(errs.UploadOnlySharing)
+	}
+	if err := Verify(sharing, args.Pwd, args.Token); err != nil {
+		return sharing, nil, err
 	}
 	path = utils.FixAndCleanPath(path)
 	if len(sharing.Files) == 1 || path != "/" {
@@ -0,0 +1,164 @@
+package sharing
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/mail"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/pkg/sign"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	codeExpiration = 10 * time.Minute
+	// codeInterval is the time a recipient waits before a new code is sent
+	codeInterval    = time.Minute
+	maxCodeAttempts = 5
+)
+
+// Verify checks the password of the sharing, and the token of a recipient if it is restricted
+func Verify(sharing *model.Sharing, pwd, token string) error {
+	if !sharing.Verify(pwd) {
+		return errors.WithStack(errs.WrongShareCode)
+	}
+	if sharing.Restricted {
+		if _, err := TokenRecipient(sharing, token); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func tokenSign() sign.Sign {
+	return sign.NewHMACSign([]byte(conf.Conf.JwtSecret))
+}
+
+func tokenData(sid string, recipientId uint) string {
+	return fmt.Sprintf("sharing-recipient/%s/%d", sid, recipientId)
+}
+
+// Token returns a token giving the recipient access to its sharing until the user tokens expire
+func Token(r *model.SharingRecipient) string {
+	expire := time.Now().Add(time.Duration(conf.Conf.TokenExpiresIn) * time.Hour).Unix()
+	return fmt.Sprintf("%d.%s", r.ID, tokenSign().Sign(tokenData(r.SharingId, r.ID), expire))
+}
+
+// TokenRecipient returns the recipient of the sharing the token was given to, if it is not revoked
+func TokenRecipient(sharing *model.Sharing, token string) (*model.SharingRecipient, error) {
+	idStr, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, errors.WithStack(errs.RecipientTokenRequired)
+	}
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return nil, errors.WithStack(errs.RecipientTokenRequired)
+	}
+	if err := tokenSign().Verify(tokenData(sharing.ID, uint(id)), signature); err != nil {
+		return nil, errors.WithStack(errs.RecipientTokenRequired)
+	}
+	r, err := op.GetSharingRecipientById(uint(id))
+	if err != nil || r.SharingId != sharing.ID || r.Revoked {
+		return nil, errors.WithStack(errs.RecipientTokenRequired)
+	}
+	return r, nil
+}
+
+// restrictedSharing returns the valid restricted sharing sid
+func restrictedSharing(sid string) (*model.Sharing, error) {
+	sharing, err := op.GetSharingById(sid)
+	if err != nil {
+		return nil, errors.WithStack(errs.SharingNotFound)
+	}
+	if !sharing.Valid() {
+		return nil, errors.WithStack(errs.InvalidSharing)
+	}
+	if !sharing.Restricted {
+		return nil, errors.New("the sharing has no recipients")
+	}
+	return sharing, nil
+}
+
+// SendCode emails a one time code to the recipient of the sharing sid. Nothing is sent to an email
+// that is no recipient, or a revoked one, and no error tells it apart: a code asked for too early
+// and a failed email are only logged, the email is sent in the background.
+func SendCode(sid, email string) error {
+	sharing, err := restrictedSharing(sid)
+	if err != nil {
+		return err
+	}
+	r, err := op.GetSharingRecipientByEmail(sharing.ID, email)
+	if err != nil || r.Revoked {
+		log.Debugf("no code sent to %s for sharing %s", email, sid)
+		return nil
+	}
+	if time.Since(r.CodeSentAt) < codeInterval {
+		log.Debugf("no code sent to %s for sharing %s, the last one is too recent", email, sid)
+		return nil
+	}
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	code := fmt.Sprintf("%06d", n.Int64())
+	r.CodeSalt = random.String(16)
+	r.CodeHash = model.TwoHashPwd(code, r.CodeSalt)
+	r.CodeSentAt = time.Now()
+	r.CodeExpires = r.CodeSentAt.Add(codeExpiration)
+	r.CodeAttempts = 0
+	if err := op.UpdateSharingRecipient(r); err != nil {
+		log.Errorf("failed save the code of %s for sharing %s: %+v", email, sid, err)
+		return nil
+	}
+	siteTitle := setting.GetStr(conf.SiteTitle)
+	body := fmt.Sprintf("Your code to access the share %s on %s is:\n\n%s\n\nIt expires in %d minutes. "+
+		"Ignore this email if you didn't ask for it.\n", sharing.ID, siteTitle, code, int(codeExpiration.Minutes()))
+	go func() {
+		if err := mail.Send(r.Email, fmt.Sprintf("[%s] Your access code", siteTitle), body); err != nil {
+			log.Errorf("failed send the code to %s for sharing %s: %+v", r.Email, sid, err)
+		}
+	}()
+	return nil
+}
+
+// VerifyCode exchanges the code sent to the recipient for a token, a code can be used once and
+// is invalidated after too many wrong attempts
+func VerifyCode(sid, email, code string) (string, error) {
+	sharing, err := restrictedSharing(sid)
+	if err != nil {
+		return "", err
+	}
+	r, err := op.GetSharingRecipientByEmail(sharing.ID, email)
+	if err != nil || r.Revoked || r.CodeHash == "" || time.Now().After(r.CodeExpires) {
+		return "", errors.New("the code is invalid or expired")
+	}
+	// the attempt is counted in place before the code is compared, so that concurrent
+	// guesses can't go over the limit
+	ok, err := op.CountSharingRecipientCodeAttempt(r.ID, maxCodeAttempts)
+	if err != nil {
+		return "", err
+	}
+	if !ok || subtle.ConstantTimeCompare([]byte(r.CodeHash), []byte(model.TwoHashPwd(code, r.CodeSalt))) != 1 {
+		return "", errors.New("the code is invalid or expired")
+	}
+	// the code is used once, a concurrent use of the same code loses
+	ok, err = op.UseSharingRecipientCode(r.ID, r.CodeHash)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", errors.New("the code is invalid or expired")
+	}
+	return Token(r), nil
+}
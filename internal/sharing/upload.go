@@ -0,0 +1,147 @@
+package sharing
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+	"strings"
+	"sync"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Upload puts the file into the folder of the upload sharing sid as its creator and returns the
+// name it is stored with, a file with the same name gets a numbered name instead of being replaced
+func Upload(ctx context.Context, sid, pwd, token string, file *stream.FileStream) (string, error) {
+	name, err := upload(ctx, sid, pwd, token, file)
+	if err != nil {
+		log.Warnf("failed upload to sharing %s: %s", sid, err)
+		return "", err
+	}
+	return name, nil
+}
+
+var (
+	dirLocksMu sync.Mutex
+	dirLocks   = map[string]*dirLock{}
+)
+
+type dirLock struct {
+	sync.Mutex
+	refs int
+}
+
+// lockDir serializes the uploads into the folder dir of the sharing sid, from picking a free
+// name until the file is put, so that two files of the same name don't replace each other
+func lockDir(sid, dir string) func() {
+	key := sid + ":" + dir
+	dirLocksMu.Lock()
+	l, ok := dirLocks[key]
+	if !ok {
+		l = &dirLock{}
+		dirLocks[key] = l
+	}
+	l.refs++
+	dirLocksMu.Unlock()
+	l.Lock()
+	return func() {
+		l.Unlock()
+		dirLocksMu.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(dirLocks, key)
+		}
+		dirLocksMu.Unlock()
+	}
+}
+
+func upload(ctx context.Context, sid, pwd, token string, file *stream.FileStream) (string, error) {
+	sharing, err := op.GetSharingById(sid)
+	if err != nil {
+		return "", errors.WithStack(errs.SharingNotFound)
+	}
+	if !sharing.Valid() {
+		return "", errors.WithStack(errs.InvalidSharing)
+	}
+	if !sharing.IsUpload() {
+		return "", errors.WithStack(errs.NotUploadSharing)
+	}
+	if err := Verify(sharing, pwd, token); err != nil {
+		return "", err
+	}
+	name := file.GetName()
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", errors.Errorf("invalid file name: %s", name)
+	}
+	size := file.GetSize()
+	if size < 0 && (sharing.MaxFileSize > 0 || sharing.MaxTotalBytes > 0) {
+		return "", errors.New("the size of the file is required")
+	}
+	if err := reserve(sharing, name, size); err != nil {
+		return "", err
+	}
+	ctx = context.WithValue(ctx, conf.UserKey, sharing.Creator)
+	dir := sharing.Files[0]
+	unlock := lockDir(sharing.ID, dir)
+	defer unlock()
+	free, err := freeName(ctx, dir, name)
+	if err == nil {
+		if free != name {
+			file.Obj = &model.ObjWrapName{Name: free, Obj: file.Obj}
+		}
+		err = fs.PutDirectly(ctx, dir, file)
+	}
+	if err != nil {
+		release(sharing, size)
+		return "", err
+	}
+	return free, nil
+}
+
+// reserve counts the size against the total bytes of the sharing before the upload, the
+// database checks the total again so that concurrent uploads can't exceed it together
+func reserve(sharing *model.Sharing, name string, size int64) error {
+	if err := sharing.CheckUpload(name, size); err != nil {
+		return err
+	}
+	if size <= 0 {
+		return nil
+	}
+	ok, err := op.ReserveSharingUpload(sharing, size)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("the sharing has no space left")
+	}
+	return nil
+}
+
+func release(sharing *model.Sharing, size int64) {
+	if size <= 0 {
+		return
+	}
+	if err := op.ReleaseSharingUpload(sharing, size); err != nil {
+		log.Errorf("failed release %d bytes of sharing %s: %+v", size, sharing.ID, err)
+	}
+}
+
+// freeName returns name, or name (n) with the lowest n no file in dir is named with
+func freeName(ctx context.Context, dir, name string) (string, error) {
+	ext := stdpath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	candidate := name
+	for i := 1; i <= 1000; i++ {
+		if obj, _ := fs.Get(ctx, stdpath.Join(dir, candidate), &fs.GetArgs{NoLog: true}); obj == nil {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s (%d)%s", base, i, ext)
+	}
+	return "", errors.Errorf("too many files named %s", name)
+}
@@ -0,0 +1,37 @@
+package sign
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/pkg/sign"
+)
+
+func linkData(id uint, path string) string {
+	return fmt.Sprintf("%s#link%d", path, id)
+}
+
+// Link signs the signed link with the id for the file at path until it expires
+func Link(id uint, path string, expires time.Time) string {
+	once.Do(Instance)
+	return fmt.Sprintf("%d.%s", id, instance.Sign(linkData(id, path), expires.Unix()))
+}
+
+// VerifyLink returns the id of the signed link the sign s was made for, if it is for path
+func VerifyLink(path, s string) (uint, error) {
+	once.Do(Instance)
+	idStr, signature, ok := strings.Cut(s, ".")
+	if !ok {
+		return 0, sign.ErrSignInvalid
+	}
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		return 0, sign.ErrSignInvalid
+	}
+	if err := instance.Verify(linkData(uint(id), path), signature); err != nil {
+		return 0, err
+	}
+	return uint(id), nil
+}
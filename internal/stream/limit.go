@@ -34,6 +34,26 @@ var (
 	ServerUploadLimit   Limiter
 )
 
+// BlockBurstLimiter waits for more tokens than its burst in several rounds instead of failing
+type BlockBurstLimiter struct {
+	*rate.Limiter
+}
+
+func (l BlockBurstLimiter) WaitN(ctx context.Context, total int) error {
+	for total > 0 {
+		n := l.Burst()
+		if l.Limiter.Limit() == rate.Inf || n > total {
+			n = total
+		}
+		err := l.Limiter.WaitN(ctx, n)
+		if err != nil {
+			return err
+		}
+		total -= n
+	}
+	return nil
+}
+
 type RateLimitReader struct {
 	io.Reader
 	Limiter Limiter
@@ -0,0 +1,167 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/drivers/base"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/sign"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	queueSize   = 1024
+	workers     = 4
+	maxAttempts = 6
+	// retryDelay doubles after every failed attempt
+	retryDelay     = 30 * time.Second
+	requestTimeout = 30 * time.Second
+)
+
+var (
+	queue   chan uint
+	queueMu sync.RWMutex
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+)
+
+// Start starts the delivery workers and resumes the deliveries left pending by the last run
+func Start() {
+	queueMu.Lock()
+	defer queueMu.Unlock()
+	if queue != nil {
+		return
+	}
+	queue = make(chan uint, queueSize)
+	ctx, cancel = context.WithCancel(context.Background())
+	for range workers {
+		wg.Add(1)
+		go work(queue)
+	}
+	go func() {
+		pending, err := db.GetPendingWebhookDeliveries()
+		if err != nil {
+			log.Errorf("failed resume webhook deliveries: %+v", err)
+			return
+		}
+		for _, d := range pending {
+			enqueue(d.ID)
+		}
+	}()
+}
+
+// Stop cancels the running deliveries and waits for the workers, the unfinished deliveries stay
+// pending until the next start
+func Stop() {
+	queueMu.Lock()
+	if queue == nil {
+		queueMu.Unlock()
+		return
+	}
+	close(queue)
+	queue = nil
+	cancel()
+	queueMu.Unlock()
+	wg.Wait()
+}
+
+func work(q chan uint) {
+	defer wg.Done()
+	for id := range q {
+		deliver(id)
+	}
+}
+
+// enqueue hands the delivery to the workers, it is retried later when they are too busy
+func enqueue(id uint) {
+	queueMu.RLock()
+	defer queueMu.RUnlock()
+	if queue == nil {
+		return
+	}
+	select {
+	case queue <- id:
+	default:
+		time.AfterFunc(retryDelay, func() { enqueue(id) })
+	}
+}
+
+func enqueueNew(webhookId uint, event, payload string) error {
+	d := &model.WebhookDelivery{WebhookId: webhookId, Event: event, Payload: payload, Status: model.DeliveryPending}
+	if err := db.CreateWebhookDelivery(d); err != nil {
+		return err
+	}
+	enqueue(d.ID)
+	return nil
+}
+
+func deliver(id uint) {
+	d, err := db.GetWebhookDeliveryById(id)
+	if err != nil || d.Status != model.DeliveryPending {
+		// the webhook and its deliveries are deleted, or it is delivered already
+		return
+	}
+	w, err := db.GetWebhookById(d.WebhookId)
+	if err != nil {
+		return
+	}
+	d.Attempts++
+	d.StatusCode, err = post(w, d)
+	if err == nil {
+		d.Status, d.Error = model.DeliverySucceeded, ""
+	} else {
+		d.Error = err.Error()
+		if d.Attempts >= maxAttempts {
+			d.Status = model.DeliveryFailed
+		} else if ctx.Err() == nil {
+			time.AfterFunc(retryDelay<<(d.Attempts-1), func() { enqueue(d.ID) })
+		}
+	}
+	if err := db.UpdateWebhookDelivery(d); err != nil {
+		log.Errorf("failed update webhook delivery %d: %+v", d.ID, err)
+	}
+}
+
+// Signature signs the payload sent at the unix time timestamp with the secret of a webhook. It is the
+// url safe base64 of the HMAC-SHA256 of payload:timestamp, followed by :timestamp, receivers should
+// reject old timestamps to prevent replays.
+func Signature(secret, payload string, timestamp int64) string {
+	return sign.HMACSign{SecretKey: []byte(secret)}.Sign(payload, timestamp)
+}
+
+// post sends the delivery once and returns the status code of the response, any status but 2xx fails
+func post(w *model.Webhook, d *model.WebhookDelivery) (int, error) {
+	reqCtx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, w.URL, strings.NewReader(d.Payload))
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "OpenList-Webhook")
+	req.Header.Set("X-OpenList-Event", d.Event)
+	req.Header.Set("X-OpenList-Delivery", strconv.FormatUint(uint64(d.ID), 10))
+	if w.Secret != "" {
+		req.Header.Set("X-OpenList-Signature", Signature(w.Secret, d.Payload, time.Now().Unix()))
+	}
+	res, err := base.HttpClient.Do(req)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64*1024))
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return res.StatusCode, fmt.Errorf("unexpected status: %s", res.Status)
+	}
+	return res.StatusCode, nil
+}
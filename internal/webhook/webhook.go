@@ -0,0 +1,214 @@
+// Package webhook posts filesystem, share and task events to the urls configured by the admin.
+// Every event sent to a webhook is stored as a delivery first, so that failed deliveries are
+// retried with backoff, survive a restart and can be inspected later.
+package webhook
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Event is the json payload posted to the webhooks
+type Event struct {
+	Event    string    `json:"event"`
+	Time     time.Time `json:"time"`
+	Username string    `json:"username,omitempty"`
+	Path     string    `json:"path,omitempty"`
+	DstPath  string    `json:"dst_path,omitempty"`
+	Size     int64     `json:"size,omitempty"`
+	// Files are the shared paths of a share event
+	Files   []string `json:"files,omitempty"`
+	ShareId string   `json:"share_id,omitempty"`
+	URL     string   `json:"url,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// Match reports whether the event is about one of the mount paths or below, every event matches no paths
+func (e *Event) Match(paths []string) bool {
+	if len(paths) == 0 {
+		return true
+	}
+	for _, p := range append([]string{e.Path, e.DstPath}, e.Files...) {
+		if p == "" {
+			continue
+		}
+		for _, prefix := range paths {
+			if utils.IsSubPath(prefix, p) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var (
+	hooks   []model.Webhook
+	loaded  bool
+	hooksMu sync.RWMutex
+)
+
+// getWebhooks returns the cached webhooks, they are loaded on first use
+func getWebhooks() ([]model.Webhook, error) {
+	hooksMu.RLock()
+	if loaded {
+		defer hooksMu.RUnlock()
+		return hooks, nil
+	}
+	hooksMu.RUnlock()
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	if !loaded {
+		res, err := db.GetWebhooks()
+		if err != nil {
+			return nil, err
+		}
+		hooks, loaded = res, true
+	}
+	return hooks, nil
+}
+
+func clearWebhooks() {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	hooks, loaded = nil, false
+}
+
+func GetWebhooks() ([]model.Webhook, error) {
+	return db.GetWebhooks()
+}
+
+func GetWebhookById(id uint) (*model.Webhook, error) {
+	return db.GetWebhookById(id)
+}
+
+func checkWebhook(w *model.Webhook) error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.Errorf("invalid url: %s", w.URL)
+	}
+	if len(w.Events) == 0 {
+		return errors.New("the webhook has no events")
+	}
+	for _, e := range w.Events {
+		if !utils.SliceContains(model.WebhookEvents, e) {
+			return errors.Errorf("unknown event: %s", e)
+		}
+	}
+	for i, p := range w.Paths {
+		w.Paths[i] = utils.FixAndCleanPath(p)
+	}
+	return nil
+}
+
+func CreateWebhook(w *model.Webhook) error {
+	if err := checkWebhook(w); err != nil {
+		return err
+	}
+	defer clearWebhooks()
+	return db.CreateWebhook(w)
+}
+
+func UpdateWebhook(w *model.Webhook) error {
+	if _, err := db.GetWebhookById(w.ID); err != nil {
+		return err
+	}
+	if err := checkWebhook(w); err != nil {
+		return err
+	}
+	defer clearWebhooks()
+	return db.UpdateWebhook(w)
+}
+
+// DeleteWebhookById deletes the webhook with its deliveries, the pending ones are dropped
+func DeleteWebhookById(id uint) error {
+	defer clearWebhooks()
+	return db.DeleteWebhookById(id)
+}
+
+func GetDeliveries(webhookId uint, pageIndex, pageSize int) ([]model.WebhookDelivery, int64, error) {
+	return db.GetWebhookDeliveries(webhookId, pageIndex, pageSize)
+}
+
+// Emit completes e with the time and the user of ctx and queues it for the webhooks subscribing to it.
+// Failures are only logged, an event must never fail the operation it is about.
+func Emit(ctx context.Context, e *Event) {
+	list, err := getWebhooks()
+	if err != nil {
+		log.Errorf("failed load webhooks: %+v", err)
+		return
+	}
+	var payload string
+	for i := range list {
+		w := &list[i]
+		if !w.Subscribes(e.Event) || !e.Match(w.Paths) {
+			continue
+		}
+		if payload == "" {
+			e.Time = time.Now()
+			if e.Username == "" {
+				if user, ok := ctx.Value(conf.UserKey).(*model.User); ok {
+					e.Username = user.Username
+				}
+			}
+			payload, err = utils.Json.MarshalToString(e)
+			if err != nil {
+				log.Errorf("failed marshal webhook event: %+v", err)
+				return
+			}
+		}
+		if err := enqueueNew(w.ID, e.Event, payload); err != nil {
+			log.Errorf("failed queue %s event for webhook [%s]: %+v", e.Event, w.Name, err)
+		}
+	}
+}
+
+// Ping sends a ping event to the webhook whatever it subscribes to
+func Ping(w *model.Webhook) (*model.WebhookDelivery, error) {
+	payload, err := utils.Json.MarshalToString(&Event{Event: model.EventPing, Time: time.Now()})
+	if err != nil {
+		return nil, err
+	}
+	d := &model.WebhookDelivery{WebhookId: w.ID, Event: model.EventPing, Payload: payload, Status: model.DeliveryPending}
+	if err := db.CreateWebhookDelivery(d); err != nil {
+		return nil, err
+	}
+	enqueue(d.ID)
+	return d, nil
+}
+
+// Redeliver sends a delivery again with fresh attempts, whatever its outcome was
+func Redeliver(id uint) error {
+	d, err := db.GetWebhookDeliveryById(id)
+	if err != nil {
+		return err
+	}
+	d.Status, d.Attempts, d.StatusCode, d.Error = model.DeliveryPending, 0, 0, ""
+	if err := db.UpdateWebhookDelivery(d); err != nil {
+		return err
+	}
+	enqueue(d.ID)
+	return nil
+}
+
+// Purge deletes the finished deliveries older than the retention
+func Purge() error {
+	days := setting.GetInt(conf.WebhookDeliveryRetentionDays, 30)
+	if days <= 0 {
+		return nil
+	}
+	n, err := db.DeleteWebhookDeliveriesBefore(time.Now().AddDate(0, 0, -days))
+	if n > 0 {
+		log.Infof("purged %d webhook deliveries older than %d days", n, days)
+	}
+	return err
+}
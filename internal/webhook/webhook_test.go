@@ -0,0 +1,24 @@
+package webhook
+
+import "testing"
+
+func TestEventMatch(t *testing.T) {
+	tests := []struct {
+		event *Event
+		paths []string
+		want  bool
+	}{
+		{&Event{Path: "/any/file"}, nil, true},
+		{&Event{Path: "/inbox/a.pdf"}, []string{"/inbox"}, true},
+		{&Event{Path: "/inbox"}, []string{"/inbox"}, true},
+		{&Event{Path: "/inboxes/a.pdf"}, []string{"/inbox"}, false},
+		{&Event{Path: "/tmp/a.pdf", DstPath: "/inbox"}, []string{"/inbox"}, true},
+		{&Event{Files: []string{"/docs", "/inbox/x"}}, []string{"/inbox"}, true},
+		{&Event{URL: "https://example.com/a"}, []string{"/inbox"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.event.Match(tt.paths); got != tt.want {
+			t.Errorf("Match(%+v, %v) = %v, want %v", tt.event, tt.paths, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package common
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	auditDetailKey = "audit_detail"
+	auditErrorKey  = "audit_error"
+)
+
+// SetAuditDetail tells the audit log what an admin request changed
+func SetAuditDetail(c *gin.Context, format string, args ...any) {
+	c.Set(auditDetailKey, fmt.Sprintf(format, args...))
+}
+
+func GetAuditDetail(c *gin.Context) string {
+	return c.GetString(auditDetailKey)
+}
+
+// GetAuditError returns the message of the error response of the request, empty if none was sent
+func GetAuditError(c *gin.Context) string {
+	return c.GetString(auditErrorKey)
+}
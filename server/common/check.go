@@ -23,7 +23,10 @@ func CanRead(user *model.User, meta *model.Meta, path string) bool {
 	if user == nil {
 		return true
 	}
-	if meta != nil && len(meta.ReadUsers) > 0 && !slices.Contains(meta.ReadUsers, user.ID) && MetaCoversPath(meta.Path, path, meta.ReadUsersSub) {
+	if !user.CanReadContent() {
+		return false
+	}
+	if meta != nil && !isListedUser(user, meta.ReadUsers, meta.ReadGroups) && MetaCoversPath(meta.Path, path, meta.ReadUsersSub) {
 		return false
 	}
 	return true
@@ -34,12 +37,25 @@ func CanWrite(user *model.User, meta *model.Meta, path string) bool {
 	if user == nil {
 		return true
 	}
-	if meta != nil && len(meta.WriteUsers) > 0 && !slices.Contains(meta.WriteUsers, user.ID) && MetaCoversPath(meta.Path, path, meta.WriteUsersSub) {
+	if meta != nil && !isListedUser(user, meta.WriteUsers, meta.WriteGroups) && MetaCoversPath(meta.Path, path, meta.WriteUsersSub) {
 		return false
 	}
 	return true
 }
 
+// isListedUser reports whether the user or one of its groups is listed, empty lists allow everyone
+func isListedUser(user *model.User, users, groups []uint) bool {
+	if len(users) == 0 && len(groups) == 0 {
+		return true
+	}
+	if slices.Contains(users, user.ID) {
+		return true
+	}
+	return slices.ContainsFunc(user.Groups, func(id uint) bool {
+		return slices.Contains(groups, id)
+	})
+}
+
 func CanWriteContentBypassUserPerms(meta *model.Meta, path string) bool {
 	if meta == nil || !meta.Write {
 		return false
@@ -247,6 +247,35 @@ func TestCanRead(t *testing.T) {
 			want:   false,
 			reason: "user ID 5 is not in ReadUsers list and path matches",
 		},
+		{
+			name: "user in a ReadGroups group",
+			user: &model.User{
+				ID:     5,
+				Groups: []uint{7},
+			},
+			meta: &model.Meta{
+				Path:       "/folder",
+				ReadUsers:  []uint{1, 2, 3},
+				ReadGroups: []uint{7},
+			},
+			path:   "/folder",
+			want:   true,
+			reason: "group 7 of user ID 5 is in ReadGroups list",
+		},
+		{
+			name: "user not in ReadGroups groups",
+			user: &model.User{
+				ID:     5,
+				Groups: []uint{8},
+			},
+			meta: &model.Meta{
+				Path:       "/folder",
+				ReadGroups: []uint{7},
+			},
+			path:   "/folder",
+			want:   false,
+			reason: "ReadGroups alone restricts the path and group 8 is not listed",
+		},
 		{
 			name: "user not in ReadUsers list with ReadUsersSub=true for sub path",
 			user: &model.User{
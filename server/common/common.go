@@ -84,6 +84,7 @@ func ErrorWithDataResp(c *gin.Context, err error, code int, data interface{}, l
 			log.Errorf("%v", err)
 		}
 	}
+	c.Set(auditErrorKey, hidePrivacy(err.Error()))
 	c.JSON(200, Resp[interface{}]{
 		Code:    code,
 		Message: hidePrivacy(err.Error()),
@@ -96,6 +97,7 @@ func ErrorStrResp(c *gin.Context, str string, code int, l ...bool) {
 	if len(l) != 0 && l[0] {
 		log.Error(str)
 	}
+	c.Set(auditErrorKey, hidePrivacy(str))
 	c.JSON(200, Resp[interface{}]{
 		Code:    code,
 		Message: hidePrivacy(str),
@@ -19,6 +19,12 @@ import (
 var ErrFailedLdapAuth = errors.New("failed to auth")
 
 func HandleLdapLogin(username, password string) error {
+	_, err := HandleLdapLoginWithGroups(username, password)
+	return err
+}
+
+// HandleLdapLoginWithGroups authenticates the user and returns the values of its group attribute
+func HandleLdapLoginWithGroups(username, password string) ([]string, error) {
 	// Auth start
 	ldapServer := setting.GetStr(conf.LdapServer)
 	skipTlsVerify := setting.GetBool(conf.LdapSkipTlsVerify)
@@ -26,11 +32,12 @@ func HandleLdapLogin(username, password string) error {
 	ldapManagerPassword := setting.GetStr(conf.LdapManagerPassword)
 	ldapUserSearchBase := setting.GetStr(conf.LdapUserSearchBase)
 	ldapUserSearchFilter := setting.GetStr(conf.LdapUserSearchFilter) // (uid=%s)
+	ldapGroupAttribute := setting.GetStr(conf.LdapGroupAttribute)     // memberOf
 
 	// Connect to LdapServer
 	l, err := dial(ldapServer, skipTlsVerify)
 	if err != nil {
-		return errors.WithMessagef(err, "failed to connect to LDAP")
+		return nil, errors.WithMessagef(err, "failed to connect to LDAP")
 	}
 	defer l.Close()
 
@@ -38,35 +45,43 @@ func HandleLdapLogin(username, password string) error {
 	if ldapManagerDN != "" && ldapManagerPassword != "" {
 		err = l.Bind(ldapManagerDN, ldapManagerPassword)
 		if err != nil {
-			return errors.WithMessagef(err, "failed to bind to LDAP")
+			return nil, errors.WithMessagef(err, "failed to bind to LDAP")
 		}
 	}
 
 	// Search for the given username
+	attributes := []string{"dn"}
+	if ldapGroupAttribute != "" {
+		attributes = append(attributes, ldapGroupAttribute)
+	}
 	searchRequest := ldap.NewSearchRequest(
 		ldapUserSearchBase,
 		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
 		fmt.Sprintf(ldapUserSearchFilter, ldap.EscapeFilter(username)),
-		[]string{"dn"},
+		attributes,
 		nil,
 	)
 	sr, err := l.Search(searchRequest)
 	if err != nil {
-		return errors.WithMessagef(err, "failed login ldap: LDAP search failed")
+		return nil, errors.WithMessagef(err, "failed login ldap: LDAP search failed")
 	}
 	if len(sr.Entries) != 1 {
-		return errors.New("failed login ldap: user does not exist or too many entries returned")
+		return nil, errors.New("failed login ldap: user does not exist or too many entries returned")
 	}
 	userDN := sr.Entries[0].DN
+	var groups []string
+	if ldapGroupAttribute != "" {
+		groups = sr.Entries[0].GetAttributeValues(ldapGroupAttribute)
+	}
 
 	// Bind as the user to verify their password
 	err = l.Bind(userDN, password)
 	if err != nil {
-		return errors.WithMessagef(ErrFailedLdapAuth, "%v", err)
+		return nil, errors.WithMessagef(ErrFailedLdapAuth, "%v", err)
 	}
 	log.Infof("LDAP auth successful for %s", username)
 	// Auth finished
-	return nil
+	return groups, nil
 }
 
 func LdapRegister(username string) (*model.User, error) {
@@ -90,6 +105,14 @@ func LdapRegister(username string) (*model.User, error) {
 	return user, nil
 }
 
+// SyncExternalGroups maps the LDAP groups or OIDC claims of the user onto its groups,
+// a failure is only logged so it never blocks the login
+func SyncExternalGroups(user *model.User, external []string) {
+	if err := op.SyncExternalGroups(user, external); err != nil {
+		log.Warnf("failed sync groups of user [%s]: %+v", user.Username, err)
+	}
+}
+
 func dial(ldapServer string, skipTlsVerify ...bool) (*ldap.Conn, error) {
 	tlsEnabled := false
 	if strings.HasPrefix(ldapServer, "ldaps://") {
@@ -14,6 +14,7 @@ import (
 	"sync"
 
 	"github.com/OpenListTeam/OpenList/v4/drivers/base"
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
@@ -113,6 +114,17 @@ func (d *FtpMainDriver) ClientDisconnected(cc ftpserver.ClientContext) {
 }
 
 func (d *FtpMainDriver) AuthUser(cc ftpserver.ClientContext, user, pass string) (ftpserver.ClientDriver, error) {
+	driver, err := d.authUser(cc, user, pass)
+	audit.Record(context.Background(), &model.AuditLog{
+		Operation: model.AuditLogin,
+		Username:  user,
+		Protocol:  model.ProtocolFTP,
+		IP:        cc.RemoteAddr().String(),
+	}, err)
+	return driver, err
+}
+
+func (d *FtpMainDriver) authUser(cc ftpserver.ClientContext, user, pass string) (ftpserver.ClientDriver, error) {
 	ip := cc.RemoteAddr().String()
 	count, ok := model.LoginCache.Get(ip)
 	if ok && count >= model.DefaultMaxAuthRetries {
@@ -156,6 +168,7 @@ func (d *FtpMainDriver) AuthUser(cc ftpserver.ClientContext, user, pass string)
 	}
 	ctx = context.WithValue(ctx, conf.ClientIPKey, ip)
 	ctx = context.WithValue(ctx, conf.ProxyHeaderKey, d.proxyHeader)
+	ctx = context.WithValue(ctx, conf.ProtocolKey, model.ProtocolFTP)
 	return ftp.NewAferoAdapter(ctx), nil
 }
 
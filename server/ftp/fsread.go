@@ -8,6 +8,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/fs"
@@ -40,8 +41,10 @@ func OpenDownload(ctx context.Context, reqPath string, offset int64) (*FileDownl
 	ip, _ := ctx.Value(conf.ClientIPKey).(string)
 	link, obj, err := fs.Link(ctx, reqPath, model.LinkArgs{IP: ip, Header: header})
 	if err != nil {
+		audit.LogDownload(ctx, reqPath, 0, err)
 		return nil, err
 	}
+	audit.LogDownload(ctx, reqPath, obj.GetSize(), nil)
 	ss, err := stream.NewSeekableStream(&stream.FileStream{
 		Obj: obj,
 		Ctx: ctx,
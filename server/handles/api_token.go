@@ -0,0 +1,168 @@
+package handles
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type APITokenCreateReq struct {
+	Name   string `json:"name" binding:"required"`
+	Scope  string `json:"scope"`
+	Access string `json:"access"`
+	// Permission masks the permissions of the user, all of them are kept if it is omitted
+	Permission *int32     `json:"permission"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+}
+
+type APITokenCreateResp struct {
+	model.APIToken
+	// Token is the bearer token and the webdav password, SecretKey the S3 secret access key.
+	// Both are only returned once.
+	Token     string `json:"token"`
+	SecretKey string `json:"secret_key"`
+}
+
+func CreateMyAPIToken(c *gin.Context) {
+	userObj, ok := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !ok || userObj.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	var req APITokenCreateReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorStrResp(c, "request invalid", 400)
+		return
+	}
+	t := &model.APIToken{
+		UserId:     userObj.ID,
+		Name:       req.Name,
+		Scope:      req.Scope,
+		Access:     req.Access,
+		Permission: -1,
+		ExpiresAt:  req.ExpiresAt,
+	}
+	if req.Permission != nil {
+		t.Permission = *req.Permission
+	}
+	raw, secret, err := op.CreateAPIToken(t)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c, APITokenCreateResp{
+		APIToken:  *t,
+		Token:     raw,
+		SecretKey: secret,
+	})
+}
+
+func ListMyAPITokens(c *gin.Context) {
+	userObj, ok := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !ok || userObj.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	listAPITokens(c, userObj)
+}
+
+func RevokeMyAPIToken(c *gin.Context) {
+	userObj, ok := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !ok || userObj.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	tokenId, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorStrResp(c, "id format invalid", 400)
+		return
+	}
+	t, err := op.GetAPITokenByIdAndUserId(uint(tokenId), userObj.ID)
+	if err != nil {
+		common.ErrorStrResp(c, "failed to get api token", 404)
+		return
+	}
+	if err = op.RevokeAPIToken(t); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func DeleteMyAPIToken(c *gin.Context) {
+	userObj, ok := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !ok || userObj.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	tokenId, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorStrResp(c, "id format invalid", 400)
+		return
+	}
+	t, err := op.GetAPITokenByIdAndUserId(uint(tokenId), userObj.ID)
+	if err != nil {
+		common.ErrorStrResp(c, "failed to get api token", 404)
+		return
+	}
+	if err = op.DeleteAPITokenById(t.ID); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func ListAPITokens(c *gin.Context) {
+	userId, err := strconv.Atoi(c.Query("uid"))
+	if err != nil {
+		common.ErrorStrResp(c, "user id format invalid", 400)
+		return
+	}
+	userObj, err := op.GetUserById(uint(userId))
+	if err != nil {
+		common.ErrorStrResp(c, "user invalid", 404)
+		return
+	}
+	listAPITokens(c, userObj)
+}
+
+func RevokeAPIToken(c *gin.Context) {
+	tokenId, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorStrResp(c, "id format invalid", 400)
+		return
+	}
+	t, err := op.GetAPITokenById(uint(tokenId))
+	if err != nil {
+		common.ErrorStrResp(c, "failed to get api token", 404)
+		return
+	}
+	if err = op.RevokeAPIToken(t); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func listAPITokens(c *gin.Context, userObj *model.User) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	tokens, total, err := op.GetAPITokensByUserId(userObj.ID, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: tokens,
+		Total:   total,
+	})
+}
@@ -0,0 +1,71 @@
+package handles
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+func ListAuditLogs(c *gin.Context) {
+	var req model.AuditLogQuery
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	logs, total, err := audit.GetAuditLogs(&req)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: logs,
+		Total:   total,
+	})
+}
+
+// ExportAuditLogs streams the logs matching the query as JSON lines, the oldest first
+func ExportAuditLogs(c *gin.Context) {
+	var req model.AuditLogQuery
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="audit-%s.jsonl"`, time.Now().Format("20060102-150405")))
+	c.Status(200)
+	enc := utils.Json.NewEncoder(c.Writer)
+	err := audit.EachAuditLogs(&req, func(logs []model.AuditLog) error {
+		for i := range logs {
+			if err := enc.Encode(&logs[i]); err != nil {
+				return err
+			}
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	if err != nil {
+		// the status is sent already, the client sees a truncated export
+		log.Errorf("failed export audit logs: %+v", err)
+	}
+}
+
+// auditLogin records the outcome of a login request once its response is sent
+func auditLogin(c *gin.Context, username, method string) {
+	var err error
+	if msg := common.GetAuditError(c); msg != "" {
+		err = errors.New(msg)
+	}
+	audit.Record(c.Request.Context(), &model.AuditLog{
+		Operation: model.AuditLogin,
+		Username:  username,
+		Detail:    method,
+	}, err)
+}
@@ -41,6 +41,7 @@ func LoginHash(c *gin.Context) {
 }
 
 func loginHash(c *gin.Context, req *LoginReq) {
+	defer auditLogin(c, req.Username, "password")
 	// check count of login
 	ip := c.ClientIP()
 	count, ok := model.LoginCache.Get(ip)
@@ -94,6 +95,10 @@ func CurrentUser(c *gin.Context) {
 		User: *user,
 	}
 	userResp.Password = ""
+	// the client shows what the user can do, including what its groups allow and limit
+	userResp.Permission = user.EffectivePermission()
+	userResp.BasePath = user.GetBasePath()
+	userResp.QuotaBytes, userResp.QuotaFiles = user.EffectiveQuota()
 	if userResp.OtpSecret != "" {
 		userResp.Otp = true
 	}
@@ -0,0 +1,135 @@
+package handles
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/dedup"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type StartDuplicateScanReq struct {
+	// Paths to look for duplicates in, all storages if empty
+	Paths   []string `json:"paths"`
+	MinSize int64    `json:"min_size"`
+}
+
+func StartDuplicateScan(c *gin.Context) {
+	var req StartDuplicateScanReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	t, err := dedup.StartScan(c.Request.Context(), req.Paths, req.MinSize)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"task": getTaskInfo(t),
+	})
+}
+
+func ListDuplicateScans(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	scans, total, err := op.GetDuplicateScans(req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: scans,
+		Total:   total,
+	})
+}
+
+func DeleteDuplicateScan(c *gin.Context) {
+	idStr := c.Query("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := op.DeleteDuplicateScanById(uint(id)); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+type ListDuplicateSetsReq struct {
+	model.PageReq
+	ScanId uint `json:"scan_id" form:"scan_id" binding:"required"`
+}
+
+func ListDuplicateSets(c *gin.Context) {
+	var req ListDuplicateSetsReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	sets, total, err := op.GetDuplicateSetsByScanId(req.ScanId, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: sets,
+		Total:   total,
+	})
+}
+
+type ResolveDuplicatesReq struct {
+	// Action is remove or hard_link
+	Action string `json:"action"`
+	// AllowPartial allows resolving the sets only compared by the head and the tail of the files
+	AllowPartial bool `json:"allow_partial"`
+	Items        []struct {
+		SetId uint   `json:"set_id"`
+		Keep  string `json:"keep"`
+	} `json:"items"`
+}
+
+type ResolveDuplicatesResult struct {
+	SetId uint   `json:"set_id"`
+	Error string `json:"error,omitempty"`
+}
+
+func ResolveDuplicates(c *gin.Context) {
+	var req ResolveDuplicatesReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if req.Action != model.DuplicateActionRemove && req.Action != model.DuplicateActionHardLink {
+		common.ErrorStrResp(c, fmt.Sprintf("unknown action: %s", req.Action), 400)
+		return
+	}
+	results := make([]ResolveDuplicatesResult, 0, len(req.Items))
+	failed := 0
+	for _, item := range req.Items {
+		res := ResolveDuplicatesResult{SetId: item.SetId}
+		set, err := op.GetDuplicateSetById(item.SetId)
+		if err == nil {
+			err = dedup.Resolve(c.Request.Context(), set, item.Keep, req.Action, req.AllowPartial)
+		}
+		if err != nil {
+			res.Error = err.Error()
+			failed++
+		}
+		results = append(results, res)
+	}
+	common.SuccessResp(c, gin.H{
+		"message": fmt.Sprintf("%d of %d set(s) resolved", len(req.Items)-failed, len(req.Items)),
+		"results": results,
+	})
+}
@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"net/http"
 	stdpath "path"
 	"strconv"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/driver"
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
@@ -30,16 +32,17 @@ func Down(c *gin.Context) {
 		common.ErrorPage(c, err, 500)
 		return
 	}
-	if common.ShouldProxy(storage, filename) {
+	if common.ShouldProxy(storage, filename) || mustProxy(c) {
 		Proxy(c)
 		return
 	} else {
-		link, _, err := fs.Link(c.Request.Context(), rawPath, model.LinkArgs{
+		link, file, err := fs.Link(c.Request.Context(), rawPath, model.LinkArgs{
 			IP:       c.ClientIP(),
 			Header:   c.Request.Header,
 			Type:     c.Query("type"),
 			Redirect: true,
 		})
+		auditDown(c, rawPath, file, err)
 		if err != nil {
 			common.ErrorPage(c, err, 500)
 			return
@@ -56,8 +59,8 @@ func Proxy(c *gin.Context) {
 		common.ErrorPage(c, err, 500)
 		return
 	}
-	if canProxy(storage, filename) {
-		if _, ok := c.GetQuery("d"); !ok {
+	if mustProxy(c) || canProxy(storage, filename) {
+		if _, ok := c.GetQuery("d"); !ok && !mustProxy(c) {
 			if url := common.GenerateDownProxyURL(storage.GetStorage(), rawPath); url != "" {
 				c.Redirect(302, url)
 				return
@@ -67,6 +70,7 @@ func Proxy(c *gin.Context) {
 			Header: c.Request.Header,
 			Type:   c.Query("type"),
 		})
+		auditDown(c, rawPath, file, err)
 		if err != nil {
 			common.ErrorPage(c, err, 500)
 			return
@@ -78,6 +82,24 @@ func Proxy(c *gin.Context) {
 	}
 }
 
+// mustProxy reports whether the request uses a signed link that is only enforced by proxying
+func mustProxy(c *gin.Context) bool {
+	l, ok := c.Request.Context().Value(conf.SignedLinkKey).(*model.SignedLink)
+	return ok && l.MustProxy()
+}
+
+// auditDown records the download of a file once its link is resolved, HEAD requests are not downloads
+func auditDown(c *gin.Context, rawPath string, file model.Obj, err error) {
+	if c.Request.Method != http.MethodGet {
+		return
+	}
+	var size int64
+	if file != nil {
+		size = file.GetSize()
+	}
+	audit.LogDownload(c.Request.Context(), rawPath, size, err)
+}
+
 func redirect(c *gin.Context, link *model.Link) {
 	defer link.Close()
 	var err error
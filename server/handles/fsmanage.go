@@ -1,6 +1,7 @@
 package handles
 
 import (
+	"context"
 	"fmt"
 	stdpath "path"
 	"strings"
@@ -64,6 +65,15 @@ type MoveCopyReq struct {
 	Overwrite    bool     `json:"overwrite"`
 	SkipExisting bool     `json:"skip_existing"`
 	Merge        bool     `json:"merge"`
+	Verify       bool     `json:"verify"`
+}
+
+// transferCtx marks the request context to verify the transferred files if asked to
+func transferCtx(c *gin.Context, verify bool) context.Context {
+	if verify {
+		return context.WithValue(c.Request.Context(), conf.TransferVerifyKey, struct{}{})
+	}
+	return c.Request.Context()
 }
 
 // FsMove performs batch move (individual item permission checks skipped for performance).
@@ -142,7 +152,7 @@ func FsMove(c *gin.Context) {
 	// All validation will be done asynchronously in the background
 	var addedTasks []task.TaskExtensionInfo
 	for i, p := range validPaths {
-		t, err := fs.Move(c.Request.Context(), p, dstDir, len(validPaths) > i+1)
+		t, err := fs.Move(transferCtx(c, req.Verify), p, dstDir, len(validPaths) > i+1)
 		if t != nil {
 			addedTasks = append(addedTasks, t)
 		}
@@ -243,9 +253,9 @@ func FsCopy(c *gin.Context) {
 	for i, p := range validPaths {
 		var t task.TaskExtensionInfo
 		if req.Merge {
-			t, err = fs.Merge(c.Request.Context(), p, dstDir, len(validPaths) > i+1)
+			t, err = fs.Merge(transferCtx(c, req.Verify), p, dstDir, len(validPaths) > i+1)
 		} else {
-			t, err = fs.Copy(c.Request.Context(), p, dstDir, len(validPaths) > i+1)
+			t, err = fs.Copy(transferCtx(c, req.Verify), p, dstDir, len(validPaths) > i+1)
 		}
 		if t != nil {
 			addedTasks = append(addedTasks, t)
@@ -304,13 +304,15 @@ func FsGet(c *gin.Context, req *FsGetReq, user *model.User) {
 		return
 	}
 	var rawURL string
+	// the links and the sign are only handed out to users that may read the file
+	readable := op.ACLAllows(user, model.ACLRead, reqPath)
 
 	storage, err := fs.GetStorage(reqPath, &fs.GetStoragesArgs{})
 	provider, ok := model.GetProvider(obj)
 	if !ok && err == nil {
 		provider = storage.Config().Name
 	}
-	if !obj.IsDir() {
+	if !obj.IsDir() && readable {
 		if err != nil {
 			common.ErrorResp(c, err, 500)
 			return
@@ -356,6 +358,10 @@ func FsGet(c *gin.Context, req *FsGetReq, user *model.User) {
 	parentMeta, _ := op.GetNearestMeta(parentPath)
 	thumb, _ := model.GetThumb(obj)
 	mountDetails, _ := model.GetStorageDetails(obj)
+	objSign := ""
+	if readable {
+		objSign = common.Sign(obj, parentPath, isEncrypt(meta, reqPath))
+	}
 	common.SuccessResp(c, FsGetResp{
 		ObjResp: ObjResp{
 			Name:         obj.GetName(),
@@ -365,7 +371,7 @@ func FsGet(c *gin.Context, req *FsGetReq, user *model.User) {
 			Created:      obj.CreateTime(),
 			HashInfoStr:  obj.GetHash().String(),
 			HashInfo:     obj.GetHash().Export(),
-			Sign:         common.Sign(obj, parentPath, isEncrypt(meta, reqPath)),
+			Sign:         objSign,
 			Type:         utils.GetFileType(obj.GetName()),
 			Thumb:        thumb,
 			MountDetails: mountDetails,
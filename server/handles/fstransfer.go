@@ -0,0 +1,177 @@
+package handles
+
+import (
+	"fmt"
+	stdpath "path"
+	"slices"
+	"sort"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/task"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type TransferPlanReq struct {
+	SrcDir      string   `json:"src_dir"`
+	DstDir      string   `json:"dst_dir"`
+	Names       []string `json:"names"`
+	Merge       bool     `json:"merge"`
+	CompareHash bool     `json:"compare_hash"`
+}
+
+type TransferSubmitReq struct {
+	SrcDir string `json:"src_dir"`
+	DstDir string `json:"dst_dir"`
+	// Paths are the entry paths of a plan, relative to both SrcDir and DstDir
+	Paths  []string `json:"paths"`
+	Move   bool     `json:"move"`
+	Verify bool     `json:"verify"`
+}
+
+// checkTransferDirs resolves the src and dst dirs of a transfer and checks the user can read the one and write the other
+func checkTransferDirs(c *gin.Context, user *model.User, reqSrcDir, reqDstDir string) (string, string, bool) {
+	srcDir, err := user.JoinPath(reqSrcDir)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return "", "", false
+	}
+	srcMeta, err := op.GetNearestMeta(srcDir)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		common.ErrorResp(c, err, 500, true)
+		return "", "", false
+	}
+	if !common.CanRead(user, srcMeta, srcDir) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return "", "", false
+	}
+	dstDir, err := user.JoinPath(reqDstDir)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return "", "", false
+	}
+	dstMeta, err := op.GetNearestMeta(dstDir)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		common.ErrorResp(c, err, 500, true)
+		return "", "", false
+	}
+	if !common.CanWrite(user, dstMeta, dstDir) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return "", "", false
+	}
+	return srcDir, dstDir, true
+}
+
+// FsTransferPlan reports what copying or merging the names from src dir into dst dir would do
+func FsTransferPlan(c *gin.Context) {
+	var req TransferPlanReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if len(req.Names) == 0 {
+		common.ErrorStrResp(c, "Empty file names", 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !user.CanCopy() && !user.CanMove() {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	srcDir, dstDir, ok := checkTransferDirs(c, user, req.SrcDir, req.DstDir)
+	if !ok {
+		return
+	}
+	for _, name := range req.Names {
+		base := stdpath.Base(name)
+		if base != name || base == "." || base == "/" || base == ".." {
+			common.ErrorStrResp(c, fmt.Sprintf("invalid file name [%s]", name), 400)
+			return
+		}
+	}
+	plan, err := fs.PlanTransfer(c.Request.Context(), srcDir, dstDir, req.Names, req.Merge, req.CompareHash)
+	if err != nil {
+		if errs.IsObjectNotFound(err) {
+			common.ErrorResp(c, err, 404)
+		} else {
+			common.ErrorResp(c, err, 500)
+		}
+		return
+	}
+	common.SuccessResp(c, plan)
+}
+
+// FsTransferSubmit copies or moves the selected entries of a plan one by one.
+// Dirs are only created, their children have to be selected to be transferred.
+func FsTransferSubmit(c *gin.Context) {
+	var req TransferSubmitReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if len(req.Paths) == 0 {
+		common.ErrorStrResp(c, "Empty paths", 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if req.Move && !user.CanMove() || !req.Move && !user.CanCopy() {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	srcDir, dstDir, ok := checkTransferDirs(c, user, req.SrcDir, req.DstDir)
+	if !ok {
+		return
+	}
+	rels := make([]string, 0, len(req.Paths))
+	for _, p := range req.Paths {
+		rel := utils.FixAndCleanPath(p)
+		if rel == "/" {
+			common.ErrorStrResp(c, fmt.Sprintf("invalid path [%s]", p), 400)
+			return
+		}
+		rels = append(rels, rel)
+	}
+	// parents go before their children
+	sort.Strings(rels)
+	rels = slices.Compact(rels)
+
+	var addedTasks []task.TaskExtensionInfo
+	for i, rel := range rels {
+		srcPath, dstPath := stdpath.Join(srcDir, rel), stdpath.Join(dstDir, rel)
+		obj, err := fs.Get(c.Request.Context(), srcPath, &fs.GetArgs{NoLog: true})
+		if err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+		if obj.IsDir() {
+			if err = fs.MakeDir(c.Request.Context(), dstPath); err != nil {
+				common.ErrorResp(c, err, 500)
+				return
+			}
+			continue
+		}
+		var t task.TaskExtensionInfo
+		if req.Move {
+			t, err = fs.Move(transferCtx(c, req.Verify), srcPath, stdpath.Dir(dstPath), len(rels) > i+1)
+		} else {
+			t, err = fs.Copy(transferCtx(c, req.Verify), srcPath, stdpath.Dir(dstPath), len(rels) > i+1)
+		}
+		if t != nil {
+			addedTasks = append(addedTasks, t)
+		}
+		if err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+	}
+	common.SuccessResp(c, gin.H{
+		"message": fmt.Sprintf("Successfully created %d task(s)", len(addedTasks)),
+		"tasks":   getTaskInfos(addedTasks),
+	})
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/search"
 	"github.com/OpenListTeam/OpenList/v4/internal/setting"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
@@ -54,16 +56,7 @@ func UpdateIndex(c *gin.Context) {
 		return
 	}
 	go func() {
-		ctx := context.Background()
-		for _, path := range req.Paths {
-			err := search.Del(ctx, path)
-			if err != nil {
-				log.Errorf("delete index on %s error: %+v", path, err)
-				return
-			}
-		}
-		err := search.BuildIndex(context.Background(), req.Paths,
-			conf.SlicesMap[conf.IgnorePaths], req.MaxDepth, false)
+		err := search.UpdateIndex(context.Background(), req.Paths, req.MaxDepth)
 		if err != nil {
 			log.Errorf("update index error: %+v", err)
 		}
@@ -71,6 +64,55 @@ func UpdateIndex(c *gin.Context) {
 	common.SuccessResp(c)
 }
 
+func ListStorageIndexes(c *gin.Context) {
+	infos, err := search.GetStorageIndexInfos()
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, infos)
+}
+
+type UpdateStorageIndexReq struct {
+	StorageId uint `json:"storage_id"`
+}
+
+func UpdateStorageIndex(c *gin.Context) {
+	var req UpdateStorageIndexReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if search.Running() {
+		common.ErrorStrResp(c, "index is running", 400)
+		return
+	}
+	if !search.Config(c).AutoUpdate {
+		common.ErrorStrResp(c, "update is not supported for current index", 400)
+		return
+	}
+	s, err := db.GetStorageById(req.StorageId)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	storage, err := op.GetStorageByMountPath(s.MountPath)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if search.StorageIndexRunning(req.StorageId) {
+		common.ErrorStrResp(c, "index of the storage is updating", 400)
+		return
+	}
+	go func() {
+		if err := search.UpdateStorageIndex(context.Background(), storage); err != nil {
+			log.Errorf("update index of storage %d error: %+v", req.StorageId, err)
+		}
+	}()
+	common.SuccessResp(c)
+}
+
 func StopIndex(c *gin.Context) {
 	quit := search.Quit.Load()
 	if quit == nil {
@@ -27,6 +27,7 @@ func LoginLdap(c *gin.Context) {
 		return
 	}
 
+	defer auditLogin(c, req.Username, "ldap")
 	// check count of login
 	ip := c.ClientIP()
 	count, ok := model.LoginCache.Get(ip)
@@ -36,7 +37,7 @@ func LoginLdap(c *gin.Context) {
 		return
 	}
 
-	err = common.HandleLdapLogin(req.Username, req.Password)
+	groups, err := common.HandleLdapLoginWithGroups(req.Username, req.Password)
 	if err != nil {
 		if errors.Is(err, common.ErrFailedLdapAuth) {
 			model.LoginCache.Set(ip, count+1)
@@ -55,6 +56,7 @@ func LoginLdap(c *gin.Context) {
 			return
 		}
 	}
+	common.SyncExternalGroups(user, groups)
 
 	// generate token
 	token, err := common.GenerateToken(user)
@@ -0,0 +1,68 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func GetMyQuota(c *gin.Context) {
+	userObj, ok := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !ok {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	info, err := op.GetQuotaInfo(userObj)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, info)
+}
+
+func GetUserQuota(c *gin.Context) {
+	userObj, ok := quotaUserFromQuery(c)
+	if !ok {
+		return
+	}
+	info, err := op.GetQuotaInfo(userObj)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, info)
+}
+
+func RescanUserQuota(c *gin.Context) {
+	userObj, ok := quotaUserFromQuery(c)
+	if !ok {
+		return
+	}
+	t, err := fs.RescanUsage(c.Request.Context(), userObj)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"task": getTaskInfo(t),
+	})
+}
+
+func quotaUserFromQuery(c *gin.Context) (*model.User, bool) {
+	userId, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorStrResp(c, "id format invalid", 400)
+		return nil, false
+	}
+	userObj, err := op.GetUserById(uint(userId))
+	if err != nil {
+		common.ErrorResp(c, err, 404)
+		return nil, false
+	}
+	return userObj, true
+}
@@ -44,29 +44,44 @@ func Search(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
+	req.Keywords, req.Filters, err = search.ParseQuery(req.Keywords)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
 	nodes, total, err := search.Search(c, req.SearchReq)
 	if err != nil {
 		common.ErrorResp(c, err, 500)
 		return
 	}
+	common.SuccessResp(c, common.PageResp{
+		Content: utils.MustSliceConvert(filterSearchNodes(user, nodes, req.Password), nodeToSearchResp),
+		Total:   total,
+	})
+}
+
+// filterSearchNodes drops the nodes the user can't see: out of its base path, behind a meta
+// password or in a dir the acl rules don't let it list
+func filterSearchNodes(user *model.User, nodes []model.SearchNode, password string) []model.SearchNode {
 	var filteredNodes []model.SearchNode
 	for _, node := range nodes {
-		if !strings.HasPrefix(node.Parent, user.BasePath) {
+		if !strings.HasPrefix(node.Parent, user.GetBasePath()) {
 			continue
 		}
 		meta, err := op.GetNearestMeta(node.Parent)
 		if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
 			continue
 		}
-		if !common.CanAccess(user, meta, path.Join(node.Parent, node.Name), req.Password) {
+		nodePath := path.Join(node.Parent, node.Name)
+		if !common.CanAccess(user, meta, nodePath, password) {
+			continue
+		}
+		if !op.ACLAllows(user, model.ACLList, nodePath) {
 			continue
 		}
 		filteredNodes = append(filteredNodes, node)
 	}
-	common.SuccessResp(c, common.PageResp{
-		Content: utils.MustSliceConvert(filteredNodes, nodeToSearchResp),
-		Total:   total,
-	})
+	return filteredNodes
 }
 
 func nodeToSearchResp(node model.SearchNode) SearchResp {
@@ -0,0 +1,47 @@
+package handles
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestFilterSearchNodesACL(t *testing.T) {
+	dB, err := gorm.Open(sqlite.Open("file:handles_search?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %+v", err)
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	db.Init(dB)
+	user := &model.User{Username: "bob", Role: model.GENERAL, BasePath: "/"}
+	if err := op.CreateUser(user); err != nil {
+		t.Fatalf("failed to create user: %+v", err)
+	}
+	t.Cleanup(func() { _ = op.DeleteUserById(user.ID) })
+	rule := &model.ACLRule{
+		Path:        "/secret",
+		SubjectType: model.ACLSubjectUser,
+		SubjectId:   user.ID,
+		Operations:  []string{model.ACLList},
+		Effect:      model.ACLDeny,
+	}
+	if err := op.CreateACLRule(rule); err != nil {
+		t.Fatalf("failed to create acl rule: %+v", err)
+	}
+	t.Cleanup(func() { _ = op.DeleteACLRuleById(rule.ID) })
+
+	nodes := []model.SearchNode{
+		{Parent: "/", Name: "secret", IsDir: true},
+		{Parent: "/secret/plans", Name: "a.txt"},
+		{Parent: "/public", Name: "b.txt"},
+	}
+	got := filterSearchNodes(user, nodes, "")
+	if len(got) != 1 || got[0].Name != "b.txt" {
+		t.Errorf("expected only the node outside the denied dir, got %+v", got)
+	}
+}
@@ -53,6 +53,12 @@ func SaveSettings(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
+	keys := make([]string, len(req))
+	for i, item := range req {
+		keys[i] = item.Key
+	}
+	// the values may be secrets, only the keys are audited
+	common.SetAuditDetail(c, "%s", strings.Join(keys, ", "))
 	if err := op.SaveSettingItems(req); err != nil {
 		common.ErrorResp(c, err, 500)
 	} else {
@@ -2,10 +2,14 @@ package handles
 
 import (
 	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
 	stdpath "path"
 	"strings"
 	"time"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/driver"
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
@@ -13,11 +17,13 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/setting"
 	"github.com/OpenListTeam/OpenList/v4/internal/sharing"
+	"github.com/OpenListTeam/OpenList/v4/internal/webhook"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
 	"github.com/OpenListTeam/go-cache"
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 )
 
 func SharingGet(c *gin.Context, req *FsGetReq) {
@@ -29,18 +35,17 @@ func SharingGet(c *gin.Context, req *FsGetReq) {
 	s, obj, err := sharing.Get(c.Request.Context(), sid, path, model.SharingListArgs{
 		Refresh: false,
 		Pwd:     req.Password,
+		Token:   sharingToken(c),
 	})
 	if dealError(c, err) {
 		return
 	}
-	_ = countAccess(c.ClientIP(), s)
+	_ = countAccess(c, s, path)
+	logAccess(c, s, model.SharingAccessView, path, "", 0)
 	url := ""
 	if !obj.IsDir() {
 		fakePath := fmt.Sprintf("/%s/%s", sid, path)
-		url = fmt.Sprintf("%s/sd%s", common.GetApiUrl(c), utils.EncodePath(fakePath, true))
-		if s.Pwd != "" {
-			url += "?pwd=" + s.Pwd
-		}
+		url = fmt.Sprintf("%s/sd%s", common.GetApiUrl(c), utils.EncodePath(fakePath, true)) + sharingQuery(c, s, req.Password)
 	}
 	thumb, _ := model.GetThumb(obj)
 	common.SuccessResp(c, FsGetResp{
@@ -73,11 +78,13 @@ func SharingList(c *gin.Context, req *ListReq) {
 	s, objs, err := sharing.List(c.Request.Context(), sid, path, model.SharingListArgs{
 		Refresh: req.Refresh,
 		Pwd:     req.Password,
+		Token:   sharingToken(c),
 	})
 	if dealError(c, err) {
 		return
 	}
-	_ = countAccess(c.ClientIP(), s)
+	_ = countAccess(c, s, path)
+	logAccess(c, s, model.SharingAccessView, path, "", 0)
 	total, objs := pagination(objs, &req.PageReq)
 	common.SuccessResp(c, FsListResp{
 		Content: utils.MustSliceConvert(objs, func(obj model.Obj) ObjResp {
@@ -125,17 +132,16 @@ func SharingArchiveMeta(c *gin.Context, req *ArchiveMetaReq) {
 			ArchiveArgs: archiveArgs,
 			Refresh:     req.Refresh,
 		},
-		Pwd: req.Password,
+		Pwd:   req.Password,
+		Token: sharingToken(c),
 	})
 	if dealError(c, err) {
 		return
 	}
-	_ = countAccess(c.ClientIP(), s)
+	_ = countAccess(c, s, path)
+	logAccess(c, s, model.SharingAccessView, path, "", 0)
 	fakePath := fmt.Sprintf("/%s/%s", sid, path)
-	url := fmt.Sprintf("%s/sad%s", common.GetApiUrl(c), utils.EncodePath(fakePath, true))
-	if s.Pwd != "" {
-		url += "?pwd=" + s.Pwd
-	}
+	url := fmt.Sprintf("%s/sad%s", common.GetApiUrl(c), utils.EncodePath(fakePath, true)) + sharingQuery(c, s, req.Password)
 	common.SuccessResp(c, ArchiveMetaResp{
 		Comment:     ret.GetComment(),
 		IsEncrypted: ret.IsEncrypted(),
@@ -171,12 +177,14 @@ func SharingArchiveList(c *gin.Context, req *ArchiveListReq) {
 			ArchiveInnerArgs: innerArgs,
 			Refresh:          req.Refresh,
 		},
-		Pwd: req.Password,
+		Pwd:   req.Password,
+		Token: sharingToken(c),
 	})
 	if dealError(c, err) {
 		return
 	}
-	_ = countAccess(c.ClientIP(), s)
+	_ = countAccess(c, s, path)
+	logAccess(c, s, model.SharingAccessView, path, "", 0)
 	total, objs := pagination(objs, &req.PageReq)
 	ret, _ := utils.SliceConvert(objs, func(src model.Obj) (ObjResp, error) {
 		return toObjsRespWithoutSignAndThumb(src), nil
@@ -196,9 +204,9 @@ func SharingDown(c *gin.Context) {
 	if err == nil {
 		if !s.Valid() {
 			err = errs.InvalidSharing
-		} else if !s.Verify(pwd) {
-			err = errs.WrongShareCode
-		} else if len(s.Files) != 1 && path == "/" {
+		} else if s.IsUpload() {
+			err = errs.UploadOnlySharing
+		} else if err = sharing.Verify(s, pwd, sharingToken(c)); err == nil && len(s.Files) != 1 && path == "/" {
 			err = errors.New("cannot get sharing root link")
 		}
 	}
@@ -214,11 +222,17 @@ func SharingDown(c *gin.Context) {
 	if dealErrorPage(c, err) {
 		return
 	}
+	if dealErrorPage(c, op.CheckSharingDownload(s, path, "")) {
+		return
+	}
 	if setting.GetBool(conf.ShareForceProxy) || common.ShouldProxy(storage, stdpath.Base(actualPath)) {
 		if _, ok := c.GetQuery("d"); !ok {
 			if url := common.GenerateDownProxyURL(storage.GetStorage(), unwrapPath); url != "" {
 				c.Redirect(302, url)
-				_ = countAccess(c.ClientIP(), s)
+				_ = countAccess(c, s, path)
+				if obj, err := op.Get(c.Request.Context(), storage, actualPath); err == nil {
+					logDownload(c, s, model.SharingAccessDownload, path, "", obj.GetSize())
+				}
 				return
 			}
 		}
@@ -230,10 +244,11 @@ func SharingDown(c *gin.Context) {
 			common.ErrorPage(c, errors.WithMessage(err, "failed get sharing link"), 500)
 			return
 		}
-		_ = countAccess(c.ClientIP(), s)
+		_ = countAccess(c, s, path)
 		proxy(c, link, obj, storage.GetStorage().ProxyRange)
+		logDownload(c, s, model.SharingAccessDownload, path, "", int64(c.Writer.Size()))
 	} else {
-		link, _, err := op.Link(c.Request.Context(), storage, actualPath, model.LinkArgs{
+		link, obj, err := op.Link(c.Request.Context(), storage, actualPath, model.LinkArgs{
 			IP:       c.ClientIP(),
 			Header:   c.Request.Header,
 			Type:     c.Query("type"),
@@ -243,8 +258,9 @@ func SharingDown(c *gin.Context) {
 			common.ErrorPage(c, errors.WithMessage(err, "failed get sharing link"), 500)
 			return
 		}
-		_ = countAccess(c.ClientIP(), s)
+		_ = countAccess(c, s, path)
 		redirect(c, link)
+		logDownload(c, s, model.SharingAccessDownload, path, "", obj.GetSize())
 	}
 }
 
@@ -263,9 +279,9 @@ func SharingArchiveExtract(c *gin.Context) {
 	if err == nil {
 		if !s.Valid() {
 			err = errs.InvalidSharing
-		} else if !s.Verify(pwd) {
-			err = errs.WrongShareCode
-		} else if len(s.Files) != 1 && path == "/" {
+		} else if s.IsUpload() {
+			err = errs.UploadOnlySharing
+		} else if err = sharing.Verify(s, pwd, sharingToken(c)); err == nil && len(s.Files) != 1 && path == "/" {
 			err = errors.New("cannot extract sharing root")
 		}
 	}
@@ -281,6 +297,9 @@ func SharingArchiveExtract(c *gin.Context) {
 	if dealErrorPage(c, err) {
 		return
 	}
+	if dealErrorPage(c, op.CheckSharingDownload(s, path, innerPath)) {
+		return
+	}
 	args := model.ArchiveInnerArgs{
 		ArchiveArgs: model.ArchiveArgs{
 			LinkArgs: model.LinkArgs{
@@ -298,13 +317,15 @@ func SharingArchiveExtract(c *gin.Context) {
 				return
 			}
 			proxy(c, link, obj, storage.GetStorage().ProxyRange)
+			logDownload(c, s, model.SharingAccessArchive, path, innerPath, int64(c.Writer.Size()))
 		} else {
 			args.Redirect = true
-			link, _, err := op.DriverExtract(c.Request.Context(), storage, actualPath, args)
+			link, obj, err := op.DriverExtract(c.Request.Context(), storage, actualPath, args)
 			if dealErrorPage(c, err) {
 				return
 			}
 			redirect(c, link)
+			logDownload(c, s, model.SharingAccessArchive, path, innerPath, obj.GetSize())
 		}
 	} else {
 		rc, size, err := op.InternalExtract(c.Request.Context(), storage, actualPath, args)
@@ -313,6 +334,7 @@ func SharingArchiveExtract(c *gin.Context) {
 		}
 		fileName := stdpath.Base(innerPath)
 		proxyInternalExtract(c, rc, size, fileName)
+		logDownload(c, s, model.SharingAccessArchive, path, innerPath, int64(c.Writer.Size()))
 	}
 }
 
@@ -323,7 +345,8 @@ func dealError(c *gin.Context, err error) bool {
 		common.ErrorStrResp(c, "the share does not exist", 500)
 	} else if errors.Is(err, errs.InvalidSharing) {
 		common.ErrorStrResp(c, "the share has expired or is no longer valid", 500)
-	} else if errors.Is(err, errs.WrongShareCode) {
+	} else if errors.Is(err, errs.WrongShareCode) || errors.Is(err, errs.UploadOnlySharing) ||
+		errors.Is(err, errs.SharingLimitExceeded) || errors.Is(err, errs.RecipientTokenRequired) {
 		common.ErrorResp(c, err, 403)
 	} else if errors.Is(err, errs.WrongArchivePassword) {
 		common.ErrorResp(c, err, 202)
@@ -340,7 +363,8 @@ func dealErrorPage(c *gin.Context, err error) bool {
 		common.ErrorPage(c, errors.New("the share does not exist"), 500)
 	} else if errors.Is(err, errs.InvalidSharing) {
 		common.ErrorPage(c, errors.New("the share has expired or is no longer valid"), 500)
-	} else if errors.Is(err, errs.WrongShareCode) {
+	} else if errors.Is(err, errs.WrongShareCode) || errors.Is(err, errs.UploadOnlySharing) ||
+		errors.Is(err, errs.SharingLimitExceeded) || errors.Is(err, errs.RecipientTokenRequired) {
 		common.ErrorPage(c, err, 403)
 	} else if errors.Is(err, errs.WrongArchivePassword) {
 		common.ErrorPage(c, err, 202)
@@ -354,20 +378,19 @@ type SharingResp struct {
 	*model.Sharing
 	CreatorName string `json:"creator"`
 	CreatorRole int    `json:"creator_role"`
+	HasPwd      bool   `json:"has_pwd"`
 }
 
 func GetSharing(c *gin.Context) {
-	sid := c.Query("id")
-	user := c.Request.Context().Value(conf.UserKey).(*model.User)
-	s, err := op.GetSharingById(sid)
-	if err != nil || (!user.IsAdmin() && s.Creator.ID != user.ID) {
-		common.ErrorStrResp(c, "sharing not found", 404)
+	s, ok := sharingOfUser(c)
+	if !ok {
 		return
 	}
 	common.SuccessResp(c, SharingResp{
 		Sharing:     s,
 		CreatorName: s.Creator.Username,
 		CreatorRole: s.Creator.Role,
+		HasPwd:      s.HasPwd(),
 	})
 }
 
@@ -397,6 +420,7 @@ func ListSharings(c *gin.Context) {
 				Sharing:     &s,
 				CreatorName: s.Creator.Username,
 				CreatorRole: s.Creator.Role,
+				HasPwd:      s.HasPwd(),
 			}
 		}),
 		Total: total,
@@ -416,6 +440,48 @@ type UpdateSharingReq struct {
 	CreatorName string `json:"creator"`
 	Accessed    int    `json:"accessed"`
 	ID          string `json:"id"`
+	Type        string `json:"type"`
+	MaxFileSize int64  `json:"max_file_size"`
+	AllowedExts string `json:"allowed_exts"`
+	// MaxTotalBytes limits the bytes uploaded into an upload sharing
+	MaxTotalBytes int64 `json:"max_total_bytes"`
+	// MaxBandwidth limits the bytes served by the downloads of the sharing
+	MaxBandwidth        int64 `json:"max_bandwidth"`
+	MaxDownloadsPerFile int   `json:"max_downloads_per_file"`
+	// an empty Pwd keeps the password of the sharing, RemovePwd removes it
+	RemovePwd bool `json:"remove_pwd"`
+	// Recipients restrict the sharing to these emails, the recipients are kept if it is null
+	Recipients []string `json:"recipients"`
+}
+
+// checkRecipients normalizes the emails of the recipients, it returns why one is invalid
+func checkRecipients(req *UpdateSharingReq) string {
+	for i, r := range req.Recipients {
+		addr, err := mail.ParseAddress(r)
+		if err != nil {
+			return fmt.Sprintf("invalid recipient [%s]", r)
+		}
+		req.Recipients[i] = strings.ToLower(addr.Address)
+	}
+	return ""
+}
+
+// checkSharingType returns why the user can't create the sharing described by req, empty if it can
+func checkSharingType(user *model.User, req *UpdateSharingReq) string {
+	switch req.Type {
+	case "":
+		return ""
+	case model.SharingTypeUpload:
+	default:
+		return fmt.Sprintf("unknown sharing type [%s]", req.Type)
+	}
+	if len(req.Files) != 1 {
+		return "an upload sharing must have exactly 1 folder"
+	}
+	if !user.CanWriteContent() || !op.ACLAllows(user, model.ACLUpload, req.Files[0]) {
+		return fmt.Sprintf("permission denied to upload to [%s]", req.Files[0])
+	}
+	return ""
 }
 
 func UpdateSharing(c *gin.Context) {
@@ -447,10 +513,14 @@ func UpdateSharing(c *gin.Context) {
 	for i, s := range req.Files {
 		s = utils.FixAndCleanPath(s)
 		req.Files[i] = s
-		if !reqUser.IsAdmin() && !strings.HasPrefix(s, user.BasePath) {
+		if !reqUser.IsAdmin() && !strings.HasPrefix(s, user.GetBasePath()) {
 			common.ErrorStrResp(c, fmt.Sprintf("permission denied to share path [%s]", s), 500)
 			return
 		}
+		if !op.ACLAllows(user, model.ACLShare, s) {
+			common.ErrorStrResp(c, fmt.Sprintf("permission denied to share path [%s]", s), 403)
+			return
+		}
 	}
 	s, err := op.GetSharingById(req.ID)
 	if err != nil || (!reqUser.IsAdmin() && s.CreatorId != user.ID) {
@@ -460,9 +530,19 @@ func UpdateSharing(c *gin.Context) {
 	if reqUser.IsAdmin() && req.CreatorName == "" {
 		user = s.Creator
 	}
+	if msg := checkSharingType(user, &req); msg != "" {
+		common.ErrorStrResp(c, msg, 403)
+		return
+	}
+	if msg := checkRecipients(&req); msg != "" {
+		common.ErrorStrResp(c, msg, 400)
+		return
+	}
 	s.Files = req.Files
 	s.Expires = req.Expires
-	s.Pwd = req.Pwd
+	if req.Pwd != "" || req.RemovePwd {
+		s.SetPwd(req.Pwd)
+	}
 	s.Accessed = req.Accessed
 	s.MaxAccessed = req.MaxAccessed
 	s.Disabled = req.Disabled
@@ -470,14 +550,29 @@ func UpdateSharing(c *gin.Context) {
 	s.Header = req.Header
 	s.Readme = req.Readme
 	s.Remark = req.Remark
+	s.Type = req.Type
+	s.MaxFileSize = req.MaxFileSize
+	s.AllowedExts = req.AllowedExts
+	s.MaxTotalBytes = req.MaxTotalBytes
+	s.MaxBandwidth = req.MaxBandwidth
+	s.MaxDownloadsPerFile = req.MaxDownloadsPerFile
 	s.Creator = user
-	if err = op.UpdateSharing(s); err != nil {
+	if req.Recipients != nil {
+		s.Restricted = len(req.Recipients) > 0
+	}
+	err = op.UpdateSharing(s)
+	if err == nil && req.Recipients != nil {
+		err = op.SetSharingRecipients(s.ID, req.Recipients)
+	}
+	auditSharing(c, model.AuditShareUpdate, s, err)
+	if err != nil {
 		common.ErrorResp(c, err, 500)
 	} else {
 		common.SuccessResp(c, SharingResp{
 			Sharing:     s,
 			CreatorName: s.Creator.Username,
 			CreatorRole: s.Creator.Role,
+			HasPwd:      s.HasPwd(),
 		})
 	}
 }
@@ -511,36 +606,62 @@ func CreateSharing(c *gin.Context) {
 	for i, s := range req.Files {
 		s = utils.FixAndCleanPath(s)
 		req.Files[i] = s
-		if !reqUser.IsAdmin() && !strings.HasPrefix(s, user.BasePath) {
+		if !reqUser.IsAdmin() && !strings.HasPrefix(s, user.GetBasePath()) {
 			common.ErrorStrResp(c, fmt.Sprintf("permission denied to share path [%s]", s), 500)
 			return
 		}
+		if !op.ACLAllows(user, model.ACLShare, s) {
+			common.ErrorStrResp(c, fmt.Sprintf("permission denied to share path [%s]", s), 403)
+			return
+		}
+	}
+	if msg := checkSharingType(user, &req); msg != "" {
+		common.ErrorStrResp(c, msg, 403)
+		return
+	}
+	if msg := checkRecipients(&req); msg != "" {
+		common.ErrorStrResp(c, msg, 400)
+		return
 	}
 	s := &model.Sharing{
 		SharingDB: &model.SharingDB{
-			ID:          req.ID,
-			Expires:     req.Expires,
-			Pwd:         req.Pwd,
-			Accessed:    req.Accessed,
-			MaxAccessed: req.MaxAccessed,
-			Disabled:    req.Disabled,
-			Sort:        req.Sort,
-			Remark:      req.Remark,
-			Readme:      req.Readme,
-			Header:      req.Header,
+			ID:                  req.ID,
+			Expires:             req.Expires,
+			Accessed:            req.Accessed,
+			MaxAccessed:         req.MaxAccessed,
+			Disabled:            req.Disabled,
+			Sort:                req.Sort,
+			Remark:              req.Remark,
+			Readme:              req.Readme,
+			Header:              req.Header,
+			Type:                req.Type,
+			MaxFileSize:         req.MaxFileSize,
+			AllowedExts:         req.AllowedExts,
+			MaxTotalBytes:       req.MaxTotalBytes,
+			MaxBandwidth:        req.MaxBandwidth,
+			MaxDownloadsPerFile: req.MaxDownloadsPerFile,
+			Restricted:          len(req.Recipients) > 0,
 		},
 		Files:   req.Files,
 		Creator: user,
 	}
+	s.SetPwd(req.Pwd)
 	var id string
-	if id, err = op.CreateSharing(s); err != nil {
+	id, err = op.CreateSharing(s)
+	s.ID = id
+	if err == nil && s.Restricted {
+		err = op.SetSharingRecipients(id, req.Recipients)
+	}
+	auditSharing(c, model.AuditShareCreate, s, err)
+	if err != nil {
 		common.ErrorResp(c, err, 500)
 	} else {
-		s.ID = id
+		webhook.Emit(c.Request.Context(), &webhook.Event{Event: model.EventShareCreate, ShareId: id, Files: s.Files})
 		common.SuccessResp(c, SharingResp{
 			Sharing:     s,
 			CreatorName: s.Creator.Username,
 			CreatorRole: s.Creator.Role,
+			HasPwd:      s.HasPwd(),
 		})
 	}
 }
@@ -553,7 +674,9 @@ func DeleteSharing(c *gin.Context) {
 		common.ErrorResp(c, err, 404)
 		return
 	}
-	if err = op.DeleteSharing(sid); err != nil {
+	err = op.DeleteSharing(sid)
+	auditSharing(c, model.AuditShareDelete, s, err)
+	if err != nil {
 		common.ErrorResp(c, err, 500)
 	} else {
 		common.SuccessResp(c)
@@ -570,7 +693,9 @@ func SetEnableSharing(disable bool) func(ctx *gin.Context) {
 			return
 		}
 		s.Disabled = disable
-		if err = op.UpdateSharing(s, true); err != nil {
+		err = op.UpdateSharing(s, true)
+		auditSharing(c, model.AuditShareUpdate, s, err)
+		if err != nil {
 			common.ErrorResp(c, err, 500)
 		} else {
 			common.SuccessResp(c)
@@ -578,18 +703,141 @@ func SetEnableSharing(disable bool) func(ctx *gin.Context) {
 	}
 }
 
+// auditSharing records a change of the sharing s, the shared paths are named in the detail
+func auditSharing(c *gin.Context, operation string, s *model.Sharing, err error) {
+	e := &model.AuditLog{
+		Operation: operation,
+		Path:      "/" + s.ID,
+		Detail:    strings.Join(s.Files, ", "),
+	}
+	if s.Disabled {
+		e.Detail += " (disabled)"
+	}
+	audit.Record(c.Request.Context(), e, err)
+}
+
 var (
 	AccessCache      = cache.NewMemCache[interface{}]()
 	AccessCountDelay = 30 * time.Minute
 )
 
-func countAccess(ip string, s *model.Sharing) error {
-	key := fmt.Sprintf("%s:%s", s.ID, ip)
+// countAccess records the access to path in the sharing, the counter goes up once per client in AccessCountDelay
+func countAccess(c *gin.Context, s *model.Sharing, path string) error {
+	audit.Record(c.Request.Context(), &model.AuditLog{
+		Operation: model.AuditShareAccess,
+		Path:      stdpath.Join("/", s.ID, path),
+	}, nil)
+	e := &webhook.Event{Event: model.EventShareAccess, ShareId: s.ID}
+	e.Path, _ = op.GetSharingUnwrapPath(s, utils.FixAndCleanPath(path))
+	webhook.Emit(c.Request.Context(), e)
+	key := fmt.Sprintf("%s:%s", s.ID, c.ClientIP())
 	_, ok := AccessCache.Get(key)
 	if !ok {
 		AccessCache.Set(key, struct{}{}, cache.WithEx[interface{}](AccessCountDelay))
-		s.Accessed += 1
-		return op.UpdateSharing(s, true)
+		return op.CountSharingAccess(s)
 	}
 	return nil
 }
+
+// logAccess adds the access to path in the sharing to its access log, path is cleaned first
+func logAccess(c *gin.Context, s *model.Sharing, kind, path, innerPath string, bytes int64) {
+	access := &model.SharingAccess{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Kind:      kind,
+		Path:      utils.FixAndCleanPath(path),
+		InnerPath: innerPath,
+		Bytes:     max(bytes, 0),
+	}
+	if s.Restricted {
+		if r, err := sharing.TokenRecipient(s, sharingToken(c)); err == nil {
+			access.Recipient = r.Email
+			now := time.Now()
+			r.LastAccess = &now
+			if err := op.UpdateSharingRecipient(r); err != nil {
+				log.Errorf("failed update recipient %s of sharing %s: %+v", r.Email, s.ID, err)
+			}
+		}
+	}
+	if err := op.RecordSharingAccess(s, access); err != nil {
+		log.Errorf("failed record access to sharing %s: %+v", s.ID, err)
+	}
+}
+
+// logDownload logs a download once it is served, HEAD requests are no downloads
+func logDownload(c *gin.Context, s *model.Sharing, kind, path, innerPath string, bytes int64) {
+	if c.Request.Method == http.MethodGet {
+		logAccess(c, s, kind, path, innerPath, bytes)
+	}
+}
+
+// sharingOfUser returns the sharing with the id in the query if the user created it or is an admin
+func sharingOfUser(c *gin.Context) (*model.Sharing, bool) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	s, err := op.GetSharingById(c.Query("id"))
+	if err != nil || (!user.IsAdmin() && s.Creator.ID != user.ID) {
+		common.ErrorStrResp(c, "sharing not found", 404)
+		return nil, false
+	}
+	return s, true
+}
+
+func GetSharingStats(c *gin.Context) {
+	s, ok := sharingOfUser(c)
+	if !ok {
+		return
+	}
+	stats, err := op.GetSharingStats(s.ID)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, stats)
+}
+
+func ListSharingAccesses(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	s, ok := sharingOfUser(c)
+	if !ok {
+		return
+	}
+	accesses, total, err := op.GetSharingAccesses(s.ID, c.Query("recipient"), req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: accesses,
+		Total:   total,
+	})
+}
+
+// sharingToken returns the token of the recipient of a restricted sharing, sent in the
+// Share-Token header or the token query
+func sharingToken(c *gin.Context) string {
+	if token := c.GetHeader("Share-Token"); token != "" {
+		return token
+	}
+	return c.Query("token")
+}
+
+// sharingQuery returns the query a link into the sharing needs, with the password and the token
+// of the request
+func sharingQuery(c *gin.Context, s *model.Sharing, pwd string) string {
+	query := url.Values{}
+	if s.HasPwd() {
+		query.Set("pwd", pwd)
+	}
+	if s.Restricted {
+		query.Set("token", sharingToken(c))
+	}
+	if len(query) == 0 {
+		return ""
+	}
+	return "?" + query.Encode()
+}
@@ -0,0 +1,96 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/sharing"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type SharingCodeReq struct {
+	Sid   string `json:"sid" binding:"required"`
+	Email string `json:"email" binding:"required"`
+	Code  string `json:"code"`
+}
+
+// SendSharingCode emails a code to a recipient of a restricted sharing, it succeeds for any email
+// so that the recipients can't be guessed
+func SendSharingCode(c *gin.Context) {
+	var req SharingCodeReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if dealError(c, sharing.SendCode(req.Sid, req.Email)) {
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// VerifySharingCode exchanges the code for the token the recipient sends with its requests to the
+// sharing, in the Share-Token header or the token query
+func VerifySharingCode(c *gin.Context) {
+	var req SharingCodeReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	token, err := sharing.VerifyCode(req.Sid, req.Email, req.Code)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	common.SuccessResp(c, gin.H{"token": token})
+}
+
+func ListSharingRecipients(c *gin.Context) {
+	s, ok := sharingOfUser(c)
+	if !ok {
+		return
+	}
+	recipients, err := op.GetSharingRecipients(s.ID)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, recipients)
+}
+
+// SetRevokeSharingRecipient revokes the recipient with the id, or gives it back its access
+func SetRevokeSharingRecipient(revoke bool) func(ctx *gin.Context) {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Query("id"))
+		if err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+		user := c.Request.Context().Value(conf.UserKey).(*model.User)
+		r, err := op.GetSharingRecipientById(uint(id))
+		if err != nil {
+			common.ErrorStrResp(c, "recipient not found", 404)
+			return
+		}
+		s, err := op.GetSharingById(r.SharingId)
+		if err != nil || (!user.IsAdmin() && s.CreatorId != user.ID) {
+			common.ErrorStrResp(c, "recipient not found", 404)
+			return
+		}
+		r.Revoked = revoke
+		err = op.UpdateSharingRecipient(r)
+		e := &model.AuditLog{Operation: model.AuditShareUpdate, Path: "/" + s.ID, Detail: "restore " + r.Email}
+		if revoke {
+			e.Detail = "revoke " + r.Email
+		}
+		audit.Record(c.Request.Context(), e, err)
+		if err != nil {
+			common.ErrorResp(c, err, 500)
+		} else {
+			common.SuccessResp(c)
+		}
+	}
+}
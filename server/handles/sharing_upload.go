@@ -0,0 +1,133 @@
+package handles
+
+import (
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/sharing"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type UploadSharingInfoResp struct {
+	ID          string     `json:"id"`
+	Remark      string     `json:"remark"`
+	Readme      string     `json:"readme"`
+	Header      string     `json:"header"`
+	Expires     *time.Time `json:"expires"`
+	MaxFileSize int64      `json:"max_file_size"`
+	AllowedExts string     `json:"allowed_exts"`
+	// RemainingBytes is -1 when the total bytes are not limited
+	RemainingBytes int64 `json:"remaining_bytes"`
+}
+
+// UploadSharingInfo tells the recipients of an upload sharing what they may upload, the folder is not revealed
+func UploadSharingInfo(c *gin.Context) {
+	s, err := op.GetSharingById(c.Query("sid"))
+	if err != nil {
+		err = errs.SharingNotFound
+	} else if !s.Valid() {
+		err = errs.InvalidSharing
+	} else if !s.IsUpload() {
+		err = errs.NotUploadSharing
+	} else {
+		err = sharing.Verify(s, c.Query("pwd"), sharingToken(c))
+	}
+	if dealError(c, err) {
+		return
+	}
+	remaining := int64(-1)
+	if s.MaxTotalBytes > 0 {
+		remaining = max(s.MaxTotalBytes-s.UploadedBytes, 0)
+	}
+	common.SuccessResp(c, UploadSharingInfoResp{
+		ID:             s.ID,
+		Remark:         s.Remark,
+		Readme:         s.Readme,
+		Header:         s.Header,
+		Expires:        s.Expires,
+		MaxFileSize:    s.MaxFileSize,
+		AllowedExts:    s.AllowedExts,
+		RemainingBytes: remaining,
+	})
+}
+
+// SharingUpload streams the body into the folder of the upload sharing, like FsStream does
+// for a user. The name may change to not replace an uploaded file, the stored one is returned.
+func SharingUpload(c *gin.Context) {
+	defer func() {
+		if n, _ := io.ReadFull(c.Request.Body, []byte{0}); n == 1 {
+			_, _ = utils.CopyWithBuffer(io.Discard, c.Request.Body)
+		}
+		_ = c.Request.Body.Close()
+	}()
+	name, err := url.PathUnescape(c.GetHeader("File-Name"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if shouldIgnoreSystemFile(name) {
+		common.ErrorStrResp(c, errs.IgnoredSystemFile.Error(), 403)
+		return
+	}
+	size := c.Request.ContentLength
+	if size < 0 {
+		if sizeStr := c.GetHeader("X-File-Size"); sizeStr != "" {
+			size, err = strconv.ParseInt(sizeStr, 10, 64)
+			if err != nil {
+				common.ErrorResp(c, err, 400)
+				return
+			}
+		}
+	}
+	mimetype := c.GetHeader("Content-Type")
+	if len(mimetype) == 0 {
+		mimetype = utils.GetMimeType(name)
+	}
+	var body io.Reader = c.Request.Body
+	if size >= 0 {
+		// the limits are checked against the declared size, the body may not be larger
+		body = &sizedReader{r: io.LimitReader(c.Request.Body, size+1), left: size}
+	}
+	s := &stream.FileStream{
+		Obj: &model.Object{
+			Name:     name,
+			Size:     size,
+			Modified: getLastModified(c),
+		},
+		Reader:   body,
+		Mimetype: mimetype,
+	}
+	stored, err := sharing.Upload(c.Request.Context(), c.Query("sid"), c.Query("pwd"), sharingToken(c), s)
+	if errors.Is(err, errs.NotUploadSharing) {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	if dealError(c, err) {
+		return
+	}
+	common.SuccessResp(c, gin.H{"name": stored})
+}
+
+// sizedReader fails the read that goes over the size the client declared
+type sizedReader struct {
+	r    io.Reader
+	left int64
+}
+
+func (r *sizedReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.left -= int64(n)
+	if r.left < 0 {
+		return n, errors.New("the body is larger than the declared size")
+	}
+	return n, err
+}
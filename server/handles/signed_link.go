@@ -0,0 +1,137 @@
+package handles
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/sign"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type SignedLinkCreateReq struct {
+	Path     string `json:"path" binding:"required"`
+	Password string `json:"password"`
+	// Expires is the time the link expires, or ExpiresIn the seconds it lasts for
+	Expires      *time.Time `json:"expires"`
+	ExpiresIn    int64      `json:"expires_in"`
+	IPs          string     `json:"ips"`
+	MaxDownloads int        `json:"max_downloads"`
+	RangeStart   int64      `json:"range_start"`
+	RangeLength  int64      `json:"range_length"`
+	RateLimit    int        `json:"rate_limit"`
+}
+
+type SignedLinkResp struct {
+	model.SignedLink
+	URL string `json:"url"`
+}
+
+// CreateMySignedLink mints a download link to a file the user can read, for someone without an account
+func CreateMySignedLink(c *gin.Context) {
+	var req SignedLinkCreateReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	if !common.CanAccess(user, meta, reqPath, req.Password) {
+		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+		return
+	}
+	if !op.ACLAllows(user, model.ACLRead, reqPath) {
+		common.ErrorStrResp(c, "you have no permission to read the file", 403)
+		return
+	}
+	obj, err := fs.Get(c.Request.Context(), reqPath, &fs.GetArgs{})
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	if obj.IsDir() {
+		common.ErrorStrResp(c, "only a file can be linked", 400)
+		return
+	}
+	l := &model.SignedLink{
+		UserId:       user.ID,
+		Path:         reqPath,
+		IPs:          req.IPs,
+		MaxDownloads: req.MaxDownloads,
+		RangeStart:   req.RangeStart,
+		RangeLength:  req.RangeLength,
+		RateLimit:    req.RateLimit,
+	}
+	if req.Expires != nil {
+		l.Expires = *req.Expires
+	} else {
+		l.Expires = time.Now().Add(time.Duration(req.ExpiresIn) * time.Second)
+	}
+	if err := op.CreateSignedLink(l); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c, SignedLinkResp{SignedLink: *l, URL: signedLinkURL(c, l)})
+}
+
+func signedLinkURL(c *gin.Context, l *model.SignedLink) string {
+	return fmt.Sprintf("%s/d%s?slink=%s", common.GetApiUrl(c), utils.EncodePath(l.Path, true), sign.Link(l.ID, l.Path, l.Expires))
+}
+
+func ListMySignedLinks(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	links, total, err := op.GetSignedLinksByUserId(user.ID, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: utils.MustSliceConvert(links, func(l model.SignedLink) SignedLinkResp {
+			return SignedLinkResp{SignedLink: l, URL: signedLinkURL(c, &l)}
+		}),
+		Total: total,
+	})
+}
+
+// DeleteMySignedLink deletes the link, it can't be used anymore
+func DeleteMySignedLink(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorStrResp(c, "id format invalid", 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	l, err := op.GetSignedLinkByIdAndUserId(uint(id), user.ID)
+	if err != nil {
+		common.ErrorStrResp(c, "failed to get signed link", 404)
+		return
+	}
+	if err := op.DeleteSignedLinkById(l.ID); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
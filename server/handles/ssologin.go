@@ -22,6 +22,7 @@ import (
 	"github.com/coreos/go-oidc"
 	"github.com/gin-gonic/gin"
 	"github.com/go-resty/resty/v2"
+	jsoniter "github.com/json-iterator/go"
 	"golang.org/x/oauth2"
 	"gorm.io/gorm"
 )
@@ -183,6 +184,24 @@ func parseJWT(p string) ([]byte, error) {
 	return payload, nil
 }
 
+// claimValues returns the claim of the id token as a list of strings, a single string claim is a list of one
+func claimValues(payload []byte, key string) []string {
+	claim := utils.Json.Get(payload, key)
+	switch claim.ValueType() {
+	case jsoniter.StringValue:
+		return []string{claim.ToString()}
+	case jsoniter.ArrayValue:
+		values := make([]string, 0, claim.Size())
+		for i := 0; i < claim.Size(); i++ {
+			if v := claim.Get(i); v.ValueType() == jsoniter.StringValue {
+				values = append(values, v.ToString())
+			}
+		}
+		return values
+	}
+	return nil
+}
+
 func OIDCLoginCallback(c *gin.Context) {
 	useCompatibility := setting.GetBool(conf.SSOCompatibilityMode)
 	method := c.Query("method")
@@ -251,6 +270,7 @@ func OIDCLoginCallback(c *gin.Context) {
 		return
 	}
 	if method == "sso_get_token" {
+		defer auditLogin(c, userID, "oidc")
 		user, err := db.GetUserBySSOID(userID)
 		if err != nil {
 			user, err = autoRegister(userID, userID, err)
@@ -259,6 +279,9 @@ func OIDCLoginCallback(c *gin.Context) {
 				return
 			}
 		}
+		if groupsKey := setting.GetStr(conf.SSOOIDCGroupsKey); groupsKey != "" {
+			common.SyncExternalGroups(user, claimValues(payload, groupsKey))
+		}
 		token, err := common.GenerateToken(user)
 		if err != nil {
 			common.ErrorResp(c, err, 400)
@@ -418,6 +441,7 @@ func SSOLoginCallback(c *gin.Context) {
 		return
 	}
 	username := utils.Json.Get(resp.Body(), usernameField).ToString()
+	defer auditLogin(c, username, "sso "+platform)
 	user, err := db.GetUserBySSOID(userID)
 	if err != nil {
 		user, err = autoRegister(username, userID, err)
@@ -96,6 +96,7 @@ func CreateStorage(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
+	common.SetAuditDetail(c, "%s", req.MountPath)
 	if id, err := op.CreateStorage(c.Request.Context(), req); err != nil {
 		common.ErrorWithDataResp(c, err, 500, gin.H{
 			"id": id,
@@ -113,6 +114,7 @@ func UpdateStorage(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
+	common.SetAuditDetail(c, "%d %s", req.ID, req.MountPath)
 	if err := op.UpdateStorage(c.Request.Context(), req); err != nil {
 		common.ErrorResp(c, err, 500, true)
 	} else {
@@ -0,0 +1,115 @@
+package handles
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func ListTrash(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	var items []model.TrashItem
+	var total int64
+	var err error
+	if user.IsAdmin() {
+		items, total, err = op.GetTrashItems(req.Page, req.PerPage)
+	} else {
+		items, total, err = op.GetTrashItemsByDeleterId(user.ID, req.Page, req.PerPage)
+	}
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: items,
+		Total:   total,
+	})
+}
+
+type TrashReq struct {
+	Ids []uint `json:"ids"`
+}
+
+func getTrashItems(c *gin.Context, user *model.User, ids []uint) ([]*model.TrashItem, bool) {
+	items := make([]*model.TrashItem, 0, len(ids))
+	for _, id := range ids {
+		item, err := op.GetTrashItemById(id)
+		if err != nil {
+			common.ErrorResp(c, err, 404)
+			return nil, false
+		}
+		if !user.IsAdmin() && (item.DeleterId != user.ID || !utils.IsSubPath(user.GetBasePath(), item.Path)) {
+			common.ErrorResp(c, errs.PermissionDenied, 403)
+			return nil, false
+		}
+		items = append(items, item)
+	}
+	return items, true
+}
+
+func RestoreTrash(c *gin.Context) {
+	var req TrashReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if len(req.Ids) == 0 {
+		common.ErrorStrResp(c, "Empty trash ids", 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !user.CanRemove() {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	items, ok := getTrashItems(c, user, req.Ids)
+	if !ok {
+		return
+	}
+	for _, item := range items {
+		if err := fs.RestoreTrash(c.Request.Context(), item); err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+	}
+	common.SuccessResp(c)
+}
+
+func PurgeTrash(c *gin.Context) {
+	var req TrashReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if len(req.Ids) == 0 {
+		common.ErrorStrResp(c, "Empty trash ids", 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !user.CanRemove() {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	items, ok := getTrashItems(c, user, req.Ids)
+	if !ok {
+		return
+	}
+	for _, item := range items {
+		if err := fs.PurgeTrash(c.Request.Context(), item); err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+	}
+	common.SuccessResp(c)
+}
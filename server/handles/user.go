@@ -39,6 +39,7 @@ func CreateUser(c *gin.Context) {
 		common.ErrorStrResp(c, "admin or guest user can not be created", 400, true)
 		return
 	}
+	common.SetAuditDetail(c, "%s", req.Username)
 	req.SetPassword(req.Password)
 	req.Password = ""
 	req.Authn = "[]"
@@ -60,6 +61,7 @@ func UpdateUser(c *gin.Context) {
 		common.ErrorResp(c, err, 500)
 		return
 	}
+	common.SetAuditDetail(c, "%d %s", user.ID, user.Username)
 	if user.Role != req.Role {
 		common.ErrorStrResp(c, "role can not be changed", 400)
 		return
@@ -74,6 +76,10 @@ func UpdateUser(c *gin.Context) {
 	if req.OtpSecret == "" {
 		req.OtpSecret = user.OtpSecret
 	}
+	if req.Groups == nil {
+		// clients that don't know about groups leave them alone
+		req.Groups = user.Groups
+	}
 	if req.Disabled && req.IsAdmin() {
 		common.ErrorStrResp(c, "admin user can not be disabled", 400)
 		return
@@ -0,0 +1,112 @@
+package handles
+
+import (
+	"fmt"
+	stdpath "path"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/sign"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type VersionResp struct {
+	model.FileVersion
+	RawURL string `json:"raw_url"`
+}
+
+func FsListVersions(c *gin.Context) {
+	var req FsGetReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	if !common.CanAccess(user, meta, reqPath, req.Password) {
+		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+		return
+	}
+	versions, err := op.GetFileVersionsByPath(reqPath)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	resp := make([]VersionResp, 0, len(versions))
+	for _, v := range versions {
+		versionPath, err := fs.GetVersionPath(&v)
+		if err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+		resp = append(resp, VersionResp{
+			FileVersion: v,
+			RawURL: fmt.Sprintf("%s/d%s?sign=%s",
+				common.GetApiUrl(c),
+				utils.EncodePath(versionPath, true),
+				sign.Sign(versionPath)),
+		})
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: resp,
+		Total:   int64(len(resp)),
+	})
+}
+
+type RestoreVersionReq struct {
+	Path string `json:"path"`
+	Id   uint   `json:"id"`
+}
+
+func FsRestoreVersion(c *gin.Context) {
+	var req RestoreVersionReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	parentPath := stdpath.Dir(reqPath)
+	parentMeta, err := op.GetNearestMeta(parentPath)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	if !user.CanWriteContent() && !common.CanWriteContentBypassUserPerms(parentMeta, parentPath) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	if !common.CanWrite(user, parentMeta, parentPath) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	v, err := op.GetFileVersionById(req.Id)
+	if err != nil || v.Path != reqPath {
+		common.ErrorStrResp(c, "version not found", 404)
+		return
+	}
+	if err = fs.RestoreVersion(c.Request.Context(), v); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c)
+}
@@ -85,6 +85,13 @@ func FinishAuthnLogin(c *gin.Context) {
 	}
 
 	var user *model.User
+	defer func() {
+		username := c.Query("username")
+		if user != nil {
+			username = user.Username
+		}
+		auditLogin(c, username, "webauthn")
+	}()
 	if username := c.Query("username"); username != "" {
 		user, err = db.GetUserByName(username)
 		if err != nil {
@@ -0,0 +1,126 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/webhook"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func ListWebhooks(c *gin.Context) {
+	hooks, err := webhook.GetWebhooks()
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, hooks)
+}
+
+func ListWebhookEvents(c *gin.Context) {
+	common.SuccessResp(c, model.WebhookEvents)
+}
+
+func CreateWebhook(c *gin.Context) {
+	var req model.Webhook
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SetAuditDetail(c, "%s %s", req.Name, req.URL)
+	if err := webhook.CreateWebhook(&req); err != nil {
+		common.ErrorResp(c, err, 500, true)
+	} else {
+		common.SuccessResp(c, req)
+	}
+}
+
+func UpdateWebhook(c *gin.Context) {
+	var req model.Webhook
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SetAuditDetail(c, "%d %s %s", req.ID, req.Name, req.URL)
+	if err := webhook.UpdateWebhook(&req); err != nil {
+		common.ErrorResp(c, err, 500, true)
+	} else {
+		common.SuccessResp(c)
+	}
+}
+
+func webhookIdFromQuery(c *gin.Context) (uint, bool) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func DeleteWebhook(c *gin.Context) {
+	id, ok := webhookIdFromQuery(c)
+	if !ok {
+		return
+	}
+	if err := webhook.DeleteWebhookById(id); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// TestWebhook sends a ping event, its outcome shows in the deliveries of the webhook
+func TestWebhook(c *gin.Context) {
+	id, ok := webhookIdFromQuery(c)
+	if !ok {
+		return
+	}
+	w, err := webhook.GetWebhookById(id)
+	if err != nil {
+		common.ErrorResp(c, err, 404)
+		return
+	}
+	d, err := webhook.Ping(w)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, d)
+}
+
+func ListWebhookDeliveries(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	id, ok := webhookIdFromQuery(c)
+	if !ok {
+		return
+	}
+	deliveries, total, err := webhook.GetDeliveries(id, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: deliveries,
+		Total:   total,
+	})
+}
+
+// RedeliverWebhook sends the delivery with the id again
+func RedeliverWebhook(c *gin.Context) {
+	id, ok := webhookIdFromQuery(c)
+	if !ok {
+		return
+	}
+	if err := webhook.Redeliver(id); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
@@ -0,0 +1,51 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+// AuditContext tells the audit log which protocol and client the requests come from
+func AuditContext(protocol string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		common.GinWithValue(c,
+			conf.ProtocolKey, protocol,
+			conf.ClientIPKey, c.ClientIP(),
+		)
+		c.Next()
+	}
+}
+
+// AuditAdmin records the changing admin requests, the operation is named after the route
+// and the outcome is the error response of the handler if it sent one
+func AuditAdmin(c *gin.Context) {
+	c.Next()
+	if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+		return
+	}
+	_, route, ok := strings.Cut(c.FullPath(), "/admin/")
+	if !ok {
+		return
+	}
+	var err error
+	if msg := common.GetAuditError(c); msg != "" {
+		err = errors.New(msg)
+	} else if c.IsAborted() {
+		err = errors.New("aborted")
+	}
+	detail := common.GetAuditDetail(c)
+	if detail == "" {
+		detail = c.Request.URL.RawQuery
+	}
+	audit.Record(c.Request.Context(), &model.AuditLog{
+		Operation: model.AuditAdminPrefix + strings.ReplaceAll(route, "/", "."),
+		Detail:    detail,
+	}, err)
+}
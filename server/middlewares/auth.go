@@ -2,6 +2,7 @@ package middlewares
 
 import (
 	"crypto/subtle"
+	"strings"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
@@ -9,6 +10,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/setting"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -46,6 +48,10 @@ func Auth(allowDisabledGuest bool) func(c *gin.Context) {
 			c.Next()
 			return
 		}
+		if strings.HasPrefix(token, model.APITokenPrefix) {
+			apiTokenAuth(c, token)
+			return
+		}
 		userClaims, err := common.ParseToken(token)
 		if err != nil {
 			common.ErrorResp(c, err, 401)
@@ -101,6 +107,10 @@ func Authn(c *gin.Context) {
 		c.Next()
 		return
 	}
+	if strings.HasPrefix(token, model.APITokenPrefix) {
+		apiTokenAuth(c, token)
+		return
+	}
 	userClaims, err := common.ParseToken(token)
 	if err != nil {
 		common.ErrorResp(c, err, 401)
@@ -129,6 +139,44 @@ func Authn(c *gin.Context) {
 	c.Next()
 }
 
+func apiTokenAuth(c *gin.Context, token string) {
+	user, err := op.GetAPITokenUser(token)
+	if err != nil {
+		common.ErrorResp(c, err, 401)
+		c.Abort()
+		return
+	}
+	common.GinWithValue(c, conf.UserKey, user)
+	log.Debugf("use api token %d of user: %s", user.APITokenId, user.Username)
+	c.Next()
+}
+
+// tokenUser returns the user of the admin token, an api token or a login token,
+// the token is checked like Auth does
+func tokenUser(token string) (*model.User, error) {
+	if subtle.ConstantTimeCompare([]byte(token), []byte(setting.GetStr(conf.Token))) == 1 {
+		return op.GetAdmin()
+	}
+	if strings.HasPrefix(token, model.APITokenPrefix) {
+		return op.GetAPITokenUser(token)
+	}
+	userClaims, err := common.ParseToken(token)
+	if err != nil {
+		return nil, err
+	}
+	user, err := op.GetUserByName(userClaims.Username)
+	if err != nil {
+		return nil, err
+	}
+	if userClaims.PwdTS != user.PwdTS {
+		return nil, errors.New("password has been changed")
+	}
+	if user.Disabled {
+		return nil, errors.New("user is disabled")
+	}
+	return user, nil
+}
+
 func AuthNotGuest(c *gin.Context) {
 	user := c.Request.Context().Value(conf.UserKey).(*model.User)
 	if user.IsGuest() {
@@ -139,6 +187,18 @@ func AuthNotGuest(c *gin.Context) {
 	}
 }
 
+// AuthNotAPIToken rejects api tokens from the account endpoints, the user of an api token
+// is a narrowed copy that must not be saved back nor create more credentials
+func AuthNotAPIToken(c *gin.Context) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if user.IsAPIToken() {
+		common.ErrorStrResp(c, "Not allowed with an api token", 403)
+		c.Abort()
+	} else {
+		c.Next()
+	}
+}
+
 func AuthAdmin(c *gin.Context) {
 	user := c.Request.Context().Value(conf.UserKey).(*model.User)
 	if !user.IsAdmin() {
@@ -1,10 +1,17 @@
 package middlewares
 
 import (
+	"net/http"
 	"strings"
+	"time"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
 	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/internal/sign"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/OpenListTeam/OpenList/v4/pkg/http_range"
+	"golang.org/x/time/rate"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
@@ -30,11 +37,15 @@ func Down(verifyFunc func(string, string) error) func(c *gin.Context) {
 			return
 		}
 		common.GinWithValue(c, conf.MetaKey, meta)
-		// verify sign
-		if needSign(meta, rawPath) {
+		// verify sign, a signed link checked by SignedLink replaces it. A sign that isn't needed
+		// still counts when it is valid, e.g. the links to the stored versions of a file.
+		_, linked := c.Request.Context().Value(conf.SignedLinkKey).(*model.SignedLink)
+		if !linked {
 			s := c.Query("sign")
 			err = verifyFunc(rawPath, strings.TrimSuffix(s, "/"))
-			if err != nil {
+			if err == nil {
+				common.GinWithValue(c, conf.SignedKey, true)
+			} else if needSign(meta, rawPath) {
 				common.ErrorPage(c, err, 401)
 				c.Abort()
 				return
@@ -44,6 +55,147 @@ func Down(verifyFunc func(string, string) error) func(c *gin.Context) {
 	}
 }
 
+// SignedLink checks the signed link in the slink query and applies its constraints to the
+// request, Down then skips the sign check
+func SignedLink(c *gin.Context) {
+	s := c.Query("slink")
+	if s == "" {
+		c.Next()
+		return
+	}
+	rawPath := c.Request.Context().Value(conf.PathKey).(string)
+	l, code, err := checkSignedLink(c, rawPath, s)
+	if err != nil {
+		common.ErrorPage(c, err, code)
+		c.Abort()
+		return
+	}
+	common.GinWithValue(c, conf.SignedLinkKey, l)
+	if l.RateLimit > 0 {
+		limit := l.RateLimit * 1024
+		c.Writer = &ResponseWriterWrapper{
+			ResponseWriter: c.Writer,
+			WrapWriter: &stream.RateLimitWriter{
+				Writer:  c.Writer,
+				Limiter: stream.BlockBurstLimiter{Limiter: rate.NewLimiter(rate.Limit(limit), limit)},
+				Ctx:     c,
+			},
+		}
+	}
+	c.Next()
+}
+
+// DownUser puts the user a download is for on the ctx so that the acl rules apply to it: the owner
+// of the signed link, else the user of the Authorization header. A verified sign was only given
+// out to someone allowed to read the file, so the download is left to the system. Other requests
+// could come from anyone, they are marked anonymous.
+func DownUser(c *gin.Context) {
+	if l, ok := c.Request.Context().Value(conf.SignedLinkKey).(*model.SignedLink); ok {
+		user, err := op.GetUserById(l.UserId)
+		if err != nil {
+			common.ErrorPage(c, err, 401)
+			c.Abort()
+			return
+		}
+		common.GinWithValue(c, conf.UserKey, user)
+		c.Next()
+		return
+	}
+	if signed, _ := c.Request.Context().Value(conf.SignedKey).(bool); signed {
+		c.Next()
+		return
+	}
+	// the header may also be the basic auth of a WebDAV client following a redirect
+	if token := c.GetHeader("Authorization"); token != "" {
+		if user, err := tokenUser(token); err == nil {
+			common.GinWithValue(c, conf.UserKey, user)
+			c.Next()
+			return
+		}
+	}
+	common.GinWithValue(c, conf.AnonymousKey, true)
+	c.Next()
+}
+
+// checkSignedLink returns the signed link the sign s was made for, or why it can't be used with its status code
+func checkSignedLink(c *gin.Context, rawPath, s string) (*model.SignedLink, int, error) {
+	id, err := sign.VerifyLink(rawPath, s)
+	if err != nil {
+		return nil, 401, err
+	}
+	l, err := op.GetSignedLinkById(id)
+	if err != nil {
+		return nil, 401, errors.New("the link is deleted")
+	}
+	if time.Now().After(l.Expires) {
+		return nil, 401, errors.New("the link is expired")
+	}
+	if user, err := op.GetUserById(l.UserId); err != nil || user.Disabled {
+		return nil, 401, errors.New("the link is no longer valid")
+	}
+	if !l.AllowsIP(c.ClientIP()) {
+		return nil, 403, errors.New("the link can't be used from this address")
+	}
+	// ranges are parsed against the size of the file, suffix ranges depend on it
+	rangeHeader := c.GetHeader("Range")
+	size := int64(-1)
+	if rangeHeader != "" {
+		obj, err := fs.Get(c.Request.Context(), rawPath, &fs.GetArgs{NoLog: true})
+		if err != nil {
+			return nil, 404, err
+		}
+		size = obj.GetSize()
+	}
+	// every download counts but the resume of one already counted for the client, which only a
+	// single range not starting at the start of the link can be
+	resume := false
+	if rangeHeader != "" {
+		ranges, err := http_range.ParseRange(rangeHeader, size)
+		if err == nil && len(ranges) > 1 && l.MaxDownloads > 0 {
+			return nil, 416, errors.New("multiple ranges can't be used with the link")
+		}
+		resume = err == nil && len(ranges) == 1 && ranges[0].Start != l.RangeStart
+	}
+	if l.RangeLength > 0 {
+		if err := limitRange(c.Request.Header, l.RangeStart, l.RangeLength, size); err != nil {
+			return nil, 416, err
+		}
+	}
+	if c.Request.Method == http.MethodGet && l.MaxDownloads > 0 {
+		ok, err := op.CountSignedLinkDownload(l, c.ClientIP(), resume)
+		if err != nil {
+			return nil, 500, err
+		}
+		if !ok {
+			return nil, 403, errors.New("the link has no download left")
+		}
+	}
+	return l, 0, nil
+}
+
+// limitRange makes the requested range fit in the length bytes from start, the whole of them
+// if no range is requested. A range going out of them is refused. The range is parsed against
+// the size of the file, which is only needed when there is one.
+func limitRange(header http.Header, start, length, size int64) error {
+	end := start + length
+	ranges, err := http_range.ParseRange(header.Get("Range"), size)
+	if err != nil || len(ranges) > 1 {
+		return errors.New("invalid range for the link")
+	}
+	r := http_range.Range{Start: start, Length: length}
+	if len(ranges) == 1 {
+		r = ranges[0]
+		if r.Start < start || r.Start >= end {
+			return errors.New("the range is out of the link")
+		}
+		if r.Length < 0 || r.Start+r.Length > end {
+			r.Length = end - r.Start
+		}
+	}
+	http_range.ApplyRangeToHttpHeader(r, header)
+	return nil
+}
+
 // TODO: implement
 // path maybe contains # ? etc.
 func parsePath(path string) string {
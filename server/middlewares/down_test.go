@@ -0,0 +1,190 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/OpenListTeam/OpenList/v4/drivers/local"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/sign"
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupDown(t *testing.T) (*gin.Engine, string) {
+	dB, err := gorm.Open(sqlite.Open("file:middlewares_down?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %+v", err)
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	db.Init(dB)
+	for _, item := range []model.SettingItem{
+		{Key: conf.Token, Value: "test-token", Type: conf.TypeString},
+		{Key: conf.SignAll, Value: "true", Type: conf.TypeBool},
+		{Key: conf.LinkExpiration, Value: "0", Type: conf.TypeNumber},
+		{Key: conf.VersionDir, Value: ".versions", Type: conf.TypeString},
+	} {
+		if err := op.SaveSettingItem(&item); err != nil {
+			t.Fatalf("failed to save setting: %+v", err)
+		}
+	}
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	_, err = op.CreateStorage(ctx, model.Storage{
+		Driver:    "Local",
+		MountPath: "/down_test",
+		Addition:  `{"root_folder_path":"` + filepath.ToSlash(root) + `"}`,
+	})
+	if err != nil {
+		t.Fatalf("failed to create storage: %+v", err)
+	}
+	t.Cleanup(func() {
+		if s, err := db.GetStorageByMountPath("/down_test"); err == nil {
+			_ = op.DeleteStorageById(ctx, s.ID)
+		}
+	})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/d/*path", PathParse, SignedLink, Down(sign.Verify), DownUser, func(c *gin.Context) {
+		rawPath := c.Request.Context().Value(conf.PathKey).(string)
+		l, _, err := fs.Link(c.Request.Context(), rawPath, model.LinkArgs{})
+		if err != nil {
+			c.Status(http.StatusForbidden)
+			return
+		}
+		_ = l.Close()
+		c.Status(http.StatusOK)
+	})
+	return r, root
+}
+
+func TestDownSignedIgnoresOtherUsersRules(t *testing.T) {
+	r, _ := setupDown(t)
+	bob := &model.User{Username: "bob", Role: model.GENERAL, Permission: 1}
+	if err := op.CreateUser(bob); err != nil {
+		t.Fatalf("failed to create user: %+v", err)
+	}
+	t.Cleanup(func() { _ = op.DeleteUserById(bob.ID) })
+	rule := &model.ACLRule{
+		Path:        "/down_test/**",
+		SubjectType: model.ACLSubjectUser,
+		SubjectId:   bob.ID,
+		Operations:  []string{model.ACLRead},
+		Effect:      model.ACLDeny,
+	}
+	if err := op.CreateACLRule(rule); err != nil {
+		t.Fatalf("failed to create acl rule: %+v", err)
+	}
+	t.Cleanup(func() { _ = op.DeleteACLRuleById(rule.ID) })
+
+	get := func(url string) int {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		return w.Code
+	}
+	if code := get("/d/down_test/a.txt?sign=" + sign.Sign("/down_test/a.txt")); code != http.StatusOK {
+		t.Errorf("expected the signed download to work despite a rule of another user, got %d", code)
+	}
+	if code := get("/d/down_test/a.txt"); code != http.StatusUnauthorized {
+		t.Errorf("expected a download without sign to be refused, got %d", code)
+	}
+
+	if !op.ACLAllowsAnonymous(model.ACLRead, "/down_test/a.txt") {
+		t.Errorf("expected a rule of a user not to apply to anonymous clients")
+	}
+	everyone := &model.ACLRule{
+		Path:        "/down_test/**",
+		SubjectType: model.ACLSubjectEveryone,
+		Operations:  []string{model.ACLRead},
+		Effect:      model.ACLDeny,
+	}
+	if err := op.CreateACLRule(everyone); err != nil {
+		t.Fatalf("failed to create acl rule: %+v", err)
+	}
+	t.Cleanup(func() { _ = op.DeleteACLRuleById(everyone.ID) })
+	if op.ACLAllowsAnonymous(model.ACLRead, "/down_test/a.txt") {
+		t.Errorf("expected a rule for everyone to apply to anonymous clients")
+	}
+}
+
+func TestDownVersionLinkWithoutSignNeeded(t *testing.T) {
+	r, root := setupDown(t)
+	if err := op.SaveSettingItem(&model.SettingItem{Key: conf.SignAll, Value: "false", Type: conf.TypeBool}); err != nil {
+		t.Fatalf("failed to save setting: %+v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, ".versions", "1"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, ".versions", "1", "a.txt"), []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	get := func(url string) int {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		return w.Code
+	}
+	versionPath := "/down_test/.versions/1/a.txt"
+	if code := get("/d" + versionPath + "?sign=" + sign.Sign(versionPath)); code != http.StatusOK {
+		t.Errorf("expected the signed version link to work, got %d", code)
+	}
+	if code := get("/d" + versionPath); code != http.StatusForbidden {
+		t.Errorf("expected the versions dir to be out of reach without sign, got %d", code)
+	}
+	if code := get("/d/down_test/a.txt"); code != http.StatusOK {
+		t.Errorf("expected a download without sign to work when no sign is needed, got %d", code)
+	}
+}
+
+func TestSignedLinkMaxDownloadsWithRanges(t *testing.T) {
+	r, _ := setupDown(t)
+	alice := &model.User{Username: "alice", Role: model.GENERAL, Permission: 1}
+	if err := op.CreateUser(alice); err != nil {
+		t.Fatalf("failed to create user: %+v", err)
+	}
+	t.Cleanup(func() { _ = op.DeleteUserById(alice.ID) })
+	l := &model.SignedLink{UserId: alice.ID, Path: "/down_test/a.txt", Expires: time.Now().Add(time.Hour), MaxDownloads: 1}
+	if err := op.CreateSignedLink(l); err != nil {
+		t.Fatalf("failed to create signed link: %+v", err)
+	}
+	t.Cleanup(func() { _ = op.DeleteSignedLinkById(l.ID) })
+	url := "/d/down_test/a.txt?slink=" + sign.Link(l.ID, l.Path, l.Expires)
+	get := func(ip, rangeHeader string) int {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		req.RemoteAddr = ip + ":1234"
+		if rangeHeader != "" {
+			req.Header.Set("Range", rangeHeader)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	if code := get("192.0.2.1", "bytes=1-"); code != http.StatusOK {
+		t.Fatalf("expected the first download to work, got %d", code)
+	}
+	if code := get("192.0.2.2", "bytes=1-"); code != http.StatusForbidden {
+		t.Errorf("expected a range from another client to count as a download, got %d", code)
+	}
+	if code := get("192.0.2.1", ""); code != http.StatusForbidden {
+		t.Errorf("expected a new download from the same client to count, got %d", code)
+	}
+	if code := get("192.0.2.1", "bytes=2-"); code != http.StatusOK {
+		t.Errorf("expected the client to resume its download, got %d", code)
+	}
+	if code := get("192.0.2.1", "bytes=0-0,1-"); code != http.StatusRequestedRangeNotSatisfiable {
+		t.Errorf("expected multiple ranges to be refused, got %d", code)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/cmd/flags"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/message"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/sign"
 	"github.com/OpenListTeam/OpenList/v4/internal/stream"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
@@ -35,7 +36,7 @@ func Init(e *gin.Engine) {
 	g.GET("/manifest.json", static.ManifestJSON)
 	g.GET("/i/:link_name", handles.Plist)
 	common.SecretKey = []byte(conf.Conf.JwtSecret)
-	g.Use(middlewares.StoragesLoaded)
+	g.Use(middlewares.StoragesLoaded, middlewares.AuditContext(model.ProtocolWeb))
 	if conf.Conf.MaxConnections > 0 {
 		g.Use(middlewares.MaxAllowed(conf.Conf.MaxConnections))
 	}
@@ -44,17 +45,17 @@ func Init(e *gin.Engine) {
 
 	downloadLimiter := middlewares.DownloadRateLimiter(stream.ClientDownloadLimit)
 	signCheck := middlewares.Down(sign.Verify)
-	g.GET("/d/*path", middlewares.PathParse, signCheck, downloadLimiter, handles.Down)
-	g.GET("/p/*path", middlewares.PathParse, signCheck, downloadLimiter, handles.Proxy)
-	g.HEAD("/d/*path", middlewares.PathParse, signCheck, handles.Down)
-	g.HEAD("/p/*path", middlewares.PathParse, signCheck, handles.Proxy)
+	g.GET("/d/*path", middlewares.PathParse, middlewares.SignedLink, signCheck, middlewares.DownUser, downloadLimiter, handles.Down)
+	g.GET("/p/*path", middlewares.PathParse, middlewares.SignedLink, signCheck, middlewares.DownUser, downloadLimiter, handles.Proxy)
+	g.HEAD("/d/*path", middlewares.PathParse, middlewares.SignedLink, signCheck, middlewares.DownUser, handles.Down)
+	g.HEAD("/p/*path", middlewares.PathParse, middlewares.SignedLink, signCheck, middlewares.DownUser, handles.Proxy)
 	archiveSignCheck := middlewares.Down(sign.VerifyArchive)
-	g.GET("/ad/*path", middlewares.PathParse, archiveSignCheck, downloadLimiter, handles.ArchiveDown)
-	g.GET("/ap/*path", middlewares.PathParse, archiveSignCheck, downloadLimiter, handles.ArchiveProxy)
-	g.GET("/ae/*path", middlewares.PathParse, archiveSignCheck, downloadLimiter, handles.ArchiveInternalExtract)
-	g.HEAD("/ad/*path", middlewares.PathParse, archiveSignCheck, handles.ArchiveDown)
-	g.HEAD("/ap/*path", middlewares.PathParse, archiveSignCheck, handles.ArchiveProxy)
-	g.HEAD("/ae/*path", middlewares.PathParse, archiveSignCheck, handles.ArchiveInternalExtract)
+	g.GET("/ad/*path", middlewares.PathParse, archiveSignCheck, middlewares.DownUser, downloadLimiter, handles.ArchiveDown)
+	g.GET("/ap/*path", middlewares.PathParse, archiveSignCheck, middlewares.DownUser, downloadLimiter, handles.ArchiveProxy)
+	g.GET("/ae/*path", middlewares.PathParse, archiveSignCheck, middlewares.DownUser, downloadLimiter, handles.ArchiveInternalExtract)
+	g.HEAD("/ad/*path", middlewares.PathParse, archiveSignCheck, middlewares.DownUser, handles.ArchiveDown)
+	g.HEAD("/ap/*path", middlewares.PathParse, archiveSignCheck, middlewares.DownUser, handles.ArchiveProxy)
+	g.HEAD("/ae/*path", middlewares.PathParse, archiveSignCheck, middlewares.DownUser, handles.ArchiveInternalExtract)
 
 	g.GET("/sd/:sid", middlewares.EmptyPathParse, middlewares.SharingIdParse, downloadLimiter, handles.SharingDown)
 	g.GET("/sd/:sid/*path", middlewares.PathParse, middlewares.SharingIdParse, downloadLimiter, handles.SharingDown)
@@ -67,18 +68,26 @@ func Init(e *gin.Engine) {
 
 	api := g.Group("/api")
 	auth := api.Group("", middlewares.Auth(false))
-	webauthn := api.Group("/authn", middlewares.Authn)
+	webauthn := api.Group("/authn", middlewares.Authn, middlewares.AuthNotAPIToken)
 
 	api.POST("/auth/login", handles.Login)
 	api.POST("/auth/login/hash", handles.LoginHash)
 	api.POST("/auth/login/ldap", handles.LoginLdap)
 	auth.GET("/me", handles.CurrentUser)
-	auth.POST("/me/update", handles.UpdateCurrent)
+	auth.POST("/me/update", middlewares.AuthNotAPIToken, handles.UpdateCurrent)
 	auth.GET("/me/sshkey/list", handles.ListMyPublicKey)
-	auth.POST("/me/sshkey/add", handles.AddMyPublicKey)
-	auth.POST("/me/sshkey/delete", handles.DeleteMyPublicKey)
-	auth.POST("/auth/2fa/generate", handles.Generate2FA)
-	auth.POST("/auth/2fa/verify", handles.Verify2FA)
+	auth.POST("/me/sshkey/add", middlewares.AuthNotAPIToken, handles.AddMyPublicKey)
+	auth.POST("/me/sshkey/delete", middlewares.AuthNotAPIToken, handles.DeleteMyPublicKey)
+	auth.GET("/me/token/list", middlewares.AuthNotAPIToken, handles.ListMyAPITokens)
+	auth.POST("/me/token/create", middlewares.AuthNotAPIToken, handles.CreateMyAPIToken)
+	auth.POST("/me/token/revoke", middlewares.AuthNotAPIToken, handles.RevokeMyAPIToken)
+	auth.POST("/me/token/delete", middlewares.AuthNotAPIToken, handles.DeleteMyAPIToken)
+	auth.GET("/me/signed_link/list", middlewares.AuthNotGuest, handles.ListMySignedLinks)
+	auth.POST("/me/signed_link/create", middlewares.AuthNotGuest, handles.CreateMySignedLink)
+	auth.POST("/me/signed_link/delete", middlewares.AuthNotGuest, handles.DeleteMySignedLink)
+	auth.GET("/me/quota", handles.GetMyQuota)
+	auth.POST("/auth/2fa/generate", middlewares.AuthNotAPIToken, handles.Generate2FA)
+	auth.POST("/auth/2fa/verify", middlewares.AuthNotAPIToken, handles.Verify2FA)
 	auth.GET("/auth/logout", handles.LogOut)
 
 	// auth
@@ -105,7 +114,9 @@ func Init(e *gin.Engine) {
 	fsAndShare(api.Group("/fs", middlewares.Auth(true)))
 	_task(auth.Group("/task", middlewares.AuthNotGuest))
 	_sharing(auth.Group("/share", middlewares.AuthNotGuest))
-	admin(auth.Group("/admin", middlewares.AuthAdmin))
+	sharingUpload(api.Group("/share/upload"))
+	sharingOTP(api.Group("/share/otp"))
+	admin(auth.Group("/admin", middlewares.AuthAdmin, middlewares.AuditAdmin))
 	if flags.Debug || flags.Dev {
 		debug(g.Group("/debug"))
 	}
@@ -132,6 +143,38 @@ func admin(g *gin.RouterGroup) {
 	user.POST("/del_cache", handles.DelUserCache)
 	user.GET("/sshkey/list", handles.ListPublicKeys)
 	user.POST("/sshkey/delete", handles.DeletePublicKey)
+	user.GET("/token/list", handles.ListAPITokens)
+	user.POST("/token/revoke", handles.RevokeAPIToken)
+	user.GET("/quota", handles.GetUserQuota)
+	user.POST("/quota/rescan", handles.RescanUserQuota)
+
+	group := g.Group("/group")
+	group.GET("/list", handles.ListGroups)
+	group.GET("/get", handles.GetGroup)
+	group.POST("/create", handles.CreateGroup)
+	group.POST("/update", handles.UpdateGroup)
+	group.POST("/delete", handles.DeleteGroup)
+
+	acl := g.Group("/acl")
+	acl.GET("/list", handles.ListACLRules)
+	acl.POST("/create", handles.CreateACLRule)
+	acl.POST("/update", handles.UpdateACLRule)
+	acl.POST("/delete", handles.DeleteACLRule)
+	acl.GET("/effective", handles.EffectivePermissions)
+
+	audit := g.Group("/audit")
+	audit.GET("/list", handles.ListAuditLogs)
+	audit.GET("/export", handles.ExportAuditLogs)
+
+	webhook := g.Group("/webhook")
+	webhook.GET("/list", handles.ListWebhooks)
+	webhook.GET("/events", handles.ListWebhookEvents)
+	webhook.POST("/create", handles.CreateWebhook)
+	webhook.POST("/update", handles.UpdateWebhook)
+	webhook.POST("/delete", handles.DeleteWebhook)
+	webhook.POST("/test", handles.TestWebhook)
+	webhook.GET("/deliveries", handles.ListWebhookDeliveries)
+	webhook.POST("/redeliver", handles.RedeliverWebhook)
 
 	storage := g.Group("/storage")
 	storage.GET("/list", handles.ListStorages)
@@ -180,6 +223,24 @@ func admin(g *gin.RouterGroup) {
 	index.POST("/stop", middlewares.SearchIndex, handles.StopIndex)
 	index.POST("/clear", middlewares.SearchIndex, handles.ClearIndex)
 	index.GET("/progress", middlewares.SearchIndex, handles.GetProgress)
+	index.GET("/storages", middlewares.SearchIndex, handles.ListStorageIndexes)
+	index.POST("/storage/update", middlewares.SearchIndex, handles.UpdateStorageIndex)
+
+	sync := g.Group("/sync")
+	sync.GET("/list", handles.ListSyncJobs)
+	sync.GET("/get", handles.GetSyncJob)
+	sync.POST("/create", handles.CreateSyncJob)
+	sync.POST("/update", handles.UpdateSyncJob)
+	sync.POST("/delete", handles.DeleteSyncJob)
+	sync.POST("/run", handles.RunSyncJob)
+	sync.GET("/runs", handles.ListSyncRuns)
+
+	dedup := g.Group("/dedup")
+	dedup.POST("/scan", middlewares.SearchIndex, handles.StartDuplicateScan)
+	dedup.GET("/scans", handles.ListDuplicateScans)
+	dedup.POST("/scan/delete", handles.DeleteDuplicateScan)
+	dedup.GET("/sets", handles.ListDuplicateSets)
+	dedup.POST("/resolve", handles.ResolveDuplicates)
 
 	scan := g.Group("/scan")
 	scan.POST("/start", handles.StartManualScan)
@@ -206,8 +267,18 @@ func _fs(g *gin.RouterGroup) {
 	g.POST("/move", handles.FsMove)
 	g.POST("/recursive_move", handles.FsRecursiveMove)
 	g.POST("/copy", handles.FsCopy)
+	transfer := g.Group("/transfer")
+	transfer.POST("/plan", handles.FsTransferPlan)
+	transfer.POST("/submit", handles.FsTransferSubmit)
 	g.POST("/remove", handles.FsRemove)
 	g.POST("/remove_empty_directory", handles.FsRemoveEmptyDirectory)
+	trash := g.Group("/trash")
+	trash.Any("/list", handles.ListTrash)
+	trash.POST("/restore", handles.RestoreTrash)
+	trash.POST("/purge", handles.PurgeTrash)
+	version := g.Group("/version")
+	version.Any("/list", handles.FsListVersions)
+	version.POST("/restore", handles.FsRestoreVersion)
 	uploadLimiter := middlewares.UploadRateLimiter(stream.ClientUploadLimit)
 	g.PUT("/put", middlewares.FsUp, uploadLimiter, handles.FsStream)
 	g.PUT("/form", middlewares.FsUp, uploadLimiter, handles.FsForm)
@@ -225,9 +296,26 @@ func _task(g *gin.RouterGroup) {
 	handles.SetupTaskRoute(g)
 }
 
+// sharingUpload serves the recipients of upload sharings, they need no account
+func sharingUpload(g *gin.RouterGroup) {
+	g.GET("/info", handles.UploadSharingInfo)
+	g.PUT("/put", middlewares.UploadRateLimiter(stream.ClientUploadLimit), handles.SharingUpload)
+}
+
+// sharingOTP serves the recipients of restricted sharings, they need no account
+func sharingOTP(g *gin.RouterGroup) {
+	g.POST("/send", handles.SendSharingCode)
+	g.POST("/verify", handles.VerifySharingCode)
+}
+
 func _sharing(g *gin.RouterGroup) {
 	g.Any("/list", handles.ListSharings)
 	g.GET("/get", handles.GetSharing)
+	g.GET("/stats", handles.GetSharingStats)
+	g.GET("/access_log", handles.ListSharingAccesses)
+	g.GET("/recipients", handles.ListSharingRecipients)
+	g.POST("/recipient/revoke", handles.SetRevokeSharingRecipient(true))
+	g.POST("/recipient/restore", handles.SetRevokeSharingRecipient(false))
 	g.POST("/create", handles.CreateSharing)
 	g.POST("/update", handles.UpdateSharing)
 	g.POST("/delete", handles.DeleteSharing)
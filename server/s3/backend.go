@@ -9,11 +9,11 @@ import (
 	"io"
 	"path"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/pkg/errors"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/fs"
@@ -35,15 +35,11 @@ var (
 
 // s3Backend implements the gofacess3.Backend interface to make an S3
 // backend for gofakes3
-type s3Backend struct {
-	meta *sync.Map
-}
+type s3Backend struct{}
 
 // newBackend creates a new SimpleBucketBackend.
-func newBackend() gofakes3.Backend {
-	return &s3Backend{
-		meta: new(sync.Map),
-	}
+func newBackend() *s3Backend {
+	return &s3Backend{}
 }
 
 // ListBuckets always returns the default bucket.
@@ -54,11 +50,18 @@ func (b *s3Backend) ListBuckets(ctx context.Context) ([]gofakes3.BucketInfo, err
 	}
 	var response []gofakes3.BucketInfo
 	for _, b := range buckets {
-		node, _ := fs.Get(ctx, b.Path, &fs.GetArgs{})
+		b, ok := resolveBucket(ctx, b)
+		if !ok {
+			continue
+		}
+		var created time.Time
+		if node, err := fs.Get(ctx, b.Path, &fs.GetArgs{}); err == nil {
+			created = node.ModTime()
+		}
 		response = append(response, gofakes3.BucketInfo{
 			// Name:         gofakes3.URLEncode(b.Name),
 			Name:         b.Name,
-			CreationDate: gofakes3.NewContentTime(node.ModTime()),
+			CreationDate: gofakes3.NewContentTime(created),
 		})
 	}
 	return response, nil
@@ -66,7 +69,7 @@ func (b *s3Backend) ListBuckets(ctx context.Context) ([]gofakes3.BucketInfo, err
 
 // ListBucket lists the objects in the given bucket.
 func (b *s3Backend) ListBucket(ctx context.Context, bucketName string, prefix *gofakes3.Prefix, page gofakes3.ListBucketPage) (*gofakes3.ObjectList, error) {
-	bucket, err := getBucketByName(bucketName)
+	bucket, err := getBucketByName(ctx, bucketName)
 	if err != nil {
 		return nil, err
 	}
@@ -87,7 +90,7 @@ func (b *s3Backend) ListBucket(ctx context.Context, bucketName string, prefix *g
 	response := gofakes3.NewObjectList()
 	path, remaining := prefixParser(prefix)
 
-	err = b.entryListR(bucketPath, path, remaining, prefix.HasDelimiter, response)
+	err = b.entryListR(ctx, bucketPath, path, remaining, prefix.HasDelimiter, response)
 	if err == gofakes3.ErrNoSuchKey {
 		// AWS just returns an empty list
 		response = gofakes3.NewObjectList()
@@ -99,16 +102,17 @@ func (b *s3Backend) ListBucket(ctx context.Context, bucketName string, prefix *g
 }
 
 // HeadObject returns the fileinfo for the given object name.
-//
-// Note that the metadata is not supported yet.
 func (b *s3Backend) HeadObject(ctx context.Context, bucketName, objectName string) (*gofakes3.Object, error) {
-	bucket, err := getBucketByName(bucketName)
+	bucket, err := getBucketByName(ctx, bucketName)
 	if err != nil {
 		return nil, err
 	}
 	bucketPath := bucket.Path
 
 	fp := path.Join(bucketPath, objectName)
+	if !canRead(ctx, fp) {
+		return nil, gofakes3.KeyNotFound(objectName)
+	}
 	fmeta, _ := op.GetNearestMeta(fp)
 	node, err := fs.Get(context.WithValue(ctx, conf.MetaKey, fmeta), fp, &fs.GetArgs{})
 	if err != nil {
@@ -120,23 +124,22 @@ func (b *s3Backend) HeadObject(ctx context.Context, bucketName, objectName strin
 	}
 
 	size := node.GetSize()
-	// hash := getFileHashByte(fobj)
+	stored := objectMeta(fp, node)
 
 	meta := map[string]string{
-		"Last-Modified": node.ModTime().Format(timeFormat),
+		"Last-Modified": node.ModTime().UTC().Format(timeFormat),
 		"Content-Type":  utils.GetMimeType(fp),
 	}
 
-	if val, ok := b.meta.Load(fp); ok {
-		metaMap := val.(map[string]string)
-		for k, v := range metaMap {
+	if stored != nil {
+		for k, v := range stored.Metadata {
 			meta[k] = v
 		}
 	}
 
 	return &gofakes3.Object{
-		Name: objectName,
-		// Hash:     hash,
+		Name:     objectName,
+		Hash:     getFileHashByte(node, stored),
 		Metadata: meta,
 		Size:     size,
 		Contents: noOpReadCloser{},
@@ -145,13 +148,16 @@ func (b *s3Backend) HeadObject(ctx context.Context, bucketName, objectName strin
 
 // GetObject fetchs the object from the filesystem.
 func (b *s3Backend) GetObject(ctx context.Context, bucketName, objectName string, rangeRequest *gofakes3.ObjectRangeRequest) (s3Obj *gofakes3.Object, err error) {
-	bucket, err := getBucketByName(bucketName)
+	bucket, err := getBucketByName(ctx, bucketName)
 	if err != nil {
 		return nil, err
 	}
 	bucketPath := bucket.Path
 
 	fp := path.Join(bucketPath, objectName)
+	if !canRead(ctx, fp) {
+		return nil, gofakes3.KeyNotFound(objectName)
+	}
 	fmeta, _ := op.GetNearestMeta(fp)
 	node, err := fs.Get(context.WithValue(ctx, conf.MetaKey, fmeta), fp, &fs.GetArgs{})
 	if err != nil {
@@ -167,6 +173,7 @@ func (b *s3Backend) GetObject(ctx context.Context, bucketName, objectName string
 		return nil, err
 	}
 	defer func() {
+		audit.LogDownload(ctx, fp, node.GetSize(), err)
 		if s3Obj == nil {
 			_ = link.Close()
 		}
@@ -196,23 +203,23 @@ func (b *s3Backend) GetObject(ctx context.Context, bucketName, objectName string
 		return nil, err
 	}
 
+	stored := objectMeta(fp, node)
 	meta := map[string]string{
-		"Last-Modified":       node.ModTime().Format(timeFormat),
+		"Last-Modified":       node.ModTime().UTC().Format(timeFormat),
 		"Content-Disposition": utils.GenerateContentDisposition(file.GetName()),
 		"Content-Type":        utils.GetMimeType(fp),
 	}
 
-	if val, ok := b.meta.Load(fp); ok {
-		metaMap := val.(map[string]string)
-		for k, v := range metaMap {
+	if stored != nil {
+		for k, v := range stored.Metadata {
 			meta[k] = v
 		}
 	}
 
 	return &gofakes3.Object{
 		// Name: gofakes3.URLEncode(objectName),
-		Name: objectName,
-		// Hash:     "",
+		Name:     objectName,
+		Hash:     getFileHashByte(node, stored),
 		Metadata: meta,
 		Size:     size,
 		Range:    rnge,
@@ -220,10 +227,28 @@ func (b *s3Backend) GetObject(ctx context.Context, bucketName, objectName string
 	}, nil
 }
 
-// TouchObject creates or updates meta on specified object.
+// TouchObject replaces the stored meta of the object at the full path fp.
 func (b *s3Backend) TouchObject(ctx context.Context, fp string, meta map[string]string) (result gofakes3.PutObjectResult, err error) {
-	//TODO: implement
-	return result, gofakes3.ErrNotImplemented
+	if !canWrite(ctx, path.Dir(fp)) {
+		return result, errs.PermissionDenied
+	}
+	fmeta, _ := op.GetNearestMeta(fp)
+	node, err := fs.Get(context.WithValue(ctx, conf.MetaKey, fmeta), fp, &fs.GetArgs{})
+	if err != nil || node.IsDir() {
+		return result, gofakes3.KeyNotFound(path.Base(fp))
+	}
+	etag := ""
+	if old := objectMeta(fp, node); old != nil {
+		etag = old.ETag
+	}
+	err = op.SaveS3ObjectMeta(&model.S3ObjectMeta{
+		Path:     fp,
+		Metadata: userMetadata(meta),
+		ETag:     etag,
+		Size:     node.GetSize(),
+		Modified: node.ModTime(),
+	})
+	return result, err
 }
 
 // PutObject creates or overwrites the object with the given name.
@@ -232,7 +257,7 @@ func (b *s3Backend) PutObject(
 	meta map[string]string,
 	input io.Reader, size int64,
 ) (result gofakes3.PutObjectResult, err error) {
-	bucket, err := getBucketByName(bucketName)
+	bucket, err := getBucketByName(ctx, bucketName)
 	if err != nil {
 		return result, err
 	}
@@ -251,6 +276,9 @@ func (b *s3Backend) PutObject(
 		reqPath = path.Dir(fp)
 	}
 	log.Debugf("reqPath: %s", reqPath)
+	if !canWrite(ctx, path.Dir(fp)) {
+		return result, errs.PermissionDenied
+	}
 	fmeta, _ := op.GetNearestMeta(fp)
 	ctx = context.WithValue(ctx, conf.MetaKey, fmeta)
 
@@ -296,9 +324,10 @@ func (b *s3Backend) PutObject(
 	if setting.GetBool(conf.IgnoreSystemFiles) && utils.IsSystemFile(obj.Name) {
 		return result, errs.IgnoredSystemFile
 	}
+	hashed := newMD5Reader(input)
 	stream := &stream.FileStream{
 		Obj:      &obj,
-		Reader:   input,
+		Reader:   hashed,
 		Mimetype: meta["Content-Type"],
 	}
 
@@ -313,7 +342,12 @@ func (b *s3Backend) PutObject(
 	// 	return result, err
 	// }
 
-	b.meta.Store(fp, meta)
+	etag := ""
+	// drivers that don't read the whole stream leave no etag
+	if hashed.n == size {
+		etag = hex.EncodeToString(hashed.hash.Sum(nil))
+	}
+	saveObjectMeta(ctx, fp, meta, etag)
 
 	return result, nil
 }
@@ -323,9 +357,13 @@ func (b *s3Backend) DeleteMulti(ctx context.Context, bucketName string, objects
 	for _, object := range objects {
 		if err := b.deleteObject(ctx, bucketName, object); err != nil {
 			log.Errorf("delete object failed: %v", err)
+			code := gofakes3.ErrInternal
+			if errors.Is(err, errs.PermissionDenied) {
+				code = errAccessDenied
+			}
 			result.Error = append(result.Error, gofakes3.ErrorResult{
-				Code:    gofakes3.ErrInternal,
-				Message: gofakes3.ErrInternal.Message(),
+				Code:    code,
+				Message: code.Message(),
 				Key:     object,
 			})
 		} else {
@@ -345,13 +383,16 @@ func (b *s3Backend) DeleteObject(ctx context.Context, bucketName, objectName str
 
 // deleteObject deletes the object from the filesystem.
 func (b *s3Backend) deleteObject(ctx context.Context, bucketName, objectName string) error {
-	bucket, err := getBucketByName(bucketName)
+	bucket, err := getBucketByName(ctx, bucketName)
 	if err != nil {
 		return err
 	}
 	bucketPath := bucket.Path
 
 	fp := path.Join(bucketPath, objectName)
+	if !canRemove(ctx, path.Dir(fp)) {
+		return errs.PermissionDenied
+	}
 	fmeta, _ := op.GetNearestMeta(fp)
 	// S3 does not report an error when attemping to delete a key that does not exist, so
 	// we need to skip IsNotExist errors.
@@ -359,7 +400,8 @@ func (b *s3Backend) deleteObject(ctx context.Context, bucketName, objectName str
 		return err
 	}
 
-	fs.Remove(ctx, fp)
+	// the meta of the object is deleted with it by fs
+	_ = fs.Remove(ctx, fp)
 	return nil
 }
 
@@ -380,7 +422,7 @@ func (b *s3Backend) BucketExists(ctx context.Context, name string) (exists bool,
 		return false, err
 	}
 	for _, b := range buckets {
-		if b.Name == name {
+		if _, ok := resolveBucket(ctx, b); ok && b.Name == name {
 			return true, nil
 		}
 	}
@@ -389,20 +431,29 @@ func (b *s3Backend) BucketExists(ctx context.Context, name string) (exists bool,
 
 // CopyObject copy specified object from srcKey to dstKey.
 func (b *s3Backend) CopyObject(ctx context.Context, srcBucket, srcKey, dstBucket, dstKey string, meta map[string]string) (result gofakes3.CopyObjectResult, err error) {
+	replace := strings.EqualFold(meta["X-Amz-Metadata-Directive"], "REPLACE")
 	if srcBucket == dstBucket && srcKey == dstKey {
-		//TODO: update meta
-		return result, nil
-	}
-
-	srcB, err := getBucketByName(srcBucket)
-	if err != nil {
-		return result, err
+		if !replace {
+			return result, gofakes3.ErrorInvalidArgument("x-amz-metadata-directive", "COPY",
+				"This copy request is illegal because it is trying to copy an object to itself without changing the object's metadata.")
+		}
+		bucket, err := getBucketByName(ctx, srcBucket)
+		if err != nil {
+			return result, err
+		}
+		if _, err := b.TouchObject(ctx, path.Join(bucket.Path, srcKey), meta); err != nil {
+			return result, err
+		}
+		obj, err := b.HeadObject(ctx, srcBucket, srcKey)
+		if err != nil {
+			return result, err
+		}
+		modified, _ := time.Parse(timeFormat, obj.Metadata["Last-Modified"])
+		return gofakes3.CopyObjectResult{
+			ETag:         `"` + hex.EncodeToString(obj.Hash) + `"`,
+			LastModified: gofakes3.NewContentTime(modified),
+		}, nil
 	}
-	srcBucketPath := srcB.Path
-
-	srcFp := path.Join(srcBucketPath, srcKey)
-	fmeta, _ := op.GetNearestMeta(srcFp)
-	srcNode, err := fs.Get(context.WithValue(ctx, conf.MetaKey, fmeta), srcFp, &fs.GetArgs{})
 
 	c, err := b.GetObject(ctx, srcBucket, srcKey, nil)
 	if err != nil {
@@ -412,16 +463,32 @@ func (b *s3Backend) CopyObject(ctx context.Context, srcBucket, srcKey, dstBucket
 		_ = c.Contents.Close()
 	}()
 
-	for k, v := range c.Metadata {
-		if _, found := meta[k]; !found && k != "X-Amz-Acl" {
-			meta[k] = v
+	srcB, err := getBucketByName(ctx, srcBucket)
+	if err != nil {
+		return result, err
+	}
+	srcFp := path.Join(srcB.Path, srcKey)
+	fmeta, _ := op.GetNearestMeta(srcFp)
+	srcNode, err := fs.Get(context.WithValue(ctx, conf.MetaKey, fmeta), srcFp, &fs.GetArgs{})
+	if err != nil {
+		return result, gofakes3.KeyNotFound(srcKey)
+	}
+
+	// the copy keeps the metadata of the source unless the request replaces it
+	dstMeta := userMetadata(meta)
+	if !replace {
+		dstMeta = make(map[string]string)
+		if stored := objectMeta(srcFp, srcNode); stored != nil {
+			for k, v := range stored.Metadata {
+				dstMeta[k] = v
+			}
 		}
 	}
-	if _, ok := meta["mtime"]; !ok {
-		meta["mtime"] = swift.TimeToFloatString(srcNode.ModTime())
+	if _, ok := dstMeta["X-Amz-Meta-Mtime"]; !ok {
+		dstMeta["mtime"] = swift.TimeToFloatString(srcNode.ModTime())
 	}
 
-	_, err = b.PutObject(ctx, dstBucket, dstKey, meta, c.Contents, c.Size)
+	_, err = b.PutObject(ctx, dstBucket, dstKey, dstMeta, c.Contents, c.Size)
 	if err != nil {
 		return
 	}
@@ -0,0 +1,46 @@
+package s3
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/itsHenry35/gofakes3"
+)
+
+func TestCheckConditions(t *testing.T) {
+	obj := &gofakes3.Object{
+		Hash:     []byte{0xab, 0xcd},
+		Metadata: map[string]string{"Last-Modified": "Sat, 17 Oct 2026 10:00:00 GMT"},
+	}
+	tests := []struct {
+		method string
+		header map[string]string
+		obj    *gofakes3.Object
+		want   int
+	}{
+		{http.MethodGet, map[string]string{"If-Match": `"abcd"`}, obj, 0},
+		{http.MethodGet, map[string]string{"If-Match": `"ffff"`}, obj, http.StatusPreconditionFailed},
+		{http.MethodGet, map[string]string{"If-None-Match": `"ffff", "abcd"`}, obj, http.StatusNotModified},
+		{http.MethodHead, map[string]string{"If-None-Match": `"ffff"`}, obj, 0},
+		{http.MethodGet, map[string]string{"If-Modified-Since": "Sat, 17 Oct 2026 10:00:00 GMT"}, obj, http.StatusNotModified},
+		{http.MethodGet, map[string]string{"If-Modified-Since": "Sat, 17 Oct 2026 09:00:00 GMT"}, obj, 0},
+		{http.MethodGet, map[string]string{"If-Unmodified-Since": "Sat, 17 Oct 2026 09:00:00 GMT"}, obj, http.StatusPreconditionFailed},
+		// If-None-Match wins over If-Modified-Since
+		{http.MethodGet, map[string]string{"If-None-Match": `"ffff"`, "If-Modified-Since": "Sat, 17 Oct 2026 10:00:00 GMT"}, obj, 0},
+		{http.MethodGet, map[string]string{"If-Match": `"ffff"`}, nil, 0},
+		{http.MethodPut, map[string]string{"If-None-Match": "*"}, obj, http.StatusPreconditionFailed},
+		{http.MethodPut, map[string]string{"If-None-Match": "*"}, nil, 0},
+		{http.MethodPut, map[string]string{"If-Match": `"abcd"`}, nil, http.StatusPreconditionFailed},
+		{http.MethodPut, map[string]string{"If-Match": `"abcd"`}, obj, 0},
+	}
+	for i, tt := range tests {
+		r := httptest.NewRequest(tt.method, "/bucket/key", nil)
+		for k, v := range tt.header {
+			r.Header.Set(k, v)
+		}
+		if got := checkConditions(r, tt.obj); got != tt.want {
+			t.Errorf("%d: got %d, want %d", i, got, tt.want)
+		}
+	}
+}
@@ -2,7 +2,15 @@
 // Package s3 implements a fake s3 server for openlist
 package s3
 
-import "io"
+import (
+	"bufio"
+	"crypto/md5"
+	"fmt"
+	"hash"
+	"io"
+	"strconv"
+	"strings"
+)
 
 type noOpReadCloser struct{}
 
@@ -34,3 +42,70 @@ func (rwc *readerWithCloser) Close() error {
 	}
 	return nil
 }
+
+// md5Reader hashes what is read through it
+type md5Reader struct {
+	r    io.Reader
+	hash hash.Hash
+	n    int64
+}
+
+func newMD5Reader(r io.Reader) *md5Reader {
+	return &md5Reader{r: r, hash: md5.New()}
+}
+
+func (m *md5Reader) Read(p []byte) (int, error) {
+	n, err := m.r.Read(p)
+	m.hash.Write(p[:n])
+	m.n += int64(n)
+	return n, err
+}
+
+// chunkedReader decodes the aws-chunked body of requests signed with
+// STREAMING-AWS4-HMAC-SHA256-PAYLOAD, the chunk signatures are not checked
+type chunkedReader struct {
+	r      *bufio.Reader
+	remain int64
+	done   bool
+}
+
+func newChunkedReader(r io.Reader) *chunkedReader {
+	return &chunkedReader{r: bufio.NewReader(r)}
+}
+
+func (c *chunkedReader) Read(p []byte) (int, error) {
+	if c.done {
+		return 0, io.EOF
+	}
+	if c.remain == 0 {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			return 0, io.ErrUnexpectedEOF
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil || size < 0 {
+			return 0, fmt.Errorf("invalid chunk header: %q", line)
+		}
+		if size == 0 {
+			c.done = true
+			return 0, io.EOF
+		}
+		c.remain = size
+	}
+	if int64(len(p)) > c.remain {
+		p = p[:c.remain]
+	}
+	n, err := c.r.Read(p)
+	c.remain -= int64(n)
+	if c.remain == 0 {
+		// every chunk ends with \r\n
+		if _, err := c.r.Discard(2); err != nil {
+			return n, io.ErrUnexpectedEOF
+		}
+	}
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
@@ -0,0 +1,22 @@
+package s3
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestChunkedReader(t *testing.T) {
+	sig := ";chunk-signature=" + strings.Repeat("0", 64) + "\r\n"
+	body := "5" + sig + "hello\r\n" + "6" + sig + " world\r\n" + "0" + sig + "\r\n"
+	got, err := io.ReadAll(newChunkedReader(strings.NewReader(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "hello world" {
+		t.Errorf("got %q", got)
+	}
+	if _, err := io.ReadAll(newChunkedReader(strings.NewReader("5" + sig + "he"))); err != io.ErrUnexpectedEOF {
+		t.Errorf("expected unexpected EOF for a truncated body, got %v", err)
+	}
+}
@@ -3,21 +3,27 @@
 package s3
 
 import (
+	"context"
 	"path"
 	"strings"
 	"time"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/itsHenry35/gofakes3"
 	log "github.com/sirupsen/logrus"
 )
 
-func (b *s3Backend) entryListR(bucket, fdPath, name string, addPrefix bool, response *gofakes3.ObjectList) error {
+func (b *s3Backend) entryListR(ctx context.Context, bucket, fdPath, name string, addPrefix bool, response *gofakes3.ObjectList) error {
 	fp := path.Join(bucket, fdPath)
 
-	dirEntries, err := getDirEntries(fp)
+	dirEntries, err := getDirEntries(ctx, fp)
 	if err != nil {
 		return err
 	}
+	metas, err := op.GetS3ObjectMetas(fp)
+	if err != nil {
+		log.Warnf("failed get s3 object metas of %s: %+v", fp, err)
+	}
 
 	// workaround as s3 can't have empty files in directories, useful in deletions
 	if len(dirEntries) == 0 {
@@ -25,7 +31,7 @@ func (b *s3Backend) entryListR(bucket, fdPath, name string, addPrefix bool, resp
 			// Key:          gofakes3.URLEncode(path.Join(fdPath, emptyObjectName)),
 			Key:          path.Join(fdPath, emptyObjectName),
 			LastModified: gofakes3.NewContentTime(time.Now()),
-			ETag:         getFileHash(nil), // No entry, so no hash
+			ETag:         getFileHash(nil, nil), // No entry, so no hash
 			Size:         0,
 			StorageClass: gofakes3.StorageStandard,
 		}
@@ -45,12 +51,15 @@ func (b *s3Backend) entryListR(bucket, fdPath, name string, addPrefix bool, resp
 		}
 
 		if entry.IsDir() {
+			if !canRead(ctx, path.Join(fp, object)) {
+				continue
+			}
 			if addPrefix {
 				// response.AddPrefix(gofakes3.URLEncode(objectPath))
 				response.AddPrefix(objectPath)
 				continue
 			}
-			err := b.entryListR(bucket, path.Join(fdPath, object), "", false, response)
+			err := b.entryListR(ctx, bucket, path.Join(fdPath, object), "", false, response)
 			if err != nil {
 				return err
 			}
@@ -59,7 +68,7 @@ func (b *s3Backend) entryListR(bucket, fdPath, name string, addPrefix bool, resp
 				// Key:          gofakes3.URLEncode(objectPath),
 				Key:          objectPath,
 				LastModified: gofakes3.NewContentTime(entry.ModTime()),
-				ETag:         getFileHash(entry),
+				ETag:         getFileHash(entry, metas[object]),
 				Size:         entry.GetSize(),
 				StorageClass: gofakes3.StorageStandard,
 			}
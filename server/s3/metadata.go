@@ -0,0 +1,66 @@
+package s3
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	log "github.com/sirupsen/logrus"
+)
+
+// storedHeaders are kept with an object besides the x-amz-meta-* headers
+var storedHeaders = []string{
+	"Cache-Control",
+	"Content-Disposition",
+	"Content-Encoding",
+	"Content-Language",
+	"Content-Type",
+	"Expires",
+}
+
+// userMetadata picks the headers of a request that are stored with the object
+func userMetadata(meta map[string]string) map[string]string {
+	res := make(map[string]string)
+	for k, v := range meta {
+		k = http.CanonicalHeaderKey(k)
+		if strings.HasPrefix(k, "X-Amz-Meta-") || slices.Contains(storedHeaders, k) {
+			res[k] = v
+		}
+	}
+	return res
+}
+
+// objectMeta returns the stored meta of the object at fp, nil if there is none or if it
+// no longer belongs to node
+func objectMeta(fp string, node model.Obj) *model.S3ObjectMeta {
+	m, err := op.GetS3ObjectMeta(fp)
+	if err != nil || !m.Fresh(node.GetSize(), node.ModTime()) {
+		return nil
+	}
+	return m
+}
+
+// saveObjectMeta stores the metadata and the etag of the object just put at fp
+func saveObjectMeta(ctx context.Context, fp string, meta map[string]string, etag string) {
+	fmeta, _ := op.GetNearestMeta(fp)
+	node, err := fs.Get(context.WithValue(ctx, conf.MetaKey, fmeta), fp, &fs.GetArgs{})
+	if err != nil {
+		log.Warnf("failed get put object %s: %+v", fp, err)
+		return
+	}
+	err = op.SaveS3ObjectMeta(&model.S3ObjectMeta{
+		Path:     fp,
+		Metadata: userMetadata(meta),
+		ETag:     etag,
+		Size:     node.GetSize(),
+		Modified: node.ModTime(),
+	})
+	if err != nil {
+		log.Warnf("failed save s3 object meta of %s: %+v", fp, err)
+	}
+}
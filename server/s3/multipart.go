@@ -0,0 +1,629 @@
+package s3
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/itsHenry35/gofakes3"
+	"github.com/itsHenry35/gofakes3/signature"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	errAccessDenied   gofakes3.ErrorCode = "AccessDenied"
+	errEntityTooLarge gofakes3.ErrorCode = "EntityTooLarge"
+)
+
+const (
+	// multipartGCInterval is how often unfinished uploads are checked for expiry
+	multipartGCInterval = 10 * time.Minute
+	maxPartNumber       = 10000
+)
+
+// multipartPart is a part staged on disk, named after its number in the directory of the upload
+type multipartPart struct {
+	Number   int
+	Size     int64
+	ETag     string
+	Modified time.Time
+}
+
+type multipartUpload struct {
+	ID        string
+	Bucket    string
+	Key       string
+	UserId    uint
+	Meta      map[string]string
+	Initiated time.Time
+
+	mu         sync.Mutex
+	parts      map[int]*multipartPart
+	lastActive time.Time
+}
+
+func (u *multipartUpload) dir() string {
+	return filepath.Join(multipartDir(), u.ID)
+}
+
+func (u *multipartUpload) partPath(number int) string {
+	return filepath.Join(u.dir(), strconv.Itoa(number))
+}
+
+// multipartUploads keeps the unfinished uploads of all users. The uploads only live in
+// memory, so the staging directory is emptied when the server starts.
+type multipartUploads struct {
+	mu      sync.Mutex
+	uploads map[string]*multipartUpload
+	// staged is the size of all staged parts, including the parts being written
+	staged int64
+}
+
+var (
+	uploads   = &multipartUploads{uploads: make(map[string]*multipartUpload)}
+	uploadsGC sync.Once
+)
+
+func multipartDir() string {
+	return filepath.Join(conf.Conf.TempDir, "s3_multipart")
+}
+
+// startMultipartGC clears what the last run left behind and removes expired uploads from then on
+func startMultipartGC() {
+	uploadsGC.Do(func() {
+		if err := os.RemoveAll(multipartDir()); err != nil {
+			log.Warnf("[s3] failed to clear multipart staging dir: %+v", err)
+		}
+		go func() {
+			for range time.Tick(multipartGCInterval) {
+				uploads.expire()
+			}
+		}()
+	})
+}
+
+func (m *multipartUploads) expire() {
+	expire := time.Duration(setting.GetInt(conf.S3MultipartExpire, 24)) * time.Hour
+	if expire <= 0 {
+		return
+	}
+	m.mu.Lock()
+	all := make([]*multipartUpload, 0, len(m.uploads))
+	for _, u := range m.uploads {
+		all = append(all, u)
+	}
+	m.mu.Unlock()
+	for _, u := range all {
+		u.mu.Lock()
+		expired := time.Since(u.lastActive) > expire
+		u.mu.Unlock()
+		if !expired {
+			continue
+		}
+		// skip the uploads being completed or aborted meanwhile
+		if _, err := m.take(u.Bucket, u.Key, u.ID, u.UserId); err != nil {
+			continue
+		}
+		log.Infof("[s3] removing expired multipart upload %s of %s/%s", u.ID, u.Bucket, u.Key)
+		m.remove(u)
+	}
+}
+
+// reserve accounts size bytes to the staging area, failing when the limit would be exceeded
+func (m *multipartUploads) reserve(size int64) error {
+	limit := int64(setting.GetInt(conf.S3MultipartLimit, 0)) * 1024 * 1024
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if limit > 0 && m.staged+size > limit {
+		return gofakes3.ErrorMessage(errEntityTooLarge, "the multipart staging area is full")
+	}
+	m.staged += size
+	return nil
+}
+
+func (m *multipartUploads) release(size int64) {
+	m.mu.Lock()
+	m.staged -= size
+	m.mu.Unlock()
+}
+
+func (m *multipartUploads) begin(bucket, key string, userId uint, meta map[string]string) (*multipartUpload, error) {
+	u := &multipartUpload{
+		ID:         random.String(32),
+		Bucket:     bucket,
+		Key:        key,
+		UserId:     userId,
+		Meta:       meta,
+		Initiated:  time.Now(),
+		parts:      make(map[int]*multipartPart),
+		lastActive: time.Now(),
+	}
+	if err := os.MkdirAll(u.dir(), 0o700); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	m.uploads[u.ID] = u
+	m.mu.Unlock()
+	return u, nil
+}
+
+func (m *multipartUploads) get(bucket, key, id string, userId uint) (*multipartUpload, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u, ok := m.uploads[id]
+	if !ok || u.Bucket != bucket || u.Key != key || u.UserId != userId {
+		return nil, gofakes3.ErrNoSuchUpload
+	}
+	return u, nil
+}
+
+// take removes the upload from the list so no other request can complete or abort it
+func (m *multipartUploads) take(bucket, key, id string, userId uint) (*multipartUpload, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u, ok := m.uploads[id]
+	if !ok || u.Bucket != bucket || u.Key != key || u.UserId != userId {
+		return nil, gofakes3.ErrNoSuchUpload
+	}
+	delete(m.uploads, id)
+	return u, nil
+}
+
+func (m *multipartUploads) put(u *multipartUpload) {
+	m.mu.Lock()
+	m.uploads[u.ID] = u
+	m.mu.Unlock()
+}
+
+// remove deletes the staged parts of an upload already taken from the list
+func (m *multipartUploads) remove(u *multipartUpload) {
+	u.mu.Lock()
+	var size int64
+	for _, p := range u.parts {
+		size += p.Size
+	}
+	u.parts = nil
+	u.mu.Unlock()
+	m.release(size)
+	if err := os.RemoveAll(u.dir()); err != nil {
+		log.Warnf("[s3] failed to remove multipart upload %s: %+v", u.ID, err)
+	}
+}
+
+func (m *multipartUploads) list(bucket string, userId uint) []*multipartUpload {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []*multipartUpload
+	for _, u := range m.uploads {
+		if u.Bucket == bucket && u.UserId == userId {
+			res = append(res, u)
+		}
+	}
+	slices.SortFunc(res, func(a, b *multipartUpload) int {
+		if c := strings.Compare(a.Key, b.Key); c != 0 {
+			return c
+		}
+		return a.Initiated.Compare(b.Initiated)
+	})
+	return res
+}
+
+// multipartHandler serves the multipart upload requests instead of gofakes3, which keeps every
+// part in memory. Parts are staged on disk and streamed to the storage when the upload completes.
+type multipartHandler struct {
+	backend *s3Backend
+	next    http.Handler
+	// auth is whether requests have to be signed, the signature is checked
+	// by gofakes3 for the requests passed on to next
+	auth bool
+}
+
+func newMultipartHandler(backend *s3Backend, auth bool, next http.Handler) http.Handler {
+	startMultipartGC()
+	return &multipartHandler{backend: backend, next: next, auth: auth}
+}
+
+func (h *multipartHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if !query.Has("uploadId") && !query.Has("uploads") {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+	if h.auth && !verifySignature(w, r) {
+		return
+	}
+	bucket, key, _ := strings.Cut(strings.Trim(r.URL.Path, "/"), "/")
+	var err error
+	if id := query.Get("uploadId"); id != "" {
+		switch r.Method {
+		case http.MethodGet:
+			err = h.listParts(w, r, bucket, key, id)
+		case http.MethodPut:
+			err = h.uploadPart(w, r, bucket, key, id)
+		case http.MethodDelete:
+			err = h.abort(w, r, bucket, key, id)
+		case http.MethodPost:
+			err = h.complete(w, r, bucket, key, id)
+		default:
+			err = gofakes3.ErrMethodNotAllowed
+		}
+	} else {
+		switch r.Method {
+		case http.MethodGet:
+			err = h.listUploads(w, r, bucket)
+		case http.MethodPost:
+			err = h.initiate(w, r, bucket, key)
+		default:
+			err = gofakes3.ErrMethodNotAllowed
+		}
+	}
+	if err != nil {
+		writeError(w, r, err)
+	}
+}
+
+// verifySignature checks the signature like the auth middleware of gofakes3
+func verifySignature(w http.ResponseWriter, r *http.Request) bool {
+	result := signature.V4SignVerify(r)
+	if result == signature.ErrUnsupportAlgorithm {
+		result = signature.V2SignVerify(r)
+	}
+	if result == signature.ErrNone {
+		return true
+	}
+	resp := signature.GetAPIError(result)
+	w.Header().Add("content-type", "application/xml")
+	w.WriteHeader(resp.HTTPStatusCode)
+	_, _ = w.Write(signature.EncodeAPIErrorToResponse(resp))
+	return false
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	resp := gofakes3.ErrorResultFromError(err)
+	var status int
+	switch resp.Code {
+	case errAccessDenied:
+		status = http.StatusForbidden
+	case errEntityTooLarge:
+		status = http.StatusBadRequest
+	default:
+		status = resp.Code.Status()
+	}
+	if status == http.StatusInternalServerError {
+		log.Errorf("[s3] multipart upload failed: %+v", err)
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	if r.Method != http.MethodHead {
+		_ = writeXML(w, resp)
+	}
+}
+
+func writeXML(w io.Writer, v any) error {
+	_, _ = w.Write([]byte(xml.Header))
+	return xml.NewEncoder(w).Encode(v)
+}
+
+func userIdOf(ctx context.Context) uint {
+	if user := s3User(ctx); user != nil {
+		return user.ID
+	}
+	return 0
+}
+
+func (h *multipartHandler) initiate(w http.ResponseWriter, r *http.Request, bucketName, key string) error {
+	if key == "" {
+		return gofakes3.ErrInvalidURI
+	}
+	if _, err := getBucketByName(r.Context(), bucketName); err != nil {
+		return err
+	}
+	meta := make(map[string]string)
+	for k, v := range r.Header {
+		if strings.HasPrefix(k, "X-Amz-") || strings.HasPrefix(k, "Content-") || k == "Cache-Control" {
+			meta[k] = v[0]
+		}
+	}
+	u, err := uploads.begin(bucketName, key, userIdOf(r.Context()), meta)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	return writeXML(w, gofakes3.InitiateMultipartUpload{
+		Bucket:   bucketName,
+		Key:      key,
+		UploadID: gofakes3.UploadID(u.ID),
+	})
+}
+
+func (h *multipartHandler) uploadPart(w http.ResponseWriter, r *http.Request, bucket, key, id string) (err error) {
+	defer r.Body.Close()
+	number, err := strconv.Atoi(r.URL.Query().Get("partNumber"))
+	if err != nil || number <= 0 || number > maxPartNumber {
+		return gofakes3.ErrInvalidPart
+	}
+	u, err := uploads.get(bucket, key, id, userIdOf(r.Context()))
+	if err != nil {
+		return err
+	}
+	var body io.Reader = r.Body
+	size := r.ContentLength
+	if r.Header.Get("X-Amz-Content-Sha256") == "STREAMING-AWS4-HMAC-SHA256-PAYLOAD" {
+		body = newChunkedReader(r.Body)
+		size, err = strconv.ParseInt(r.Header.Get("X-Amz-Decoded-Content-Length"), 10, 64)
+		if err != nil {
+			return gofakes3.ErrMissingContentLength
+		}
+	}
+	if size < 0 {
+		return gofakes3.ErrMissingContentLength
+	}
+	if err := uploads.reserve(size); err != nil {
+		return err
+	}
+	kept := false
+	defer func() {
+		if !kept {
+			uploads.release(size)
+		}
+	}()
+
+	tmp, err := os.CreateTemp(u.dir(), "part-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tmp.Close()
+		if !kept {
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+	hash := md5.New()
+	n, err := io.Copy(io.MultiWriter(tmp, hash), io.LimitReader(body, size))
+	if err != nil {
+		return err
+	}
+	if n != size {
+		return gofakes3.ErrIncompleteBody
+	}
+	sum := hash.Sum(nil)
+	if expected := r.Header.Get("Content-MD5"); expected != "" && expected != base64.StdEncoding.EncodeToString(sum) {
+		return gofakes3.ErrBadDigest
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	etag := `"` + hex.EncodeToString(sum) + `"`
+	u.mu.Lock()
+	if u.parts == nil {
+		// aborted while the part was uploading
+		u.mu.Unlock()
+		return gofakes3.ErrNoSuchUpload
+	}
+	if err := os.Rename(tmp.Name(), u.partPath(number)); err != nil {
+		u.mu.Unlock()
+		return err
+	}
+	kept = true
+	old := u.parts[number]
+	u.parts[number] = &multipartPart{Number: number, Size: size, ETag: etag, Modified: time.Now()}
+	u.lastActive = time.Now()
+	u.mu.Unlock()
+	// the part replaced one with the same number
+	if old != nil {
+		uploads.release(old.Size)
+	}
+	w.Header().Set("ETag", etag)
+	return nil
+}
+
+func (h *multipartHandler) abort(w http.ResponseWriter, r *http.Request, bucket, key, id string) error {
+	u, err := uploads.take(bucket, key, id, userIdOf(r.Context()))
+	if err != nil {
+		return err
+	}
+	uploads.remove(u)
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (h *multipartHandler) complete(w http.ResponseWriter, r *http.Request, bucket, key, id string) (err error) {
+	var req gofakes3.CompleteMultipartUploadRequest
+	body, err := io.ReadAll(io.LimitReader(r.Body, 10*1024*1024))
+	_ = r.Body.Close()
+	if err != nil {
+		return err
+	}
+	if err := xml.Unmarshal(body, &req); err != nil {
+		return gofakes3.ErrorMessage(gofakes3.ErrMalformedXML, err.Error())
+	}
+	if len(req.Parts) == 0 {
+		return gofakes3.ErrMalformedXML
+	}
+
+	u, err := uploads.take(bucket, key, id, userIdOf(r.Context()))
+	if err != nil {
+		return err
+	}
+	// a failed upload can be completed again like on S3
+	done := false
+	defer func() {
+		if done {
+			uploads.remove(u)
+		} else {
+			uploads.put(u)
+		}
+	}()
+
+	u.mu.Lock()
+	var (
+		files []*os.File
+		size  int64
+		sums  []byte
+	)
+	defer func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}()
+	for i, p := range req.Parts {
+		if i > 0 && p.PartNumber <= req.Parts[i-1].PartNumber {
+			u.mu.Unlock()
+			return gofakes3.ErrInvalidPartOrder
+		}
+		part, ok := u.parts[p.PartNumber]
+		if !ok || strings.Trim(p.ETag, `"`) != strings.Trim(part.ETag, `"`) {
+			u.mu.Unlock()
+			return gofakes3.ErrInvalidPart
+		}
+		f, err := os.Open(u.partPath(p.PartNumber))
+		if err != nil {
+			u.mu.Unlock()
+			return err
+		}
+		files = append(files, f)
+		size += part.Size
+		sum, _ := hex.DecodeString(strings.Trim(part.ETag, `"`))
+		sums = append(sums, sum...)
+	}
+	u.mu.Unlock()
+
+	readers := make([]io.Reader, len(files))
+	for i, f := range files {
+		readers[i] = f
+	}
+	if _, err := h.backend.PutObject(r.Context(), bucket, key, u.Meta, io.MultiReader(readers...), size); err != nil {
+		return err
+	}
+	done = true
+
+	sum := md5.Sum(sums)
+	etag := fmt.Sprintf(`"%s-%d"`, hex.EncodeToString(sum[:]), len(req.Parts))
+	w.Header().Set("Content-Type", "application/xml")
+	return writeXML(w, gofakes3.CompleteMultipartUploadResult{
+		Bucket: bucket,
+		Key:    key,
+		ETag:   etag,
+	})
+}
+
+func (h *multipartHandler) listUploads(w http.ResponseWriter, r *http.Request, bucket string) error {
+	if _, err := getBucketByName(r.Context(), bucket); err != nil {
+		return err
+	}
+	query := r.URL.Query()
+	prefix := query.Get("prefix")
+	keyMarker := query.Get("key-marker")
+	idMarker := query.Get("upload-id-marker")
+	maxUploads := parseMax(query.Get("max-uploads"), 1000)
+
+	res := gofakes3.ListMultipartUploadsResult{
+		Bucket:         bucket,
+		KeyMarker:      keyMarker,
+		UploadIDMarker: gofakes3.UploadID(idMarker),
+		MaxUploads:     int64(maxUploads),
+		Prefix:         prefix,
+	}
+	// the uploads of a key after the marker come after the upload with the id marker
+	skipping := keyMarker != "" && idMarker != ""
+	for _, u := range uploads.list(bucket, userIdOf(r.Context())) {
+		if !strings.HasPrefix(u.Key, prefix) || u.Key < keyMarker {
+			continue
+		}
+		if u.Key == keyMarker {
+			if !skipping {
+				continue
+			}
+			if u.ID == idMarker {
+				skipping = false
+			}
+			continue
+		}
+		if len(res.Uploads) == maxUploads {
+			res.IsTruncated = true
+			last := res.Uploads[len(res.Uploads)-1]
+			res.NextKeyMarker = last.Key
+			res.NextUploadIDMarker = last.UploadID
+			break
+		}
+		res.Uploads = append(res.Uploads, gofakes3.ListMultipartUploadItem{
+			Key:          u.Key,
+			UploadID:     gofakes3.UploadID(u.ID),
+			StorageClass: gofakes3.StorageStandard,
+			Initiated:    gofakes3.NewContentTime(u.Initiated),
+		})
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	return writeXML(w, res)
+}
+
+func (h *multipartHandler) listParts(w http.ResponseWriter, r *http.Request, bucket, key, id string) error {
+	u, err := uploads.get(bucket, key, id, userIdOf(r.Context()))
+	if err != nil {
+		return err
+	}
+	query := r.URL.Query()
+	marker, _ := strconv.Atoi(query.Get("part-number-marker"))
+	maxParts := parseMax(query.Get("max-parts"), 1000)
+
+	u.mu.Lock()
+	parts := make([]*multipartPart, 0, len(u.parts))
+	for _, p := range u.parts {
+		if p.Number > marker {
+			parts = append(parts, p)
+		}
+	}
+	u.mu.Unlock()
+	slices.SortFunc(parts, func(a, b *multipartPart) int {
+		return a.Number - b.Number
+	})
+
+	res := gofakes3.ListMultipartUploadPartsResult{
+		Bucket:           bucket,
+		Key:              key,
+		UploadID:         gofakes3.UploadID(id),
+		StorageClass:     gofakes3.StorageStandard,
+		PartNumberMarker: marker,
+		MaxParts:         int64(maxParts),
+	}
+	if len(parts) > maxParts {
+		parts = parts[:maxParts]
+		res.IsTruncated = true
+	}
+	for _, p := range parts {
+		res.Parts = append(res.Parts, gofakes3.ListMultipartUploadPartItem{
+			PartNumber:   p.Number,
+			LastModified: gofakes3.NewContentTime(p.Modified),
+			ETag:         p.ETag,
+			Size:         p.Size,
+		})
+		res.NextPartNumberMarker = p.Number
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	return writeXML(w, res)
+}
+
+// parseMax parses a max-uploads or max-parts query, clamped to def
+func parseMax(s string, def int) int {
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 || n > def {
+		return def
+	}
+	return n
+}
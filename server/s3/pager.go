@@ -8,58 +8,47 @@ import (
 	"github.com/itsHenry35/gofakes3"
 )
 
-// pager splits the object list into smulitply pages.
+// pager splits the object list into smulitply pages. Keys and common prefixes are returned
+// together in the order of their names, a page starts after the marker, which is the last
+// key or prefix of the previous page for both V1 markers and V2 continuation tokens.
 func (db *s3Backend) pager(list *gofakes3.ObjectList, page gofakes3.ListBucketPage) (*gofakes3.ObjectList, error) {
-	// sort by alphabet
-	sort.Slice(list.CommonPrefixes, func(i, j int) bool {
-		return list.CommonPrefixes[i].Prefix < list.CommonPrefixes[j].Prefix
-	})
-	// sort by modtime
-	sort.Slice(list.Contents, func(i, j int) bool {
-		return list.Contents[i].LastModified.Before(list.Contents[j].LastModified.Time)
-	})
-	tokens := page.MaxKeys
-	if tokens == 0 {
-		tokens = 1000
+	type item struct {
+		key     string
+		prefix  bool
+		content *gofakes3.Content
+	}
+	items := make([]item, 0, len(list.CommonPrefixes)+len(list.Contents))
+	for _, p := range list.CommonPrefixes {
+		items = append(items, item{key: p.Prefix, prefix: true})
+	}
+	for _, c := range list.Contents {
+		items = append(items, item{key: c.Key, content: c})
 	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].key < items[j].key
+	})
 	if page.HasMarker {
-		for i, obj := range list.Contents {
-			if obj.Key == page.Marker {
-				list.Contents = list.Contents[i+1:]
-				break
-			}
-		}
-		for i, obj := range list.CommonPrefixes {
-			if obj.Prefix == page.Marker {
-				list.CommonPrefixes = list.CommonPrefixes[i+1:]
-				break
-			}
-		}
+		i := sort.Search(len(items), func(i int) bool {
+			return items[i].key > page.Marker
+		})
+		items = items[i:]
 	}
-
-	response := gofakes3.NewObjectList()
-	for _, obj := range list.CommonPrefixes {
-		if tokens <= 0 {
-			break
-		}
-		response.AddPrefix(obj.Prefix)
-		tokens--
+	maxKeys := int(page.MaxKeys)
+	if maxKeys <= 0 {
+		maxKeys = 1000
 	}
 
-	for _, obj := range list.Contents {
-		if tokens <= 0 {
+	response := gofakes3.NewObjectList()
+	for i, it := range items {
+		if i == maxKeys {
+			response.IsTruncated = true
+			response.NextMarker = items[i-1].key
 			break
 		}
-		response.Add(obj)
-		tokens--
-	}
-
-	if len(list.CommonPrefixes)+len(list.Contents) > int(page.MaxKeys) {
-		response.IsTruncated = true
-		if len(response.Contents) > 0 {
-			response.NextMarker = response.Contents[len(response.Contents)-1].Key
+		if it.prefix {
+			response.AddPrefix(it.key)
 		} else {
-			response.NextMarker = response.CommonPrefixes[len(response.CommonPrefixes)-1].Prefix
+			response.Add(it.content)
 		}
 	}
 
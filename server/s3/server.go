@@ -7,21 +7,46 @@ import (
 	"math/rand"
 	"net/http"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/itsHenry35/gofakes3"
 )
 
 // Make a new S3 Server to serve the remote
 func NewServer(ctx context.Context) (h http.Handler, err error) {
 	var newLogger logger
+	authList := authlistResolver()
+	backend := newBackend()
 	faker := gofakes3.New(
-		newBackend(),
+		backend,
 		// gofakes3.WithHostBucket(!opt.pathBucketMode),
 		gofakes3.WithLogger(newLogger),
 		gofakes3.WithRequestID(rand.Uint64()),
 		gofakes3.WithoutVersioning(),
-		gofakes3.WithV4Auth(authlistResolver()),
+		gofakes3.WithV4Auth(authList),
 		gofakes3.WithIntegrityCheck(true), // Check Content-MD5 if supplied
 	)
 
-	return faker.Server(), nil
+	// unsigned requests are served without auth, apiTokenAuth only lets them read public buckets
+	anonymous := gofakes3.New(
+		backend,
+		gofakes3.WithLogger(newLogger),
+		gofakes3.WithRequestID(rand.Uint64()),
+		gofakes3.WithoutVersioning(),
+	)
+
+	handler := newConditionalHandler(backend, true, faker.Server())
+	handler = newMultipartHandler(backend, true, handler)
+	anonymousHandler := newConditionalHandler(backend, false, anonymous.Server())
+	return auditContext(apiTokenAuth(faker, authList, anonymousHandler, handler)), nil
+}
+
+// auditContext tells the audit log that the requests come from s3 and which client sent them
+func auditContext(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), conf.ProtocolKey, model.ProtocolS3)
+		ctx = context.WithValue(ctx, conf.ClientIPKey, utils.ClientIP(r))
+		handler.ServeHTTP(w, r.WithContext(ctx))
+	})
 }
@@ -4,7 +4,9 @@ package s3
 
 import (
 	"context"
+	"encoding/hex"
 	"encoding/json"
+	"slices"
 	"strings"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
@@ -13,12 +15,22 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
 	"github.com/itsHenry35/gofakes3"
+	"github.com/pkg/errors"
 )
 
 type Bucket struct {
 	Name string `json:"name"`
 	Path string `json:"path"`
+	// Users limits the bucket to these usernames, empty allows every user
+	Users []string `json:"users,omitempty"`
+	// PerUser makes the path relative to the base path of the user, so every
+	// user reaches its own folder through the same bucket
+	PerUser bool `json:"per_user,omitempty"`
+	// Public lets unsigned requests read the bucket
+	Public bool `json:"public,omitempty"`
 }
 
 const emptyObjectName = "ThisIsAnEmptyFolderInTheS3Bucket"
@@ -29,23 +41,102 @@ func getAndParseBuckets() ([]Bucket, error) {
 	return res, err
 }
 
-func getBucketByName(name string) (Bucket, error) {
+// s3User returns the user of the api token the request is signed with,
+// requests signed with the static keys have no user
+func s3User(ctx context.Context) *model.User {
+	user, _ := ctx.Value(conf.UserKey).(*model.User)
+	return user
+}
+
+// resolveBucket returns the bucket as seen by the user in ctx and whether it may be used.
+// Buckets must be inside the base path of the user, per user buckets need a user.
+func resolveBucket(ctx context.Context, b Bucket) (Bucket, bool) {
+	user := s3User(ctx)
+	if user == nil {
+		return b, !b.PerUser
+	}
+	if len(b.Users) > 0 && !slices.Contains(b.Users, user.Username) {
+		return b, false
+	}
+	if b.PerUser {
+		p, err := user.JoinPath(b.Path)
+		if err != nil {
+			return b, false
+		}
+		b.Path = p
+	}
+	return b, utils.IsSubPath(user.GetBasePath(), b.Path)
+}
+
+func getBucketByName(ctx context.Context, name string) (Bucket, error) {
 	buckets, err := getAndParseBuckets()
 	if err != nil {
 		return Bucket{}, err
 	}
 	for _, b := range buckets {
-		if b.Name == name {
+		if b.Name != name {
+			continue
+		}
+		if b, ok := resolveBucket(ctx, b); ok {
 			return b, nil
 		}
 	}
 	return Bucket{}, gofakes3.BucketNotFound(name)
 }
 
-func getDirEntries(path string) ([]model.Obj, error) {
-	ctx := context.Background()
+// canRead checks the meta of p like the http api does, there is no way to send the
+// password of a folder so those are only readable by users that can skip it
+func canRead(ctx context.Context, p string) bool {
+	user := s3User(ctx)
+	if user == nil {
+		return true
+	}
+	meta, err := op.GetNearestMeta(p)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		return false
+	}
+	return common.CanAccess(user, meta, p, "")
+}
+
+// canWrite checks the permission and the meta of the user in ctx for creating objects in dir
+func canWrite(ctx context.Context, dir string) bool {
+	user := s3User(ctx)
+	if user == nil {
+		return true
+	}
+	meta, err := op.GetNearestMeta(dir)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		return false
+	}
+	if !user.CanWriteContent() && !common.CanWriteContentBypassUserPerms(meta, dir) {
+		return false
+	}
+	return common.CanWrite(user, meta, dir)
+}
+
+// canRemove checks the permission and the meta of the user in ctx for removing objects in dir
+func canRemove(ctx context.Context, dir string) bool {
+	user := s3User(ctx)
+	if user == nil {
+		return true
+	}
+	if !user.CanRemove() {
+		return false
+	}
+	meta, err := op.GetNearestMeta(dir)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		return false
+	}
+	return common.CanWrite(user, meta, dir)
+}
+
+func getDirEntries(ctx context.Context, path string) ([]model.Obj, error) {
+	if !canRead(ctx, path) {
+		return nil, gofakes3.ErrNoSuchKey
+	}
 	meta, _ := op.GetNearestMeta(path)
-	fi, err := fs.Get(context.WithValue(ctx, conf.MetaKey, meta), path, &fs.GetArgs{})
+	ctx = context.WithValue(ctx, conf.MetaKey, meta)
+	fi, err := fs.Get(ctx, path, &fs.GetArgs{})
 	if errs.IsNotFoundError(err) {
 		return nil, gofakes3.ErrNoSuchKey
 	} else if err != nil {
@@ -56,7 +147,7 @@ func getDirEntries(path string) ([]model.Obj, error) {
 		return nil, gofakes3.ErrNoSuchKey
 	}
 
-	dirEntries, err := fs.List(context.WithValue(ctx, conf.MetaKey, meta), path, &fs.ListArgs{})
+	dirEntries, err := fs.List(ctx, path, &fs.ListArgs{})
 	if err != nil {
 		return nil, err
 	}
@@ -64,52 +155,33 @@ func getDirEntries(path string) ([]model.Obj, error) {
 	return dirEntries, nil
 }
 
-// func getFileHashByte(node interface{}) []byte {
-// 	b, err := hex.DecodeString(getFileHash(node))
-// 	if err != nil {
-// 		return nil
-// 	}
-// 	return b
-// }
-
-func getFileHash(node interface{}) string {
-	// var o fs.Object
-
-	// switch b := node.(type) {
-	// case vfs.Node:
-	// 	fsObj, ok := b.DirEntry().(fs.Object)
-	// 	if !ok {
-	// 		fs.Debugf("serve s3", "File uploading - reading hash from VFS cache")
-	// 		in, err := b.Open(os.O_RDONLY)
-	// 		if err != nil {
-	// 			return ""
-	// 		}
-	// 		defer func() {
-	// 			_ = in.Close()
-	// 		}()
-	// 		h, err := hash.NewMultiHasherTypes(hash.NewHashSet(hash.MD5))
-	// 		if err != nil {
-	// 			return ""
-	// 		}
-	// 		_, err = io.Copy(h, in)
-	// 		if err != nil {
-	// 			return ""
-	// 		}
-	// 		return h.Sums()[hash.MD5]
-	// 	}
-	// 	o = fsObj
-	// case fs.Object:
-	// 	o = b
-	// }
-
-	// hash, err := o.Hash(context.Background(), hash.MD5)
-	// if err != nil {
-	// 	return ""
-	// }
-	// return hash
+// getFileHash returns the quoted etag of node, the md5 stored when it was put through S3 or
+// the one the storage knows. It is empty when there is none.
+func getFileHash(node model.Obj, meta *model.S3ObjectMeta) string {
+	if node == nil {
+		return ""
+	}
+	if sum := getFileHashByte(node, meta); sum != nil {
+		return `"` + hex.EncodeToString(sum) + `"`
+	}
 	return ""
 }
 
+func getFileHashByte(node model.Obj, meta *model.S3ObjectMeta) []byte {
+	etag := ""
+	if meta != nil && meta.Fresh(node.GetSize(), node.ModTime()) {
+		etag = meta.ETag
+	}
+	if etag == "" {
+		etag = node.GetHash().GetHash(utils.MD5)
+	}
+	sum, err := hex.DecodeString(etag)
+	if err != nil || len(sum) == 0 {
+		return nil
+	}
+	return sum
+}
+
 func prefixParser(p *gofakes3.Prefix) (path, remaining string) {
 	idx := strings.LastIndexByte(p.Prefix, '/')
 	if idx < 0 {
@@ -153,10 +225,10 @@ func prefixParser(p *gofakes3.Prefix) (path, remaining string) {
 func authlistResolver() map[string]string {
 	s3accesskeyid := setting.GetStr(conf.S3AccessKeyId)
 	s3secretaccesskey := setting.GetStr(conf.S3SecretAccessKey)
+	authList := make(map[string]string)
 	if s3accesskeyid == "" && s3secretaccesskey == "" {
-		return nil
+		return authList
 	}
-	authList := make(map[string]string)
 	authList[s3accesskeyid] = s3secretaccesskey
 	return authList
 }
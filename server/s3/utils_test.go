@@ -0,0 +1,40 @@
+package s3
+
+import (
+	"context"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+func TestResolveBucket(t *testing.T) {
+	user := &model.User{Username: "alice", BasePath: "/home/alice"}
+	userCtx := context.WithValue(context.Background(), conf.UserKey, user)
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		bucket Bucket
+		path   string
+		ok     bool
+	}{
+		{"static keys", context.Background(), Bucket{Name: "b", Path: "/"}, "/", true},
+		{"static keys per user", context.Background(), Bucket{Name: "b", Path: "/", PerUser: true}, "", false},
+		{"outside base path", userCtx, Bucket{Name: "b", Path: "/home/bob"}, "", false},
+		{"inside base path", userCtx, Bucket{Name: "b", Path: "/home/alice/docs"}, "/home/alice/docs", true},
+		{"not listed", userCtx, Bucket{Name: "b", Path: "/home/alice", Users: []string{"bob"}}, "", false},
+		{"listed", userCtx, Bucket{Name: "b", Path: "/home/alice", Users: []string{"alice"}}, "/home/alice", true},
+		{"per user", userCtx, Bucket{Name: "b", Path: "/photos", PerUser: true}, "/home/alice/photos", true},
+		{"per user relative", userCtx, Bucket{Name: "b", Path: "../bob", PerUser: true}, "", false},
+	}
+	for _, tt := range tests {
+		b, ok := resolveBucket(tt.ctx, tt.bucket)
+		if ok != tt.ok {
+			t.Errorf("%s: got ok %v, want %v", tt.name, ok, tt.ok)
+			continue
+		}
+		if ok && b.Path != tt.path {
+			t.Errorf("%s: got path %s, want %s", tt.name, b.Path, tt.path)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/OpenListTeam/OpenList/v4/drivers/base"
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
@@ -72,6 +73,7 @@ func (d *SftpDriver) GetFileSystem(sc *ssh.ServerConn) (sftpd.FileSystem, error)
 	ctx = context.WithValue(ctx, conf.MetaPassKey, "")
 	ctx = context.WithValue(ctx, conf.ClientIPKey, sc.RemoteAddr().String())
 	ctx = context.WithValue(ctx, conf.ProxyHeaderKey, d.proxyHeader)
+	ctx = context.WithValue(ctx, conf.ProtocolKey, model.ProtocolSFTP)
 	return &sftp.DriverAdapter{FtpDriver: ftp.NewAferoAdapter(ctx)}, nil
 }
 
@@ -155,6 +157,15 @@ func (d *SftpDriver) AuthLogCallback(conn ssh.ConnMetadata, method string, err e
 	} else if method != "none" {
 		utils.Log.Infof("[SFTP] %s(%s) tries logging in via %s but with error: %s", conn.User(), ip, method, err)
 	}
+	if err == nil || method != "none" {
+		audit.Record(context.Background(), &model.AuditLog{
+			Operation: model.AuditLogin,
+			Username:  conn.User(),
+			Protocol:  model.ProtocolSFTP,
+			IP:        ip,
+			Detail:    method,
+		}, err)
+	}
 }
 
 func (d *SftpDriver) GetBanner(_ ssh.ConnMetadata) string {
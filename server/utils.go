@@ -6,9 +6,14 @@ import (
 )
 
 func tryLdapLoginAndRegister(user, pass string) (*model.User, error) {
-	err := common.HandleLdapLogin(user, pass)
+	groups, err := common.HandleLdapLoginWithGroups(user, pass)
 	if err != nil {
 		return nil, err
 	}
-	return common.LdapRegister(user)
+	userObj, err := common.LdapRegister(user)
+	if err != nil {
+		return nil, err
+	}
+	common.SyncExternalGroups(userObj, groups)
+	return userObj, nil
 }
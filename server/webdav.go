@@ -2,10 +2,12 @@ package server
 
 import (
 	"crypto/subtle"
+	"errors"
 	"net/http"
 	"path"
 	"strings"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
@@ -23,12 +25,12 @@ var handler *webdav.Handler
 func WebDav(dav *gin.RouterGroup) {
 	handler = &webdav.Handler{
 		Prefix:     path.Join(conf.URL.Path, "/dav"),
-		LockSystem: webdav.NewMemLS(),
+		LockSystem: webdav.NewDBLS(),
 		Logger: func(request *http.Request, err error) {
 			log.Errorf("%s %s %+v", request.Method, request.URL.Path, err)
 		},
 	}
-	dav.Use(WebDAVAuth)
+	dav.Use(middlewares.AuditContext(model.ProtocolWebDAV), WebDAVAuth)
 	uploadLimiter := middlewares.UploadRateLimiter(stream.ClientUploadLimit)
 	downloadLimiter := middlewares.DownloadRateLimiter(stream.ClientDownloadLimit)
 	dav.Any("/*path", uploadLimiter, downloadLimiter, ServeWebDAV)
@@ -69,6 +71,18 @@ func WebDAVAuth(c *gin.Context) {
 		log.Debugf("[webdav auth] token: %s", bt)
 		if strings.HasPrefix(bt, "Bearer") {
 			bt = strings.TrimPrefix(bt, "Bearer ")
+			if strings.HasPrefix(bt, model.APITokenPrefix) {
+				user, err := op.GetAPITokenUser(bt)
+				if err == nil && user.CanWebdavRead() && allowTokenMethod(user, c.Request) {
+					common.GinWithValue(c, conf.UserKey, user)
+					common.GinWithValue(c, conf.MetaPassKey, "")
+					c.Next()
+					return
+				}
+				c.Status(http.StatusForbidden)
+				c.Abort()
+				return
+			}
 			token := setting.GetStr(conf.Token)
 			if token != "" && subtle.ConstantTimeCompare([]byte(bt), []byte(token)) == 1 {
 				admin, err := op.GetAdmin()
@@ -101,6 +115,11 @@ func WebDAVAuth(c *gin.Context) {
 			return
 		}
 		model.LoginCache.Set(ip, count+1)
+		audit.Record(c.Request.Context(), &model.AuditLog{
+			Operation: model.AuditLogin,
+			Username:  username,
+			Detail:    "basic",
+		}, errors.New("wrong username or password"))
 		c.Status(http.StatusUnauthorized)
 		c.Abort()
 		return
@@ -117,6 +136,11 @@ func WebDAVAuth(c *gin.Context) {
 		c.Abort()
 		return
 	}
+	if !allowTokenMethod(user, c.Request) {
+		c.Status(http.StatusForbidden)
+		c.Abort()
+		return
+	}
 	if (c.Request.Method == "PUT" || c.Request.Method == "MKCOL") && !user.CanWebdavManage() {
 		c.Status(http.StatusForbidden)
 		c.Abort()
@@ -151,7 +175,29 @@ func WebDAVAuth(c *gin.Context) {
 	c.Next()
 }
 
+// allowTokenMethod keeps upload only api tokens to the methods needed to upload,
+// PROPFIND is limited to the target itself so the names in a dir are not listed
+func allowTokenMethod(user *model.User, r *http.Request) bool {
+	if user.CanReadContent() {
+		return true
+	}
+	switch r.Method {
+	case "OPTIONS", "PUT", "MKCOL", "LOCK", "UNLOCK":
+		return true
+	case "PROPFIND":
+		return r.Header.Get("Depth") == "0"
+	}
+	return false
+}
+
 func tryLogin(username, password string) (*model.User, bool) {
+	if strings.HasPrefix(password, model.APITokenPrefix) {
+		// an api token is accepted as the password of its user
+		user, err := op.GetAPITokenUser(password)
+		if err == nil && user.Username == username {
+			return user, true
+		}
+	}
 	user, err := op.GetUserByName(username)
 	if err == nil {
 		err = user.ValidateRawPassword(password)
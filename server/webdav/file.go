@@ -59,13 +59,13 @@ func moveFiles(ctx context.Context, src, dst string, overwrite bool) (status int
 		err = fs.Rename(ctx, src, dstName)
 	} else {
 		_, err = fs.Move(context.WithValue(ctx, conf.NoTaskKey, struct{}{}), src, dstDir)
-		if err != nil {
-			return http.StatusInternalServerError, err
-		}
-		if srcName != dstName {
+		if err == nil && srcName != dstName {
 			err = fs.Rename(ctx, path.Join(dstDir, srcName), dstName)
 		}
 	}
+	if errors.Is(errors.Cause(err), errs.Locked) {
+		return StatusLocked, err
+	}
 	if err != nil {
 		return http.StatusInternalServerError, err
 	}
@@ -99,6 +99,9 @@ func copyFiles(ctx context.Context, src, dst string, overwrite bool) (status int
 		return http.StatusForbidden, nil
 	}
 	_, err = fs.Copy(context.WithValue(ctx, conf.NoTaskKey, struct{}{}), src, dstDir)
+	if errors.Is(errors.Cause(err), errs.Locked) {
+		return StatusLocked, err
+	}
 	if err != nil {
 		return http.StatusInternalServerError, err
 	}
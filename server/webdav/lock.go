@@ -416,6 +416,14 @@ func (b *byExpiry) Pop() interface{} {
 
 const infiniteTimeout = -1
 
+// tempLockTimeout bounds the locks a request takes for itself, so that those left behind by a
+// crashed instance in a shared LockSystem don't lock their resources forever.
+const tempLockTimeout = time.Hour
+
+// maxLockTimeout caps the timeout clients ask for, RFC 4918 lets the server grant a shorter one.
+// A lock its client forgot about then doesn't keep its resource locked for everyone.
+const maxLockTimeout = tempLockTimeout
+
 // parseTimeout parses the Timeout HTTP header, as per section 10.7. If s is
 // empty, an infiniteTimeout is returned.
 func parseTimeout(s string) (time.Duration, error) {
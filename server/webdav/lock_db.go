@@ -0,0 +1,142 @@
+package webdav
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"gorm.io/gorm"
+)
+
+// NewDBLS returns a LockSystem that keeps its locks in the database. They survive restarts,
+// are shared by the instances using the same database and are respected by the other
+// protocols, internal/fs refuses to change a locked resource.
+//
+// Which locks are held by a Confirm call is only known to this instance.
+func NewDBLS() LockSystem {
+	return &dbLS{held: make(map[string]bool)}
+}
+
+type dbLS struct {
+	mu   sync.Mutex
+	held map[string]bool
+}
+
+func (m *dbLS) Confirm(now time.Time, name0, name1 string, conditions ...Condition) (func(), error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var t0, t1 string
+	var err error
+	if name0 != "" {
+		if t0, err = m.lookup(now, slashClean(name0), conditions...); err != nil || t0 == "" {
+			return nil, confirmErr(err)
+		}
+	}
+	if name1 != "" {
+		if t1, err = m.lookup(now, slashClean(name1), conditions...); err != nil || t1 == "" {
+			return nil, confirmErr(err)
+		}
+	}
+
+	// Don't hold the same lock twice.
+	if t1 == t0 {
+		t1 = ""
+	}
+	for _, t := range []string{t0, t1} {
+		if t != "" {
+			m.held[t] = true
+		}
+	}
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		delete(m.held, t0)
+		delete(m.held, t1)
+	}, nil
+}
+
+func confirmErr(err error) error {
+	if err != nil {
+		return err
+	}
+	return ErrConfirmationFailed
+}
+
+// lookup returns the token of the lock on the named resource that matches one of the
+// conditions and isn't held, an empty token if there is none.
+func (m *dbLS) lookup(now time.Time, name string, conditions ...Condition) (string, error) {
+	// TODO: support Condition.Not and Condition.ETag.
+	for _, c := range conditions {
+		if c.Token == "" || m.held[c.Token] {
+			continue
+		}
+		l, err := op.GetWebDAVLock(now, c.Token)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if l.Covers(name) {
+			return l.Token, nil
+		}
+	}
+	return "", nil
+}
+
+func (m *dbLS) Create(now time.Time, details LockDetails) (string, error) {
+	l := &model.WebDAVLock{
+		Root:      slashClean(details.Root),
+		ZeroDepth: details.ZeroDepth,
+		OwnerXML:  details.OwnerXML,
+		Duration:  details.Duration,
+	}
+	if err := op.CreateWebDAVLock(now, l); err != nil {
+		if errors.Is(err, errs.Locked) {
+			return "", ErrLocked
+		}
+		return "", err
+	}
+	return l.Token, nil
+}
+
+func (m *dbLS) Refresh(now time.Time, token string, duration time.Duration) (LockDetails, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.held[token] {
+		return LockDetails{}, ErrLocked
+	}
+	l, err := op.RefreshWebDAVLock(now, token, duration)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return LockDetails{}, ErrNoSuchLock
+	}
+	if err != nil {
+		return LockDetails{}, err
+	}
+	return LockDetails{
+		Root:      l.Root,
+		Duration:  l.Duration,
+		OwnerXML:  l.OwnerXML,
+		ZeroDepth: l.ZeroDepth,
+	}, nil
+}
+
+func (m *dbLS) Unlock(now time.Time, token string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.held[token] {
+		return ErrLocked
+	}
+	_, err := op.GetWebDAVLock(now, token)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNoSuchLock
+	}
+	if err != nil {
+		return err
+	}
+	return op.DeleteWebDAVLock(token)
+}
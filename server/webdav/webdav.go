@@ -17,6 +17,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/net"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
@@ -107,7 +108,7 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) lock(now time.Time, root string) (token string, status int, err error) {
 	token, err = h.LockSystem.Create(now, LockDetails{
 		Root:      root,
-		Duration:  infiniteTimeout,
+		Duration:  tempLockTimeout,
 		ZeroDepth: true,
 	})
 	if err != nil {
@@ -119,7 +120,10 @@ func (h *Handler) lock(now time.Time, root string) (token string, status int, er
 	return token, 0, nil
 }
 
-func (h *Handler) confirmLocks(r *http.Request, src, dst string) (release func(), status int, err error) {
+// confirmLocks claims the locks of src and dst, full paths, for the request. The returned ctx
+// tells internal/fs the tokens of the claimed locks so that it lets the request through.
+func (h *Handler) confirmLocks(r *http.Request, src, dst string) (ctx context.Context, release func(), status int, err error) {
+	ctx = r.Context()
 	hdr := r.Header.Get("If")
 	if hdr == "" {
 		// An empty If header means that the client hasn't previously created locks.
@@ -131,7 +135,7 @@ func (h *Handler) confirmLocks(r *http.Request, src, dst string) (release func()
 		if src != "" {
 			srcToken, status, err = h.lock(now, src)
 			if err != nil {
-				return nil, status, err
+				return nil, nil, status, err
 			}
 		}
 		if dst != "" {
@@ -140,11 +144,12 @@ func (h *Handler) confirmLocks(r *http.Request, src, dst string) (release func()
 				if srcToken != "" {
 					h.LockSystem.Unlock(now, srcToken)
 				}
-				return nil, status, err
+				return nil, nil, status, err
 			}
 		}
 
-		return func() {
+		ctx = context.WithValue(ctx, conf.LockTokensKey, []string{srcToken, dstToken})
+		return ctx, func() {
 			if dstToken != "" {
 				h.LockSystem.Unlock(now, dstToken)
 			}
@@ -156,8 +161,9 @@ func (h *Handler) confirmLocks(r *http.Request, src, dst string) (release func()
 
 	ih, ok := parseIfHeader(hdr)
 	if !ok {
-		return nil, http.StatusBadRequest, errInvalidIfHeader
+		return nil, nil, http.StatusBadRequest, errInvalidIfHeader
 	}
+	user := ctx.Value(conf.UserKey).(*model.User)
 	// ih is a disjunction (OR) of ifLists, so any ifList will do.
 	for _, l := range ih.lists {
 		lsrc := l.resourceTag
@@ -173,7 +179,11 @@ func (h *Handler) confirmLocks(r *http.Request, src, dst string) (release func()
 			}
 			lsrc, status, err = h.stripPrefix(u.Path)
 			if err != nil {
-				return nil, status, err
+				return nil, nil, status, err
+			}
+			lsrc, err = user.JoinPath(lsrc)
+			if err != nil {
+				return nil, nil, http.StatusForbidden, err
 			}
 		}
 		release, err = h.LockSystem.Confirm(time.Now(), lsrc, dst, l.conditions...)
@@ -181,15 +191,21 @@ func (h *Handler) confirmLocks(r *http.Request, src, dst string) (release func()
 			continue
 		}
 		if err != nil {
-			return nil, http.StatusInternalServerError, err
+			return nil, nil, http.StatusInternalServerError, err
 		}
-		return release, 0, nil
+		tokens := make([]string, 0, len(l.conditions))
+		for _, c := range l.conditions {
+			if !c.Not && c.Token != "" {
+				tokens = append(tokens, c.Token)
+			}
+		}
+		return context.WithValue(ctx, conf.LockTokensKey, tokens), release, 0, nil
 	}
 	// Section 10.4.1 says that "If this header is evaluated and all state lists
 	// fail, then the request must fail with a 412 (Precondition Failed) status."
 	// We follow the spec even though the cond_put_corrupt_token test case from
 	// the litmus test warns on seeing a 412 instead of a 423 (Locked).
-	return nil, http.StatusPreconditionFailed, ErrLocked
+	return nil, nil, http.StatusPreconditionFailed, ErrLocked
 }
 
 func (h *Handler) handleOptions(w http.ResponseWriter, r *http.Request) (status int, err error) {
@@ -251,6 +267,9 @@ func (h *Handler) handleGetHeadPost(w http.ResponseWriter, r *http.Request) (sta
 		}
 		return http.StatusMethodNotAllowed, nil
 	}
+	if r.Method == http.MethodGet {
+		defer func() { audit.LogDownload(ctx, reqPath, fi.GetSize(), err) }()
+	}
 	// Let ServeContent determine the Content-Type header.
 	storage, _ := fs.GetStorage(reqPath, &fs.GetStoragesArgs{})
 	if storage.GetStorage().Webdav302() {
@@ -295,14 +314,7 @@ func (h *Handler) handleDelete(w http.ResponseWriter, r *http.Request) (status i
 	if err != nil {
 		return status, err
 	}
-	release, status, err := h.confirmLocks(r, reqPath, "")
-	if err != nil {
-		return status, err
-	}
-	defer release()
-
-	ctx := r.Context()
-	user := ctx.Value(conf.UserKey).(*model.User)
+	user := r.Context().Value(conf.UserKey).(*model.User)
 	if !user.CanRemove() {
 		return http.StatusForbidden, nil
 	}
@@ -310,6 +322,11 @@ func (h *Handler) handleDelete(w http.ResponseWriter, r *http.Request) (status i
 	if err != nil {
 		return http.StatusForbidden, err
 	}
+	ctx, release, status, err := h.confirmLocks(r, reqPath, "")
+	if err != nil {
+		return status, err
+	}
+	defer release()
 	// TODO: return MultiStatus where appropriate.
 
 	// "godoc os RemoveAll" says that "If the path does not exist, RemoveAll
@@ -330,6 +347,9 @@ func (h *Handler) handleDelete(w http.ResponseWriter, r *http.Request) (status i
 		return http.StatusForbidden, errs.PermissionDenied
 	}
 	if err := fs.Remove(ctx, reqPath); err != nil {
+		if errors.Is(errors.Cause(err), errs.Locked) {
+			return StatusLocked, err
+		}
 		return http.StatusMethodNotAllowed, err
 	}
 	//fs.ClearCache(path.Dir(reqPath))
@@ -350,19 +370,18 @@ func (h *Handler) handlePut(w http.ResponseWriter, r *http.Request) (status int,
 	if reqPath == "" {
 		return http.StatusMethodNotAllowed, nil
 	}
-	release, status, err := h.confirmLocks(r, reqPath, "")
+	user := r.Context().Value(conf.UserKey).(*model.User)
+	reqPath, err = user.JoinPath(reqPath)
+	if err != nil {
+		return http.StatusForbidden, err
+	}
+	ctx, release, status, err := h.confirmLocks(r, reqPath, "")
 	if err != nil {
 		return status, err
 	}
 	defer release()
 	// TODO(rost): Support the If-Match, If-None-Match headers? See bradfitz'
 	// comments in http.checkEtag.
-	ctx := r.Context()
-	user := ctx.Value(conf.UserKey).(*model.User)
-	reqPath, err = user.JoinPath(reqPath)
-	if err != nil {
-		return http.StatusForbidden, err
-	}
 	size := r.ContentLength
 	if size < 0 {
 		sizeStr := r.Header.Get("X-File-Size")
@@ -406,6 +425,12 @@ func (h *Handler) handlePut(w http.ResponseWriter, r *http.Request) (status int,
 	if errs.IsNotFoundError(err) {
 		return http.StatusNotFound, err
 	}
+	if errors.Is(errors.Cause(err), errs.QuotaExceeded) {
+		return http.StatusInsufficientStorage, err
+	}
+	if errors.Is(errors.Cause(err), errs.Locked) {
+		return StatusLocked, err
+	}
 
 	// TODO(rost): Returning 405 Method Not Allowed might not be appropriate.
 	if err != nil {
@@ -428,18 +453,16 @@ func (h *Handler) handleMkcol(w http.ResponseWriter, r *http.Request) (status in
 	if err != nil {
 		return status, err
 	}
-	release, status, err := h.confirmLocks(r, reqPath, "")
-	if err != nil {
-		return status, err
-	}
-	defer release()
-
-	ctx := r.Context()
-	user := ctx.Value(conf.UserKey).(*model.User)
+	user := r.Context().Value(conf.UserKey).(*model.User)
 	reqPath, err = user.JoinPath(reqPath)
 	if err != nil {
 		return http.StatusForbidden, err
 	}
+	ctx, release, status, err := h.confirmLocks(r, reqPath, "")
+	if err != nil {
+		return status, err
+	}
+	defer release()
 
 	if r.ContentLength > 0 {
 		return http.StatusUnsupportedMediaType, nil
@@ -508,8 +531,7 @@ func (h *Handler) handleCopyMove(w http.ResponseWriter, r *http.Request) (status
 		return http.StatusForbidden, errDestinationEqualsSource
 	}
 
-	ctx := r.Context()
-	user := ctx.Value(conf.UserKey).(*model.User)
+	user := r.Context().Value(conf.UserKey).(*model.User)
 	src, err = user.JoinPath(src)
 	if err != nil {
 		return http.StatusForbidden, err
@@ -525,7 +547,7 @@ func (h *Handler) handleCopyMove(w http.ResponseWriter, r *http.Request) (status
 		// even though a COPY doesn't modify the source, if a concurrent
 		// operation modifies the source. However, the litmus test explicitly
 		// checks that COPYing a locked-by-another source is OK.
-		release, status, err := h.confirmLocks(r, "", dst)
+		ctx, release, status, err := h.confirmLocks(r, "", dst)
 		if err != nil {
 			return status, err
 		}
@@ -545,7 +567,7 @@ func (h *Handler) handleCopyMove(w http.ResponseWriter, r *http.Request) (status
 		return copyFiles(ctx, src, dst, r.Header.Get("Overwrite") != "F")
 	}
 
-	release, status, err := h.confirmLocks(r, src, dst)
+	ctx, release, status, err := h.confirmLocks(r, src, dst)
 	if err != nil {
 		return status, err
 	}
@@ -567,6 +589,9 @@ func (h *Handler) handleLock(w http.ResponseWriter, r *http.Request) (retStatus
 	if err != nil {
 		return http.StatusBadRequest, err
 	}
+	if duration < 0 || duration > maxLockTimeout {
+		duration = maxLockTimeout
+	}
 	li, status, err := readLockInfo(r.Body)
 	if err != nil {
 		return status, err
@@ -758,7 +783,7 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 		}
 		var pstats []Propstat
 		if pf.Propname != nil {
-			pnames, err := propnames(ctx, h.LockSystem, info)
+			pnames, err := propnames(ctx, h.LockSystem, reqPath, info)
 			if err != nil {
 				return err
 			}
@@ -768,14 +793,14 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 			}
 			pstats = append(pstats, pstat)
 		} else if pf.Allprop != nil {
-			pstats, err = allprop(ctx, h.LockSystem, info, pf.Prop)
+			pstats, err = allprop(ctx, h.LockSystem, reqPath, info, pf.Prop)
 		} else {
-			pstats, err = props(ctx, h.LockSystem, info, pf.Prop)
+			pstats, err = props(ctx, h.LockSystem, reqPath, info, pf.Prop)
 		}
 		if err != nil {
 			return err
 		}
-		href := path.Join(h.Prefix, strings.TrimPrefix(reqPath, user.BasePath))
+		href := path.Join(h.Prefix, strings.TrimPrefix(reqPath, user.GetBasePath()))
 		if href != "/" && info.IsDir() {
 			href += "/"
 		}
@@ -798,18 +823,17 @@ func (h *Handler) handleProppatch(w http.ResponseWriter, r *http.Request) (statu
 	if err != nil {
 		return status, err
 	}
-	release, status, err := h.confirmLocks(r, reqPath, "")
+	user := r.Context().Value(conf.UserKey).(*model.User)
+	reqPath, err = user.JoinPath(reqPath)
+	if err != nil {
+		return http.StatusForbidden, err
+	}
+	ctx, release, status, err := h.confirmLocks(r, reqPath, "")
 	if err != nil {
 		return status, err
 	}
 	defer release()
 
-	ctx := r.Context()
-	user := ctx.Value(conf.UserKey).(*model.User)
-	reqPath, err = user.JoinPath(reqPath)
-	if err != nil {
-		return http.StatusForbidden, err
-	}
 	meta, err := op.GetNearestMeta(reqPath)
 	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
 		return http.StatusInternalServerError, err